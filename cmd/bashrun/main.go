@@ -17,7 +17,6 @@ import (
 	"github.com/golang-migrate/migrate/v4"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"github.com/swaggo/swag"
-	"golang.org/x/sync/semaphore"
 
 	"github.com/PoorMercymain/bashrun/docs"
 	"github.com/PoorMercymain/bashrun/internal/bashrun/config"
@@ -71,22 +70,69 @@ func main() {
 	pg := repository.NewPostgres(pool)
 
 	var wg sync.WaitGroup
-	sem := semaphore.NewWeighted(cfg.MaxConcurrentCommands)
 	commandContext, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	r := repository.New(pg)
-	s := service.New(commandContext, r, sem, &wg)
+	s := service.New(commandContext, r, &wg, &cfg)
 	h := handler.New(s)
 
+	summary, err := s.RecoverCommands(context.Background())
+	if err != nil {
+		logger.Logger().Fatalln(err.Error())
+	}
+
+	logger.Logger().Infof("Orphaned commands recovered: %d lost, %d reattached, %d requeued, %d expired", summary.Lost, summary.Reattached, summary.Requeued, summary.Expired)
+
+	workerContext, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	workersDone := make(chan struct{})
+	go func() {
+		s.RunWorkers(workerContext)
+		close(workersDone)
+	}()
+
+	deliveryContext, stopDeliveries := context.WithCancel(context.Background())
+	defer stopDeliveries()
+
+	deliveriesDone := make(chan struct{})
+	go func() {
+		s.DeliverCallbacks(deliveryContext)
+		close(deliveriesDone)
+	}()
+
+	schedulerContext, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+
+	schedulerDone := make(chan struct{})
+	go func() {
+		s.RunScheduler(schedulerContext)
+		close(schedulerDone)
+	}()
+
 	mux := http.NewServeMux()
 
 	mux.Handle("GET /ping", http.HandlerFunc(h.Ping))
 	mux.Handle("POST /commands", http.HandlerFunc(h.CreateCommand))
+	mux.Handle("POST /commands/script", http.HandlerFunc(h.CreateScript))
 	mux.Handle("GET /commands", http.HandlerFunc(h.ListCommands))
 	mux.Handle("GET /commands/stop/{command_id}", http.HandlerFunc(h.StopCommand))
 	mux.Handle("GET /commands/{command_id}", http.HandlerFunc(h.ReadCommand))
 	mux.Handle("GET /commands/output/{command_id}", http.HandlerFunc(h.ReadOutput))
+	mux.Handle("GET /commands/{command_id}/{subresource}", http.HandlerFunc(h.CommandSubresource))
+	mux.Handle("POST /schedules", http.HandlerFunc(h.CreateSchedule))
+	mux.Handle("GET /schedules", http.HandlerFunc(h.ListSchedules))
+	mux.Handle("GET /schedules/{schedule_id}", http.HandlerFunc(h.ReadSchedule))
+	mux.Handle("POST /schedules/{schedule_id}/pause", http.HandlerFunc(h.PauseSchedule))
+	mux.Handle("POST /schedules/{schedule_id}/resume", http.HandlerFunc(h.ResumeSchedule))
+	mux.Handle("DELETE /schedules/{schedule_id}", http.HandlerFunc(h.DeleteSchedule))
+	mux.Handle("POST /workflows", http.HandlerFunc(h.CreateWorkflow))
+	mux.Handle("GET /workflows", http.HandlerFunc(h.ListWorkflows))
+	mux.Handle("GET /workflows/{workflow_id}", http.HandlerFunc(h.ReadWorkflow))
+	mux.Handle("POST /pipelines", http.HandlerFunc(h.CreatePipeline))
+	mux.Handle("GET /pipelines", http.HandlerFunc(h.ListPipelines))
+	mux.Handle("GET /pipelines/{pipeline_id}", http.HandlerFunc(h.ReadPipeline))
 	mux.Handle("/swagger/*", httpSwagger.WrapHandler)
 
 	server := &http.Server{
@@ -117,11 +163,17 @@ func main() {
 		logger.Logger().Errorln("error while shutting down server:", err.Error())
 	}
 
+	stopScheduler()
+	<-schedulerDone
+
+	stopWorkers()
+
 	ctx, cancel = context.WithTimeout(commandContext, time.Second*5)
 	defer cancel()
 
 	wgChan := make(chan struct{})
 	go func() {
+		<-workersDone
 		wg.Wait()
 		wgChan <- struct{}{}
 	}()
@@ -132,6 +184,9 @@ func main() {
 		logger.Logger().Errorln("some of the commands were interrupted")
 	}
 
+	stopDeliveries()
+	<-deliveriesDone
+
 	ctx, cancel = context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
@@ -62,6 +62,135 @@ const DocTemplate = `{
                     "description": "Запускаемая команда",
                     "example": "ls"
                   },
+                  "timeout_seconds": {
+                    "type": "integer",
+                    "description": "Таймаут выполнения в секундах, по истечении группе процессов команды отправляется SIGTERM, а после kill_grace_seconds - SIGKILL, статус становится timed_out. Если не указан, используется значение по умолчанию из конфигурации, не может превышать максимум из конфигурации",
+                    "example": 60
+                  },
+                  "kill_grace_seconds": {
+                    "type": "integer",
+                    "description": "Время в секундах между SIGTERM и SIGKILL при таймауте",
+                    "example": 5
+                  },
+                  "env": {
+                      "type": "object",
+                      "description": "Переменные окружения команды",
+                      "example": {"FOO": "bar"},
+                      "additionalProperties": {
+                          "type": "string"
+                      }
+                  },
+                  "workdir": {
+                      "type": "string",
+                      "description": "Рабочая директория команды (должна существовать)",
+                      "example": "/tmp"
+                  },
+                  "clear_env": {
+                      "type": "boolean",
+                      "description": "Запустить команду без переменных окружения сервиса, только с переданными в env",
+                      "example": false
+                  },
+                  "stdin": {
+                      "type": "string",
+                      "description": "Текст, передаваемый команде в stdin",
+                      "example": "hello"
+                  },
+                  "stdin_base64": {
+                      "type": "string",
+                      "description": "Данные для stdin в base64 (нельзя указывать вместе с stdin)",
+                      "example": "aGVsbG8K"
+                  },
+                  "interpreter": {
+                      "type": "string",
+                      "description": "Интерпретатор команды: sh (по умолчанию), bash, zsh, python3 или путь из ALLOWED_INTERPRETERS",
+                      "example": "bash"
+                  },
+                  "argv": {
+                      "type": "array",
+                      "description": "Запуск программы с явным массивом аргументов без разбора командной оболочкой (указывается вместо command и interpreter)",
+                      "example": ["echo", "hello world"],
+                      "items": {
+                          "type": "string"
+                      }
+                  },
+                  "labels": {
+                    "type": "array",
+                    "items": {
+                      "type": "string"
+                    },
+                    "description": "Метки команды, по которым можно фильтровать список",
+                    "example": ["nightly"]
+                  },
+                  "max_output_bytes": {
+                      "type": "integer",
+                      "description": "Максимальный размер сохраняемого вывода (stdout и stderr вместе) в байтах. Если не указан, используется значение из конфигурации, не может его превышать",
+                      "example": 1048576
+                  },
+                  "output_policy": {
+                      "type": "string",
+                      "description": "Что делать при превышении max_output_bytes: head - сохранить начало вывода, tail - сохранить конец вывода, kill - сохранить начало и завершить команду со статусом output_limit_exceeded. По умолчанию берется из конфигурации",
+                      "example": "tail",
+                      "enum": ["head", "tail", "kill"]
+                  },
+                  "callback_url": {
+                      "type": "string",
+                      "description": "Адрес (http или https), на который после завершения команды отправляется POST-запрос с JSON (id, статус, код завершения, время выполнения и последние 4 КиБ stdout и stderr). Доставки сохраняются в БД и повторяются с экспоненциальной задержкой до получения ответа 2xx",
+                      "example": "https://example.com/hooks/bashrun"
+                  },
+                  "callback_secret": {
+                      "type": "string",
+                      "description": "Секрет для подписи запроса: HMAC-SHA256 от тела передается в заголовке X-Bashrun-Signature в виде sha256=<hex>. Можно указать только вместе с callback_url",
+                      "example": "s3cr3t"
+                  },
+                  "priority": {
+                      "type": "integer",
+                      "description": "Приоритет в очереди от -1000 до 1000 (по умолчанию 0). Команды с большим приоритетом запускаются раньше, при равном приоритете - в порядке создания",
+                      "example": 10
+                  },
+                  "run_at": {
+                      "type": "string",
+                      "format": "date-time",
+                      "description": "Время запуска (RFC 3339). До этого времени команда находится в статусе scheduled и ее можно остановить через GET /commands/stop/{command_id}. Время хранится в БД, поэтому отложенные команды переживают перезапуск сервиса. Нельзя указывать вместе с delay_seconds",
+                      "example": "2024-05-01T03:00:00+03:00"
+                  },
+                  "delay_seconds": {
+                      "type": "integer",
+                      "description": "Задержка запуска в секундах от момента создания команды. Нельзя указывать вместе с run_at",
+                      "example": 600
+                  },
+                  "retry": {
+                      "type": "object",
+                      "description": "Политика повторов. Каждая попытка создается отдельной командой с parent_id и attempt (см. GET /commands/{command_id}/attempts) со своим выводом, кодом выхода и временем выполнения. Исходная команда сама не запускается: она переходит в started при запуске первой попытки, а после последней получает ее статус, код выхода и ошибку. Вывод исходной команды - вывод последней попытки, уведомление callback_url отправляется один раз по итоговому результату. Остановка исходной команды останавливает текущую попытку",
+                      "properties": {
+                          "max_attempts": {
+                              "type": "integer",
+                              "description": "Максимальное количество попыток от 1 до 100, включая первую"
+                          },
+                          "backoff": {
+                              "type": "string",
+                              "enum": ["fixed", "exponential"],
+                              "description": "Задержка между попытками: fixed - всегда delay_seconds, exponential (по умолчанию) - delay_seconds, удваиваемая после каждой попытки"
+                          },
+                          "delay_seconds": {
+                              "type": "integer",
+                              "description": "Задержка перед второй попыткой в секундах (по умолчанию 0)"
+                          },
+                          "max_delay_seconds": {
+                              "type": "integer",
+                              "description": "Верхняя граница задержки в секундах (0 - без ограничения, но не больше суток)"
+                          },
+                          "jitter": {
+                              "type": "boolean",
+                              "description": "Выбирать случайную задержку от половины до полной"
+                          },
+                          "exit_codes": {
+                              "type": "array",
+                              "items": {"type": "integer"},
+                              "description": "Коды выхода от 1 до 255, при которых команда повторяется. Если не указаны, повтор выполняется при любом ненулевом коде. Остановленные, прерванные по таймауту и другие не завершившиеся сами команды не повторяются"
+                          }
+                      },
+                      "example": {"max_attempts": 3, "backoff": "exponential", "delay_seconds": 5, "jitter": true, "exit_codes": [75]}
+                  }
                 }
               }
             }
@@ -118,7 +247,7 @@ const DocTemplate = `{
         }
       },
       "get": {
-        "description": "Запрос для получения списка с информацией о командах. Можно использовать лимит и оффсет, по умолчанию используется лимит 15, оффест 0, а максимальный лимит - 50. Список отсортирован по id в порядке возрастания",
+        "description": "Запрос для получения списка с информацией о командах. Можно использовать лимит и оффсет, по умолчанию используется лимит 15, оффест 0, а максимальный лимит - 50. По умолчанию список отсортирован по id в порядке возрастания, также его можно фильтровать по статусу, коду завершения, времени создания, команде и меткам, сортировать по id, времени создания или длительности и искать по выводу команды",
         "tags": [
             "Commands"
         ],
@@ -142,10 +271,173 @@ const DocTemplate = `{
                   "description": "Оффсет (сдвиг), минимум - 0, по умолчанию - 0"
                 }
             },
+            {
+                "in": "query",
+                "name": "status",
+                "required": false,
+                "schema": {
+                  "type": "string",
+                  "description": "Фильтр по статусу",
+                  "enum": ["scheduled", "created", "started", "done", "stopped", "timed_out", "output_limit_exceeded", "failed", "lost"]
+                }
+            },
+            {
+                "in": "query",
+                "name": "exit_status",
+                "required": false,
+                "schema": {
+                  "type": "integer",
+                  "description": "Фильтр по коду завершения"
+                }
+            },
+            {
+                "in": "query",
+                "name": "created_from",
+                "required": false,
+                "schema": {
+                  "type": "string",
+                  "format": "date-time",
+                  "description": "Начало диапазона времени создания (RFC 3339)"
+                }
+            },
+            {
+                "in": "query",
+                "name": "created_to",
+                "required": false,
+                "schema": {
+                  "type": "string",
+                  "format": "date-time",
+                  "description": "Конец диапазона времени создания (RFC 3339)"
+                }
+            },
+            {
+                "in": "query",
+                "name": "command",
+                "required": false,
+                "schema": {
+                  "type": "string",
+                  "description": "Подстрока команды"
+                }
+            },
+            {
+                "in": "query",
+                "name": "command_regex",
+                "required": false,
+                "schema": {
+                  "type": "string",
+                  "description": "Регулярное выражение (POSIX) для команды"
+                }
+            },
+            {
+                "in": "query",
+                "name": "label",
+                "required": false,
+                "schema": {
+                  "type": "string",
+                  "description": "Фильтр по метке"
+                }
+            },
+            {
+                "in": "query",
+                "name": "schedule_id",
+                "required": false,
+                "schema": {
+                  "type": "integer",
+                  "description": "Только запуски расписания с этим идентификатором"
+                }
+            },
+            {
+                "in": "query",
+                "name": "workflow_id",
+                "required": false,
+                "schema": {
+                  "type": "integer",
+                  "description": "Только команды узлов workflow с этим идентификатором"
+                }
+            },
+            {
+                "in": "query",
+                "name": "pipeline_id",
+                "required": false,
+                "schema": {
+                  "type": "integer",
+                  "description": "Только команды шагов pipeline с этим идентификатором"
+                }
+            },
+            {
+                "in": "query",
+                "name": "q",
+                "required": false,
+                "schema": {
+                  "type": "string",
+                  "description": "Полнотекстовый поиск по выводу команды"
+                }
+            },
+            {
+                "in": "query",
+                "name": "include_output",
+                "required": false,
+                "schema": {
+                  "type": "boolean",
+                  "description": "Возвращать ли output и stderr команд, по умолчанию true. При false вывод не собирается из базы данных, и список отдается быстрее"
+                }
+            },
+            {
+                "in": "query",
+                "name": "sort",
+                "required": false,
+                "schema": {
+                  "type": "string",
+                  "enum": ["id", "created_at", "duration"],
+                  "description": "Поле сортировки: id, created_at или duration, по умолчанию - id"
+                }
+            },
+            {
+                "in": "query",
+                "name": "order",
+                "required": false,
+                "schema": {
+                  "type": "string",
+                  "enum": ["asc", "desc"],
+                  "description": "Направление сортировки: asc или desc, по умолчанию - asc"
+                }
+            },
+            {
+                "in": "query",
+                "name": "after",
+                "required": false,
+                "schema": {
+                  "type": "string",
+                  "description": "Курсор следующей страницы из заголовка Link (нельзя указывать вместе с before и offset)"
+                }
+            },
+            {
+                "in": "query",
+                "name": "before",
+                "required": false,
+                "schema": {
+                  "type": "string",
+                  "description": "Курсор предыдущей страницы из заголовка Link (нельзя указывать вместе с after и offset)"
+                }
+            }
         ],
         "responses": {
           "200": {
             "description": "Успешно найдены элементы списка",
+            "headers": {
+              "Link": {
+                "description": "Ссылки на следующую (rel=\"next\") и предыдущую (rel=\"prev\") страницы с курсорами after и before",
+                "schema": {
+                  "type": "string"
+                }
+              },
+              "X-Total-Count": {
+                "description": "Общее количество команд, подходящих под фильтры",
+                "schema": {
+                  "type": "integer"
+                }
+              }
+            },
             "content": {
               "application/json": {
                 "schema": {
@@ -160,21 +452,165 @@ const DocTemplate = `{
                             "type": "string",
                             "description": "Команда"
                         },
+                        "script": {
+                            "type": "string",
+                            "description": "Текст скрипта (для команд, созданных через /commands/script)",
+                            "example": ""
+                        },
+                        "script_args": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            },
+                            "description": "Аргументы скрипта"
+                        },
+                        "interpreter": {
+                            "type": "string",
+                            "description": "Интерпретатор команды (пустой при запуске через argv)"
+                        },
+                        "argv": {
+                            "type": "array",
+                            "description": "Программа и аргументы при запуске без командной оболочки"
+                        },
                         "pid": {
                             "type": "integer",
                             "description": "PID процесса, выполняющего команду"
                         },
                         "output": {
                             "type": "string",
-                            "description": "Вывод команды"
+                            "description": "Вывод команды (не возвращается при include_output=false)"
+                        },
+                        "stderr": {
+                            "type": "string",
+                            "description": "Вывод команды в stderr (не возвращается при include_output=false)"
                         },
                         "status": {
                             "type": "string",
-                            "description": "Статус выполнения команды"
+                            "description": "Статус выполнения команды: (scheduled ->) created -> started -> done, stopped, timed_out, output_limit_exceeded, failed или lost (scheduled - отложенная команда ждет run_at, команду в статусе scheduled или created можно остановить или она может завершиться с ошибкой до запуска, а lost выставляется при восстановлении после перезапуска сервиса)",
+                            "enum": ["scheduled", "created", "started", "done", "stopped", "timed_out", "output_limit_exceeded", "failed", "lost"]
+                        },
+                        "error": {
+                            "type": "string",
+                            "description": "Причина ошибки для статусов failed и lost"
                         },
                         "exitStatus": {
                             "type": "integer",
                             "description": "Exit код команды"
+                        },
+                        "timeout_seconds": {
+                            "type": "integer",
+                            "description": "Таймаут выполнения в секундах"
+                        },
+                        "kill_grace_seconds": {
+                            "type": "integer",
+                            "description": "Время в секундах между SIGTERM и SIGKILL при таймауте"
+                        },
+                        "created_at": {
+                            "type": "string",
+                            "format": "date-time",
+                            "description": "Время создания команды"
+                        },
+                        "run_at": {
+                            "type": "string",
+                            "format": "date-time",
+                            "description": "Время отложенного запуска (run_at или время создания плюс delay_seconds), null - команда ставится в очередь сразу"
+                        },
+                        "started_at": {
+                            "type": "string",
+                            "format": "date-time",
+                            "description": "Время запуска процесса (null, если команда не запускалась)"
+                        },
+                        "finished_at": {
+                            "type": "string",
+                            "format": "date-time",
+                            "description": "Время завершения процесса"
+                        },
+                        "queued_ms": {
+                            "type": "integer",
+                            "description": "Время ожидания запуска в миллисекундах"
+                        },
+                        "duration_ms": {
+                            "type": "integer",
+                            "description": "Время выполнения команды в миллисекундах"
+                        },
+                        "user_cpu_ms": {
+                            "type": "integer",
+                            "description": "Процессорное время в пользовательском режиме в миллисекундах"
+                        },
+                        "system_cpu_ms": {
+                            "type": "integer",
+                            "description": "Процессорное время в режиме ядра в миллисекундах"
+                        },
+                        "env": {
+                            "type": "object",
+                            "description": "Переменные окружения команды (значения ключей, содержащих подстроки из REDACTED_ENV_KEYS, скрыты)"
+                        },
+                        "workdir": {
+                            "type": "string",
+                            "description": "Рабочая директория команды"
+                        },
+                        "clear_env": {
+                            "type": "boolean",
+                            "description": "Команда запущена без переменных окружения сервиса"
+                        },
+                        "labels": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            },
+                            "description": "Метки команды"
+                        },
+                        "max_output_bytes": {
+                            "type": "integer",
+                            "description": "Максимальный размер сохраняемого вывода в байтах"
+                        },
+                        "output_policy": {
+                            "type": "string",
+                            "description": "Политика обработки превышения размера вывода (head, tail или kill)"
+                        },
+                        "truncated": {
+                            "type": "boolean",
+                            "description": "Сохраненный вывод неполный, так как превышен max_output_bytes"
+                        },
+                        "output_bytes": {
+                            "type": "integer",
+                            "description": "Сколько байт всего вывела команда (включая не сохраненные)"
+                        },
+                        "callback_url": {
+                            "type": "string",
+                            "description": "Адрес, на который отправляется уведомление о завершении команды"
+                        },
+                        "priority": {
+                            "type": "integer",
+                            "description": "Приоритет команды в очереди"
+                        },
+                        "schedule_id": {
+                            "type": "integer",
+                            "description": "Идентификатор расписания, создавшего команду, или null"
+                        },
+                        "retry": {
+                            "type": "object",
+                            "description": "Политика повторов из запроса, null - без повторов"
+                        },
+                        "parent_id": {
+                            "type": "integer",
+                            "description": "Для попытки - идентификатор исходной команды с политикой повторов, иначе null"
+                        },
+                        "attempt": {
+                            "type": "integer",
+                            "description": "Номер попытки начиная с 1, для обычных команд null"
+                        },
+                        "workflow_id": {
+                            "type": "integer",
+                            "description": "Идентификатор workflow, узлом которого является команда, или null"
+                        },
+                        "pipeline_id": {
+                            "type": "integer",
+                            "description": "Идентификатор pipeline, шагом которого является команда, или null"
+                        },
+                        "queue_position": {
+                            "type": "integer",
+                            "description": "Позиция в очереди (1 - следующая на запуск), только для команд, ожидающих запуска в GET /commands/{command_id}, иначе null"
                         }
                         }
                     }
@@ -183,10 +619,42 @@ const DocTemplate = `{
                     {
                         "command_id": 1,
                         "command": "ls",
+                        "script": "",
+                        "script_args": null,
+                        "interpreter": "sh",
+                        "argv": null,
                         "pid": 5,
                         "output": "abc",
+                        "stderr": "",
                         "status": "done",
-                        "exitStatus": 0
+                        "error": "",
+                        "exitStatus": 0,
+                        "timeout_seconds": null,
+                        "kill_grace_seconds": 5,
+                        "created_at": "2024-05-01T12:00:00Z",
+                        "started_at": "2024-05-01T12:00:01Z",
+                        "finished_at": "2024-05-01T12:00:01Z",
+                        "queued_ms": 1000,
+                        "duration_ms": 12,
+                        "user_cpu_ms": 3,
+                        "system_cpu_ms": 2,
+                        "env": {"FOO": "bar", "API_TOKEN": "***"},
+                        "workdir": "",
+                        "clear_env": false,
+                        "labels": ["nightly"],
+                        "max_output_bytes": null,
+                        "output_policy": "head",
+                        "truncated": false,
+                        "output_bytes": 3,
+                        "callback_url": "",
+                        "priority": 0,
+                        "schedule_id": null,
+                        "retry": null,
+                        "parent_id": null,
+                        "attempt": null,
+                        "workflow_id": null,
+                        "pipeline_id": null,
+                        "queue_position": null
                     }
                 ]
               }
@@ -208,7 +676,7 @@ const DocTemplate = `{
             }
           },
           "204": {
-            "description": "Не найдены команды на \"странице\" с таким лимитом и оффсетом",
+            "description": "Не найдены команды на \"странице\" с таким лимитом и оффсетом"
           },
           "500": {
             "description": "Внутренняя ошибка сервера",
@@ -230,7 +698,7 @@ const DocTemplate = `{
     },
     "/commands/stop/{command_id}": {
       "get": {
-        "description": "Запрос для остановки выполенения команды, если ее можно остановить - то она найдется по PID в отдельной горутине и получит kill (используется singleflight, чтобу минимизировать число обращений к ОС)",
+        "description": "Запрос для остановки выполенения команды, если ее можно остановить - то в отдельной горутине сигнал будет отправлен всей группе процессов команды (используется singleflight, чтобу минимизировать число обращений к ОС). Статус stopped выставляется только после того, как завершились все процессы группы",
         "tags": [
             "Commands"
         ],
@@ -244,11 +712,21 @@ const DocTemplate = `{
                   "type": "integer",
                   "description": "Идентификатор команды"
                 }
+            },
+            {
+                "in": "query",
+                "name": "signal",
+                "required": false,
+                "schema": {
+                  "type": "string",
+                  "enum": ["TERM", "INT", "HUP", "KILL"],
+                  "description": "Сигнал, отправляемый группе процессов команды, по умолчанию KILL"
+                }
             }
         ],
         "responses": {
           "202": {
-            "description": "Запрос остановки зарегистрирован и будет обработан в отдельной горутине",
+            "description": "Запрос остановки зарегистрирован и будет обработан в отдельной горутине"
           },
           "400": {
             "description": "Некорректные данные/команда и так уже не запущена",
@@ -332,6 +810,26 @@ const DocTemplate = `{
                         "type": "string",
                         "description": "Команда"
                     },
+                    "script": {
+                        "type": "string",
+                        "description": "Текст скрипта (для команд, созданных через /commands/script)",
+                        "example": ""
+                    },
+                    "script_args": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "description": "Аргументы скрипта"
+                    },
+                    "interpreter": {
+                        "type": "string",
+                        "description": "Интерпретатор команды (пустой при запуске через argv)"
+                    },
+                    "argv": {
+                        "type": "array",
+                        "description": "Программа и аргументы при запуске без командной оболочки"
+                    },
                     "pid": {
                         "type": "integer",
                         "description": "PID процесса, выполняющего команду"
@@ -340,54 +838,209 @@ const DocTemplate = `{
                         "type": "string",
                         "description": "Вывод команды"
                     },
+                    "stderr": {
+                        "type": "string",
+                        "description": "Вывод команды в stderr"
+                    },
                     "status": {
                         "type": "string",
-                        "description": "Статус выполнения команды"
+                        "description": "Статус выполнения команды: (scheduled ->) created -> started -> done, stopped, timed_out, output_limit_exceeded, failed или lost (scheduled - отложенная команда ждет run_at, команду в статусе scheduled или created можно остановить или она может завершиться с ошибкой до запуска, а lost выставляется при восстановлении после перезапуска сервиса)",
+                        "enum": ["scheduled", "created", "started", "done", "stopped", "timed_out", "output_limit_exceeded", "failed", "lost"]
+                    },
+                    "error": {
+                        "type": "string",
+                        "description": "Причина ошибки для статусов failed и lost"
                     },
                     "exitStatus": {
                         "type": "integer",
                         "description": "Exit код команды"
-                    }
-                  }
-                },
-                "example": {
-                    "command_id": 1,
-                    "command": "ls",
-                    "pid": 5,
-                    "output": "abc",
-                    "status": "done",
-                    "exitStatus": 0
-                }
-              }
-            }
-          },
-          "400": {
-            "description": "Некорректные данные",
-            "content": {
-              "application/json": {
-                "schema": {
-                  "type": "object",
-                  "properties": {
-                    "error": {
-                      "type": "string"
-                    }
-                  }
-                }
-              }
-            }
-          },
-          "404": {
-            "description": "Команда с таким id не найдена",
-            "content": {
-                "application/json": {
-                  "schema": {
-                    "type": "object",
-                    "properties": {
-                        "error": {
-                                "type": "string"
-                            }
-                        }
-                    }
+                    },
+                    "timeout_seconds": {
+                        "type": "integer",
+                        "description": "Таймаут выполнения в секундах"
+                    },
+                    "kill_grace_seconds": {
+                        "type": "integer",
+                        "description": "Время в секундах между SIGTERM и SIGKILL при таймауте"
+                    },
+                    "created_at": {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Время создания команды"
+                    },
+                    "run_at": {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Время отложенного запуска (run_at или время создания плюс delay_seconds), null - команда ставится в очередь сразу"
+                    },
+                    "started_at": {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Время запуска процесса (null, если команда не запускалась)"
+                    },
+                    "finished_at": {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Время завершения процесса"
+                    },
+                    "queued_ms": {
+                        "type": "integer",
+                        "description": "Время ожидания запуска в миллисекундах"
+                    },
+                    "duration_ms": {
+                        "type": "integer",
+                        "description": "Время выполнения команды в миллисекундах"
+                    },
+                    "user_cpu_ms": {
+                        "type": "integer",
+                        "description": "Процессорное время в пользовательском режиме в миллисекундах"
+                    },
+                    "system_cpu_ms": {
+                        "type": "integer",
+                        "description": "Процессорное время в режиме ядра в миллисекундах"
+                    },
+                    "env": {
+                        "type": "object",
+                        "description": "Переменные окружения команды (значения ключей, содержащих подстроки из REDACTED_ENV_KEYS, скрыты)"
+                    },
+                    "workdir": {
+                        "type": "string",
+                        "description": "Рабочая директория команды"
+                    },
+                    "clear_env": {
+                        "type": "boolean",
+                        "description": "Команда запущена без переменных окружения сервиса"
+                    },
+                    "labels": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "description": "Метки команды"
+                    },
+                    "max_output_bytes": {
+                        "type": "integer",
+                        "description": "Максимальный размер сохраняемого вывода в байтах"
+                    },
+                    "output_policy": {
+                        "type": "string",
+                        "description": "Политика обработки превышения размера вывода (head, tail или kill)"
+                    },
+                    "truncated": {
+                        "type": "boolean",
+                        "description": "Сохраненный вывод неполный, так как превышен max_output_bytes"
+                    },
+                    "output_bytes": {
+                        "type": "integer",
+                        "description": "Сколько байт всего вывела команда (включая не сохраненные)"
+                    },
+                    "callback_url": {
+                        "type": "string",
+                        "description": "Адрес, на который отправляется уведомление о завершении команды"
+                    },
+                    "priority": {
+                        "type": "integer",
+                        "description": "Приоритет команды в очереди"
+                    },
+                    "schedule_id": {
+                        "type": "integer",
+                        "description": "Идентификатор расписания, создавшего команду, или null"
+                    },
+                    "retry": {
+                        "type": "object",
+                        "description": "Политика повторов из запроса, null - без повторов"
+                    },
+                    "parent_id": {
+                        "type": "integer",
+                        "description": "Для попытки - идентификатор исходной команды с политикой повторов, иначе null"
+                    },
+                    "attempt": {
+                        "type": "integer",
+                        "description": "Номер попытки начиная с 1, для обычных команд null"
+                    },
+                    "workflow_id": {
+                        "type": "integer",
+                        "description": "Идентификатор workflow, узлом которого является команда, или null"
+                    },
+                    "pipeline_id": {
+                        "type": "integer",
+                        "description": "Идентификатор pipeline, шагом которого является команда, или null"
+                    },
+                    "queue_position": {
+                        "type": "integer",
+                        "description": "Позиция в очереди (1 - следующая на запуск), только для команд, ожидающих запуска в GET /commands/{command_id}, иначе null"
+                    }
+                  }
+                },
+                "example": {
+                    "command_id": 1,
+                    "command": "ls",
+                    "script": "",
+                    "script_args": null,
+                    "interpreter": "sh",
+                    "argv": null,
+                    "pid": 5,
+                    "output": "abc",
+                    "stderr": "",
+                    "status": "done",
+                    "exitStatus": 0,
+                    "timeout_seconds": null,
+                    "kill_grace_seconds": 5,
+                    "created_at": "2024-05-01T12:00:00Z",
+                    "started_at": "2024-05-01T12:00:01Z",
+                    "finished_at": "2024-05-01T12:00:01Z",
+                    "queued_ms": 1000,
+                    "duration_ms": 12,
+                    "user_cpu_ms": 3,
+                    "system_cpu_ms": 2,
+                    "env": {"FOO": "bar", "API_TOKEN": "***"},
+                    "workdir": "",
+                    "clear_env": false,
+                    "labels": ["nightly"],
+                    "max_output_bytes": null,
+                    "output_policy": "head",
+                    "truncated": false,
+                    "output_bytes": 3,
+                    "callback_url": "",
+                    "priority": 0,
+                    "schedule_id": null,
+                    "retry": null,
+                    "parent_id": null,
+                    "attempt": null,
+                    "workflow_id": null,
+                    "pipeline_id": null,
+                    "queue_position": null
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "Некорректные данные",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object",
+                  "properties": {
+                    "error": {
+                      "type": "string"
+                    }
+                  }
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "Команда с таким id не найдена",
+            "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                        "error": {
+                                "type": "string"
+                            }
+                        }
+                    }
                 }
             }
           },
@@ -407,7 +1060,7 @@ const DocTemplate = `{
             }
           }
         }
-      },
+      }
     },
     "/commands/output/{command_id}": {
         "get": {
@@ -425,18 +1078,116 @@ const DocTemplate = `{
                     "type": "integer",
                     "description": "Идентификатор команды"
                   }
+              },
+              {
+                  "in": "query",
+                  "name": "combined",
+                  "required": false,
+                  "schema": {
+                    "type": "boolean",
+                    "description": "Вернуть общий вывод stdout и stderr в порядке записи, по умолчанию false"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "offset",
+                  "required": false,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Смещение в байтах, с которого нужно начать чтение, по умолчанию 0"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "limit",
+                  "required": false,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Максимальное количество байт, по умолчанию вывод возвращается целиком"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "format",
+                  "required": false,
+                  "schema": {
+                    "type": "string",
+                    "enum": ["text", "raw"],
+                    "description": "raw - вернуть вывод как application/octet-stream (то же самое происходит, если в заголовке Accept указан application/octet-stream)"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "unit",
+                  "required": false,
+                  "schema": {
+                    "type": "string",
+                    "enum": ["bytes", "lines"],
+                    "description": "Единица измерения offset и limit, по умолчанию bytes"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "tail",
+                  "required": false,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Вернуть последние N строк, не сочетается с offset и limit"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "download",
+                  "required": false,
+                  "schema": {
+                    "type": "boolean",
+                    "description": "Отдать вывод как файл (заголовок Content-Disposition: attachment)"
+                  }
+              },
+              {
+                  "in": "header",
+                  "name": "Range",
+                  "required": false,
+                  "schema": {
+                    "type": "string",
+                    "description": "Диапазон байт в формате bytes=начало-конец, bytes=начало- или bytes=-N (последние N байт), учитывается только если в query не переданы offset, limit, unit и tail",
+                    "example": "bytes=0-1023"
+                  }
               }
           ],
           "responses": {
             "200": {
-              "description": "OK",
+              "description": "OK (вывод возвращается байт в байт, без разбиения на строки)",
                 "content": {
-                    "text/plain": {}
+                    "text/plain": {},
+                    "application/octet-stream": {}
                 }
             },
             "204": {
                 "description": "Вывод команды пуст"
             },
+            "206": {
+              "description": "Часть вывода по заголовку Range, диапазон указан в заголовке Content-Range",
+                "content": {
+                    "text/plain": {},
+                    "application/octet-stream": {}
+                }
+            },
+            "416": {
+              "description": "Диапазон из заголовка Range выходит за пределы вывода",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
             "400": {
               "description": "Некорректные данные",
               "content": {
@@ -483,7 +1234,2177 @@ const DocTemplate = `{
               }
             }
           }
-        },
+        }
+    },
+    "/commands/{command_id}/stderr": {
+        "get": {
+          "description": "Запрос для получения вывода команды в stderr по id",
+          "tags": [
+              "Commands"
+          ],
+          "summary": "Получение stderr команды по id",
+          "parameters": [
+              {
+                  "in": "path",
+                  "name": "command_id",
+                  "required": true,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Идентификатор команды"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "offset",
+                  "required": false,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Смещение в байтах, с которого нужно начать чтение, по умолчанию 0"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "limit",
+                  "required": false,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Максимальное количество байт, по умолчанию вывод возвращается целиком"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "format",
+                  "required": false,
+                  "schema": {
+                    "type": "string",
+                    "enum": ["text", "raw"],
+                    "description": "raw - вернуть вывод как application/octet-stream (то же самое происходит, если в заголовке Accept указан application/octet-stream)"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "unit",
+                  "required": false,
+                  "schema": {
+                    "type": "string",
+                    "enum": ["bytes", "lines"],
+                    "description": "Единица измерения offset и limit, по умолчанию bytes"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "tail",
+                  "required": false,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Вернуть последние N строк, не сочетается с offset и limit"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "download",
+                  "required": false,
+                  "schema": {
+                    "type": "boolean",
+                    "description": "Отдать вывод как файл (заголовок Content-Disposition: attachment)"
+                  }
+              },
+              {
+                  "in": "header",
+                  "name": "Range",
+                  "required": false,
+                  "schema": {
+                    "type": "string",
+                    "description": "Диапазон байт в формате bytes=начало-конец, bytes=начало- или bytes=-N (последние N байт), учитывается только если в query не переданы offset, limit, unit и tail",
+                    "example": "bytes=0-1023"
+                  }
+              }
+          ],
+          "responses": {
+            "200": {
+              "description": "OK (вывод возвращается байт в байт, без разбиения на строки)",
+                "content": {
+                    "text/plain": {},
+                    "application/octet-stream": {}
+                }
+            },
+            "204": {
+                "description": "stderr команды пуст"
+            },
+            "206": {
+              "description": "Часть вывода по заголовку Range, диапазон указан в заголовке Content-Range",
+                "content": {
+                    "text/plain": {},
+                    "application/octet-stream": {}
+                }
+            },
+            "416": {
+              "description": "Диапазон из заголовка Range выходит за пределы вывода",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "400": {
+              "description": "Некорректные данные",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "404": {
+              "description": "Команда с таким id не найдена",
+              "content": {
+                  "application/json": {
+                    "schema": {
+                      "type": "object",
+                      "properties": {
+                          "error": {
+                                  "type": "string"
+                              }
+                          }
+                      }
+                  }
+              }
+            },
+            "500": {
+              "description": "Внутренняя ошибка сервера",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            }
+          }
+        }
     },
+    "/commands/{command_id}/stream": {
+        "get": {
+          "description": "Server-Sent Events с выводом команды по мере выполнения: событие output на каждую новую строку stdout (id события - номер строки), status на каждую смену статуса и exit с exit кодом. При переподключении можно передать заголовок Last-Event-ID, чтобы продолжить с места обрыва без повторов и пропусков строк. Если команда уже завершилась, события формируются по данным из БД",
+          "tags": [
+              "Commands"
+          ],
+          "summary": "Стрим вывода и статуса команды",
+          "parameters": [
+              {
+                  "in": "path",
+                  "name": "command_id",
+                  "required": true,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Идентификатор команды"
+                  }
+              },
+              {
+                  "in": "header",
+                  "name": "Last-Event-ID",
+                  "required": false,
+                  "schema": {
+                    "type": "integer",
+                    "description": "id последнего полученного события"
+                  }
+              }
+          ],
+          "responses": {
+            "200": {
+              "description": "OK",
+                "content": {
+                    "text/event-stream": {}
+                }
+            },
+            "400": {
+              "description": "Некорректные данные",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "404": {
+              "description": "Команда с таким id не найдена",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "500": {
+              "description": "Внутренняя ошибка сервера",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            }
+          }
+        }
+    },
+    "/commands/script": {
+        "post": {
+          "description": "Запрос для создания команды из многострочного скрипта. Скрипт сохраняется во временный файл и запускается напрямую, если начинается с шебанга, иначе - через указанный интерпретатор (sh по умолчанию)",
+          "tags": [
+              "Commands"
+          ],
+          "summary": "Создать команду из скрипта",
+          "parameters": [
+              {
+                  "in": "query",
+                  "name": "arg",
+                  "required": false,
+                  "schema": {
+                    "type": "array",
+                    "items": {
+                      "type": "string"
+                    },
+                    "description": "Позиционные аргументы скрипта (для text/x-shellscript)"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "interpreter",
+                  "required": false,
+                  "schema": {
+                    "type": "string",
+                    "description": "Интерпретатор скрипта (для text/x-shellscript), если не указан - используется шебанг скрипта или sh"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "timeout_seconds",
+                  "required": false,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Таймаут выполнения в секундах (для text/x-shellscript)"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "kill_grace_seconds",
+                  "required": false,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Время между SIGTERM и SIGKILL в секундах (для text/x-shellscript)"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "workdir",
+                  "required": false,
+                  "schema": {
+                    "type": "string",
+                    "description": "Рабочая директория (для text/x-shellscript)"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "max_output_bytes",
+                  "required": false,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Максимальный размер сохраняемого вывода в байтах (для text/x-shellscript)"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "output_policy",
+                  "required": false,
+                  "schema": {
+                    "type": "string",
+                    "description": "Политика обработки превышения размера вывода: head, tail или kill (для text/x-shellscript)"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "callback_url",
+                  "required": false,
+                  "schema": {
+                    "type": "string",
+                    "description": "Адрес для уведомления о завершении команды (для text/x-shellscript)"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "callback_secret",
+                  "required": false,
+                  "schema": {
+                    "type": "string",
+                    "description": "Секрет для подписи уведомления (для text/x-shellscript)"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "priority",
+                  "required": false,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Приоритет в очереди от -1000 до 1000 (для text/x-shellscript)"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "run_at",
+                  "required": false,
+                  "schema": {
+                    "type": "string",
+                    "format": "date-time",
+                    "description": "Время отложенного запуска в RFC 3339 (для text/x-shellscript)"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "delay_seconds",
+                  "required": false,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Задержка запуска в секундах (для text/x-shellscript)"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "retry_max_attempts",
+                  "required": false,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Максимальное количество попыток, включает повторы (для text/x-shellscript)"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "retry_backoff",
+                  "required": false,
+                  "schema": {
+                    "type": "string",
+                    "description": "Задержка между попытками: fixed или exponential (для text/x-shellscript)"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "retry_delay_seconds",
+                  "required": false,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Задержка перед второй попыткой в секундах (для text/x-shellscript)"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "retry_max_delay_seconds",
+                  "required": false,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Верхняя граница задержки между попытками в секундах (для text/x-shellscript)"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "retry_jitter",
+                  "required": false,
+                  "schema": {
+                    "type": "boolean",
+                    "description": "Случайная задержка от половины до полной (для text/x-shellscript)"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "retry_exit_code",
+                  "required": false,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Код выхода, при котором команда повторяется, можно указать несколько раз (для text/x-shellscript)"
+                  }
+              }
+          ],
+          "requestBody": {
+          "required": true,
+          "content": {
+            "text/x-shellscript": {
+              "schema": {
+                "type": "string",
+                "description": "Текст скрипта, аргументы и параметры передаются в query",
+                "example": "#!/bin/sh\necho $1"
+              }
+            },
+            "multipart/form-data": {
+              "schema": {
+                "type": "object",
+                "required": [
+                  "script"
+                ],
+                "properties": {
+                  "script": {
+                    "type": "string",
+                    "format": "binary",
+                    "description": "Файл (или текстовое поле) со скриптом"
+                  },
+                  "arg": {
+                    "type": "array",
+                    "items": {
+                      "type": "string"
+                    },
+                    "description": "Позиционные аргументы скрипта"
+                  },
+                  "interpreter": {
+                    "type": "string",
+                    "description": "Интерпретатор скрипта"
+                  },
+                  "timeout_seconds": {
+                    "type": "integer",
+                    "description": "Таймаут выполнения в секундах"
+                  },
+                  "kill_grace_seconds": {
+                    "type": "integer",
+                    "description": "Время между SIGTERM и SIGKILL в секундах"
+                  },
+                  "workdir": {
+                    "type": "string",
+                    "description": "Рабочая директория"
+                  },
+                  "max_output_bytes": {
+                    "type": "integer",
+                    "description": "Максимальный размер сохраняемого вывода в байтах"
+                  },
+                  "output_policy": {
+                    "type": "string",
+                    "description": "Политика обработки превышения размера вывода: head, tail или kill"
+                  },
+                  "callback_url": {
+                    "type": "string",
+                    "description": "Адрес для уведомления о завершении команды"
+                  },
+                  "callback_secret": {
+                    "type": "string",
+                    "description": "Секрет для подписи уведомления"
+                  },
+                  "priority": {
+                    "type": "integer",
+                    "description": "Приоритет в очереди от -1000 до 1000"
+                  },
+                  "run_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "description": "Время отложенного запуска в RFC 3339"
+                  },
+                  "delay_seconds": {
+                    "type": "integer",
+                    "description": "Задержка запуска в секундах"
+                  },
+                  "retry_max_attempts": {
+                    "type": "integer",
+                    "description": "Максимальное количество попыток, включает повторы"
+                  },
+                  "retry_backoff": {
+                    "type": "string",
+                    "description": "Задержка между попытками: fixed или exponential"
+                  },
+                  "retry_delay_seconds": {
+                    "type": "integer",
+                    "description": "Задержка перед второй попыткой в секундах"
+                  },
+                  "retry_max_delay_seconds": {
+                    "type": "integer",
+                    "description": "Верхняя граница задержки между попытками в секундах"
+                  },
+                  "retry_jitter": {
+                    "type": "boolean",
+                    "description": "Случайная задержка от половины до полной"
+                  },
+                  "retry_exit_code": {
+                    "type": "integer",
+                    "description": "Код выхода, при котором команда повторяется, можно указать несколько раз"
+                  }
+                }
+              }
+            }
+          }
+        },
+          "responses": {
+            "202": {
+            "description": "Создание прошло успешно, скрипт запустится когда позволит семафор",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object",
+                  "properties": {
+                    "command_id": {
+                        "type": "integer",
+                        "description": "id команды",
+                        "example": 1
+                    }
+                  }
+                }
+              }
+            }
+          },
+            "400": {
+              "description": "Некорректные данные",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "413": {
+              "description": "Скрипт слишком большой",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "500": {
+              "description": "Внутренняя ошибка сервера",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            }
+          }
+        }
+    },
+    "/commands/{command_id}/wait": {
+        "get": {
+          "description": "Ожидание завершения команды (long-poll): запрос блокируется, пока команда не получит конечный статус, либо пока не истечет timeout. Команда, выполняемая этим экземпляром сервиса, ожидается через уведомление из горутины, выполняющей ее, состояние остальных команд опрашивается в БД. Если команда уже завершилась, ответ возвращается сразу",
+          "tags": [
+              "Commands"
+          ],
+          "summary": "Ожидание завершения команды",
+          "parameters": [
+              {
+                  "in": "path",
+                  "name": "command_id",
+                  "required": true,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Идентификатор команды"
+                  }
+              },
+              {
+                  "in": "query",
+                  "name": "timeout",
+                  "required": false,
+                  "schema": {
+                    "type": "string",
+                    "description": "Максимальное время ожидания (например, 30s или 2m, число без единицы измерения считается секундами), по умолчанию 30s, не может превышать MAX_WAIT_SECONDS"
+                  }
+              }
+          ],
+          "responses": {
+            "200": {
+              "description": "Команда завершилась",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "description": "Команда в том же формате, что и в GET /commands/{command_id}"
+                  }
+                }
+              }
+            },
+            "202": {
+              "description": "Таймаут ожидания истек, команда еще выполняется (возвращается текущее состояние)",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "description": "Команда в том же формате, что и в GET /commands/{command_id}"
+                  }
+                }
+              }
+            },
+            "400": {
+              "description": "Некорректные данные",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "404": {
+              "description": "Команда с таким id не найдена",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "500": {
+              "description": "Внутренняя ошибка сервера",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            }
+          }
+        }
+    },
+    "/commands/{command_id}/attempts": {
+        "get": {
+          "description": "Получение попыток команды с политикой повторов (retry). Каждая попытка - отдельная команда со своим выводом (GET /commands/{command_id}/output), кодом выхода и временем выполнения. Следующая попытка создается в статусе scheduled с run_at, равным времени окончания предыдущей плюс задержка",
+          "tags": [
+              "Commands"
+          ],
+          "summary": "Получение попыток команды",
+          "parameters": [
+              {
+                  "in": "path",
+                  "name": "command_id",
+                  "required": true,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Идентификатор исходной команды"
+                  }
+              }
+          ],
+          "responses": {
+            "200": {
+              "description": "Список попыток в порядке номеров в формате GET /commands/{command_id}, пустой для команд без повторов",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "array",
+                    "items": {
+                      "type": "object"
+                    }
+                  },
+                  "example": [
+                    {
+                      "command_id": 2,
+                      "command": "curl -f https://example.com",
+                      "status": "done",
+                      "exitStatus": 22,
+                      "created_at": "2024-05-01T12:00:00Z",
+                      "started_at": "2024-05-01T12:00:01Z",
+                      "finished_at": "2024-05-01T12:00:02Z",
+                      "parent_id": 1,
+                      "attempt": 1
+                    },
+                    {
+                      "command_id": 3,
+                      "command": "curl -f https://example.com",
+                      "status": "done",
+                      "exitStatus": 0,
+                      "created_at": "2024-05-01T12:00:02Z",
+                      "run_at": "2024-05-01T12:00:07Z",
+                      "started_at": "2024-05-01T12:00:07Z",
+                      "finished_at": "2024-05-01T12:00:08Z",
+                      "parent_id": 1,
+                      "attempt": 2
+                    }
+                  ]
+                }
+              }
+            },
+            "400": {
+              "description": "Некорректные данные",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "404": {
+              "description": "Команда с таким id не найдена",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "500": {
+              "description": "Внутренняя ошибка сервера",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            }
+          }
+        }
+    },
+    "/commands/{command_id}/deliveries": {
+        "get": {
+          "description": "Получение истории доставок уведомлений о завершении команды (callback_url). Уведомления сохраняются в БД перед отправкой, поэтому переживают перезапуск сервиса, а неудачные попытки повторяются с экспоненциальной задержкой (от 1 секунды до 1 часа)",
+          "tags": [
+              "Commands"
+          ],
+          "summary": "Получение доставок уведомлений",
+          "parameters": [
+              {
+                  "in": "path",
+                  "name": "command_id",
+                  "required": true,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Идентификатор команды"
+                  }
+              }
+          ],
+          "responses": {
+            "200": {
+              "description": "Список доставок уведомлений в порядке создания",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "array",
+                    "items": {
+                      "type": "object",
+                      "properties": {
+                        "delivery_id": {
+                          "type": "integer",
+                          "description": "Идентификатор доставки"
+                        },
+                        "command_id": {
+                          "type": "integer",
+                          "description": "Идентификатор команды"
+                        },
+                        "url": {
+                          "type": "string",
+                          "description": "Адрес уведомления"
+                        },
+                        "payload": {
+                          "type": "object",
+                          "description": "Отправляемое тело запроса"
+                        },
+                        "status": {
+                          "type": "string",
+                          "description": "Статус доставки: pending - ожидает отправки или повтора, delivered - получен ответ 2xx, failed - исчерпаны попытки (CALLBACK_MAX_ATTEMPTS)",
+                          "enum": ["pending", "delivered", "failed"]
+                        },
+                        "attempts": {
+                          "type": "integer",
+                          "description": "Количество сделанных попыток"
+                        },
+                        "response_status": {
+                          "type": "integer",
+                          "description": "HTTP-статус последнего ответа"
+                        },
+                        "last_error": {
+                          "type": "string",
+                          "description": "Ошибка последней попытки"
+                        },
+                        "created_at": {
+                          "type": "string",
+                          "format": "date-time",
+                          "description": "Время создания доставки"
+                        },
+                        "last_attempt_at": {
+                          "type": "string",
+                          "format": "date-time",
+                          "description": "Время последней попытки"
+                        },
+                        "next_attempt_at": {
+                          "type": "string",
+                          "format": "date-time",
+                          "description": "Время следующей попытки"
+                        },
+                        "delivered_at": {
+                          "type": "string",
+                          "format": "date-time",
+                          "description": "Время успешной доставки"
+                        }
+                      }
+                    }
+                  },
+                  "example": [
+                    {
+                      "delivery_id": 1,
+                      "command_id": 1,
+                      "url": "https://example.com/hooks/bashrun",
+                      "payload": {"command_id": 1, "status": "done", "exit_status": 0},
+                      "status": "delivered",
+                      "attempts": 2,
+                      "response_status": 204,
+                      "last_error": "",
+                      "created_at": "2024-05-01T12:00:01Z",
+                      "last_attempt_at": "2024-05-01T12:00:03Z",
+                      "next_attempt_at": null,
+                      "delivered_at": "2024-05-01T12:00:03Z"
+                    }
+                  ]
+                }
+              }
+            },
+            "400": {
+              "description": "Некорректные данные",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "404": {
+              "description": "Команда с таким id не найдена",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "500": {
+              "description": "Внутренняя ошибка сервера",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            }
+          }
+        }
+    },
+    "/schedules": {
+        "post": {
+          "description": "Создание расписания, по которому сервис сам создает команды. Планировщик раз в SCHEDULE_POLL_INTERVAL_MS миллисекунд создает команды для наступивших запусков и связывает их с расписанием (поле schedule_id). Следующий запуск переносится в БД через compare-and-set, поэтому при нескольких экземплярах сервиса с общей БД каждый запуск создается один раз. Пропущенные во время простоя запуски не догоняются - создается один запуск, а следующий вычисляется от текущего времени",
+          "tags": [
+              "Schedules"
+          ],
+          "summary": "Создание расписания",
+          "parameters": [],
+          "requestBody": {
+            "required": true,
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object",
+                  "properties": {
+                    "cron": {
+                      "type": "string",
+                      "description": "Cron-выражение из 5 полей (минута, час, день месяца, месяц, день недели) с поддержкой *, списков, диапазонов, шагов и названий месяцев и дней недели, либо @yearly, @monthly, @weekly, @daily, @hourly",
+                      "example": "0 3 * * *"
+                    },
+                    "timezone": {
+                      "type": "string",
+                      "description": "Часовой пояс IANA, в котором вычисляется расписание (по умолчанию UTC)",
+                      "example": "Europe/Moscow"
+                    },
+                    "overlap": {
+                      "type": "string",
+                      "description": "Что делать, если предыдущий запуск еще ожидает или выполняется: skip - пропустить запуск (по умолчанию), queue - поставить в очередь после предыдущего, replace - остановить предыдущий запуск и запустить новый",
+                      "enum": ["skip", "queue", "replace"],
+                      "example": "skip"
+                    },
+                    "command": {
+                      "type": "object",
+                      "description": "Параметры команды, как в теле POST /commands",
+                      "example": {"command": "/opt/maintenance/vacuum.sh", "timeout_seconds": 3600}
+                    }
+                  }
+                }
+              }
+            }
+          },
+          "responses": {
+            "201": {
+              "description": "Расписание создано",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "schedule_id": {
+                        "type": "integer"
+                      }
+                    }
+                  },
+                  "example": {
+                    "schedule_id": 1
+                  }
+                }
+              }
+            },
+            "400": {
+              "description": "Некорректные данные",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "500": {
+              "description": "Внутренняя ошибка сервера",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            }
+          }
+        },
+        "get": {
+          "description": "Получение списка расписаний",
+          "tags": [
+              "Schedules"
+          ],
+          "summary": "Получение расписаний",
+          "parameters": [],
+          "responses": {
+            "200": {
+              "description": "Список расписаний в порядке создания",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "array",
+                    "items": {
+                      "type": "object",
+                      "properties": {
+                      "schedule_id": {
+                        "type": "integer",
+                        "description": "Идентификатор расписания"
+                      },
+                      "cron": {
+                        "type": "string",
+                        "description": "Cron-выражение"
+                      },
+                      "timezone": {
+                        "type": "string",
+                        "description": "Часовой пояс, в котором вычисляется расписание"
+                      },
+                      "overlap": {
+                        "type": "string",
+                        "description": "Политика пересечения запусков",
+                        "enum": ["skip", "queue", "replace"]
+                      },
+                      "command": {
+                        "type": "object",
+                        "description": "Параметры команды в формате POST /commands (callback_secret не возвращается, значения секретных переменных окружения скрываются)"
+                      },
+                      "paused": {
+                        "type": "boolean",
+                        "description": "Приостановлено ли расписание"
+                      },
+                      "next_run_at": {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Время следующего запуска, null - запусков больше не будет"
+                      },
+                      "last_run_at": {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Время последнего запуска"
+                      },
+                      "last_command_id": {
+                        "type": "integer",
+                        "description": "Идентификатор команды, созданной последним запуском"
+                      },
+                      "skipped_runs": {
+                        "type": "integer",
+                        "description": "Сколько запусков пропущено политикой skip"
+                      },
+                      "created_at": {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Время создания расписания"
+                      }
+                      }
+                    }
+                  },
+                  "example": [
+                    {
+                      "schedule_id": 1,
+                      "cron": "0 3 * * *",
+                      "timezone": "Europe/Moscow",
+                      "overlap": "skip",
+                      "command": {"command": "/opt/maintenance/vacuum.sh", "timeout_seconds": 3600, "kill_grace_seconds": 5, "output_policy": "head"},
+                      "paused": false,
+                      "next_run_at": "2024-05-02T03:00:00+03:00",
+                      "last_run_at": "2024-05-01T03:00:00+03:00",
+                      "last_command_id": 42,
+                      "skipped_runs": 0,
+                      "created_at": "2024-04-30T12:00:00Z"
+                    }
+                  ]
+                }
+              }
+            },
+            "500": {
+              "description": "Внутренняя ошибка сервера",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            }
+          }
+        }
+    },
+    "/schedules/{schedule_id}": {
+        "get": {
+          "description": "Получение расписания по id",
+          "tags": [
+              "Schedules"
+          ],
+          "summary": "Получение расписания",
+          "parameters": [
+              {
+                  "in": "path",
+                  "name": "schedule_id",
+                  "required": true,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Идентификатор расписания"
+                  }
+              }
+          ],
+          "responses": {
+            "200": {
+              "description": "Расписание",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "schedule_id": {
+                        "type": "integer",
+                        "description": "Идентификатор расписания"
+                      },
+                      "cron": {
+                        "type": "string",
+                        "description": "Cron-выражение"
+                      },
+                      "timezone": {
+                        "type": "string",
+                        "description": "Часовой пояс, в котором вычисляется расписание"
+                      },
+                      "overlap": {
+                        "type": "string",
+                        "description": "Политика пересечения запусков",
+                        "enum": ["skip", "queue", "replace"]
+                      },
+                      "command": {
+                        "type": "object",
+                        "description": "Параметры команды в формате POST /commands (callback_secret не возвращается, значения секретных переменных окружения скрываются)"
+                      },
+                      "paused": {
+                        "type": "boolean",
+                        "description": "Приостановлено ли расписание"
+                      },
+                      "next_run_at": {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Время следующего запуска, null - запусков больше не будет"
+                      },
+                      "last_run_at": {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Время последнего запуска"
+                      },
+                      "last_command_id": {
+                        "type": "integer",
+                        "description": "Идентификатор команды, созданной последним запуском"
+                      },
+                      "skipped_runs": {
+                        "type": "integer",
+                        "description": "Сколько запусков пропущено политикой skip"
+                      },
+                      "created_at": {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Время создания расписания"
+                      }
+                    }
+                  },
+                  "example": {
+                      "schedule_id": 1,
+                      "cron": "0 3 * * *",
+                      "timezone": "Europe/Moscow",
+                      "overlap": "skip",
+                      "command": {"command": "/opt/maintenance/vacuum.sh", "timeout_seconds": 3600, "kill_grace_seconds": 5, "output_policy": "head"},
+                      "paused": false,
+                      "next_run_at": "2024-05-02T03:00:00+03:00",
+                      "last_run_at": "2024-05-01T03:00:00+03:00",
+                      "last_command_id": 42,
+                      "skipped_runs": 0,
+                      "created_at": "2024-04-30T12:00:00Z"
+                    }
+                }
+              }
+            },
+            "400": {
+              "description": "Некорректные данные",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "404": {
+              "description": "Расписание с таким id не найдено",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "500": {
+              "description": "Внутренняя ошибка сервера",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            }
+          }
+        },
+        "delete": {
+          "description": "Удаление расписания. Уже созданные им команды сохраняются, а их schedule_id становится null",
+          "tags": [
+              "Schedules"
+          ],
+          "summary": "Удаление расписания",
+          "parameters": [
+              {
+                  "in": "path",
+                  "name": "schedule_id",
+                  "required": true,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Идентификатор расписания"
+                  }
+              }
+          ],
+          "responses": {
+            "204": {
+              "description": "Расписание удалено"
+            },
+            "400": {
+              "description": "Некорректные данные",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "404": {
+              "description": "Расписание с таким id не найдено",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "500": {
+              "description": "Внутренняя ошибка сервера",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            }
+          }
+        }
+    },
+    "/schedules/{schedule_id}/pause": {
+        "post": {
+          "description": "Приостановка расписания: новые запуски не создаются, уже созданные команды продолжают выполняться",
+          "tags": [
+              "Schedules"
+          ],
+          "summary": "Приостановка расписания",
+          "parameters": [
+              {
+                  "in": "path",
+                  "name": "schedule_id",
+                  "required": true,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Идентификатор расписания"
+                  }
+              }
+          ],
+          "responses": {
+            "204": {
+              "description": "Расписание приостановлено"
+            },
+            "400": {
+              "description": "Некорректные данные",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "404": {
+              "description": "Расписание с таким id не найдено",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "500": {
+              "description": "Внутренняя ошибка сервера",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            }
+          }
+        }
+    },
+    "/schedules/{schedule_id}/resume": {
+        "post": {
+          "description": "Возобновление приостановленного расписания. Запуски, пропущенные во время паузы, не создаются, следующий запуск вычисляется от текущего времени",
+          "tags": [
+              "Schedules"
+          ],
+          "summary": "Возобновление расписания",
+          "parameters": [
+              {
+                  "in": "path",
+                  "name": "schedule_id",
+                  "required": true,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Идентификатор расписания"
+                  }
+              }
+          ],
+          "responses": {
+            "204": {
+              "description": "Расписание возобновлено"
+            },
+            "400": {
+              "description": "Некорректные данные",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "404": {
+              "description": "Расписание с таким id не найдено",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "500": {
+              "description": "Внутренняя ошибка сервера",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            }
+          }
+        }
+    },
+    "/workflows": {
+        "post": {
+          "description": "Создание workflow - графа команд без циклов. Узел запускается, когда все его зависимости из depends_on завершены или пропущены и выполнено его условие: on_success (по умолчанию) - все зависимости завершились со статусом done и кодом 0, on_failure - хотя бы одна зависимость завершилась неуспешно, always - в любом случае. Если условие не выполнено, узел пропускается (статус skipped), и это учитывается его зависимыми узлами. Команды узлов создаются как обычные команды со ссылкой на workflow (поле workflow_id) и выполняются через общую очередь с ограничением MAX_CONCURRENT_COMMANDS. Граф проверяется на циклы при создании",
+          "tags": [
+              "Workflows"
+          ],
+          "summary": "Создание workflow",
+          "parameters": [],
+          "requestBody": {
+            "required": true,
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object",
+                  "properties": {
+                    "name": {
+                      "type": "string",
+                      "description": "Название workflow",
+                      "example": "release"
+                    },
+                    "nodes": {
+                      "type": "array",
+                      "description": "Узлы workflow (от 1 до 100) с уникальными названиями",
+                      "items": {
+                        "type": "object",
+                        "properties": {
+                          "name": {
+                            "type": "string",
+                            "description": "Название узла, на которое ссылаются другие узлы"
+                          },
+                          "depends_on": {
+                            "type": "array",
+                            "items": {
+                              "type": "string"
+                            },
+                            "description": "Названия узлов, после которых запускается узел"
+                          },
+                          "condition": {
+                            "type": "string",
+                            "description": "Условие запуска узла (по умолчанию on_success)",
+                            "enum": ["on_success", "on_failure", "always"]
+                          },
+                          "command": {
+                            "type": "object",
+                            "description": "Параметры команды, как в теле POST /commands, кроме run_at и delay_seconds"
+                          }
+                        }
+                      },
+                      "example": [
+                        {"name": "build", "command": {"command": "make"}},
+                        {"name": "test", "depends_on": ["build"], "command": {"argv": ["make", "test"]}},
+                        {"name": "deploy", "depends_on": ["test"], "command": {"command": "./deploy.sh"}},
+                        {"name": "notify", "depends_on": ["test"], "condition": "on_failure", "command": {"command": "./notify.sh"}},
+                        {"name": "cleanup", "depends_on": ["deploy", "notify"], "condition": "always", "command": {"command": "make clean"}}
+                      ]
+                    }
+                  }
+                }
+              }
+            }
+          },
+          "responses": {
+            "201": {
+              "description": "Workflow создан, узлы без зависимостей поставлены в очередь",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "workflow_id": {
+                        "type": "integer"
+                      }
+                    }
+                  },
+                  "example": {
+                    "workflow_id": 1
+                  }
+                }
+              }
+            },
+            "400": {
+              "description": "Некорректные данные или цикл в графе",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "500": {
+              "description": "Внутренняя ошибка сервера",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            }
+          }
+        },
+        "get": {
+          "description": "Получение списка workflow без узлов",
+          "tags": [
+              "Workflows"
+          ],
+          "summary": "Получение workflow",
+          "parameters": [],
+          "responses": {
+            "200": {
+              "description": "Список workflow в порядке создания",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "array",
+                    "items": {
+                      "type": "object",
+                      "properties": {
+                      "workflow_id": {
+                        "type": "integer",
+                        "description": "Идентификатор workflow"
+                      },
+                      "name": {
+                        "type": "string",
+                        "description": "Название workflow"
+                      },
+                      "status": {
+                        "type": "string",
+                        "description": "Общий статус: running - есть незавершенные узлы, failed - все узлы завершены или пропущены и хотя бы один завершился неуспешно, succeeded - иначе",
+                        "enum": ["running", "succeeded", "failed"]
+                      },
+                      "created_at": {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Время создания workflow"
+                      },
+                      "finished_at": {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Время завершения workflow, null - еще выполняется"
+                      }
+                      }
+                    }
+                  },
+                  "example": [
+                    {
+                      "workflow_id": 1,
+                      "name": "release",
+                      "status": "running",
+                      "created_at": "2024-05-01T12:00:00Z",
+                      "finished_at": null
+                    }
+                  ]
+                }
+              }
+            },
+            "500": {
+              "description": "Внутренняя ошибка сервера",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            }
+          }
+        }
+    },
+    "/workflows/{workflow_id}": {
+        "get": {
+          "description": "Получение workflow по id со статусами узлов",
+          "tags": [
+              "Workflows"
+          ],
+          "summary": "Получение workflow",
+          "parameters": [
+              {
+                  "in": "path",
+                  "name": "workflow_id",
+                  "required": true,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Идентификатор workflow"
+                  }
+              }
+          ],
+          "responses": {
+            "200": {
+              "description": "Workflow",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "workflow_id": {
+                        "type": "integer",
+                        "description": "Идентификатор workflow"
+                      },
+                      "name": {
+                        "type": "string",
+                        "description": "Название workflow"
+                      },
+                      "status": {
+                        "type": "string",
+                        "description": "Общий статус: running - есть незавершенные узлы, failed - все узлы завершены или пропущены и хотя бы один завершился неуспешно, succeeded - иначе",
+                        "enum": ["running", "succeeded", "failed"]
+                      },
+                      "created_at": {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Время создания workflow"
+                      },
+                      "finished_at": {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Время завершения workflow, null - еще выполняется"
+                      },
+                      "nodes": {
+                        "type": "array",
+                        "description": "Узлы в порядке из запроса",
+                        "items": {
+                          "type": "object",
+                          "properties": {
+                            "name": {
+                              "type": "string",
+                              "description": "Название узла"
+                            },
+                            "depends_on": {
+                              "type": "array",
+                              "items": {
+                                "type": "string"
+                              },
+                              "description": "Узлы, от которых зависит узел"
+                            },
+                            "condition": {
+                              "type": "string",
+                              "description": "Условие запуска узла",
+                              "enum": ["on_success", "on_failure", "always"]
+                            },
+                            "status": {
+                              "type": "string",
+                              "description": "pending - узел ожидает зависимости, skipped - узел пропущен, потому что условие не выполнено, иначе статус команды узла"
+                            },
+                            "command_id": {
+                              "type": "integer",
+                              "description": "Идентификатор команды узла, null - узел еще не запущен или пропущен"
+                            },
+                            "exit_status": {
+                              "type": "integer",
+                              "description": "Код завершения команды узла или null"
+                            }
+                          }
+                        }
+                      }
+                    }
+                  },
+                  "example": {
+                      "workflow_id": 1,
+                      "name": "release",
+                      "status": "running",
+                      "created_at": "2024-05-01T12:00:00Z",
+                      "finished_at": null,
+                      "nodes": [
+                        {"name": "build", "depends_on": [], "condition": "on_success", "status": "done", "command_id": 10, "exit_status": 0},
+                        {"name": "test", "depends_on": ["build"], "condition": "on_success", "status": "done", "command_id": 11, "exit_status": 1},
+                        {"name": "deploy", "depends_on": ["test"], "condition": "on_success", "status": "skipped", "command_id": null, "exit_status": null},
+                        {"name": "notify", "depends_on": ["test"], "condition": "on_failure", "status": "started", "command_id": 12, "exit_status": null},
+                        {"name": "cleanup", "depends_on": ["deploy", "notify"], "condition": "always", "status": "pending", "command_id": null, "exit_status": null}
+                      ]
+                    }
+                }
+              }
+            },
+            "400": {
+              "description": "Некорректные данные",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "404": {
+              "description": "Workflow с таким id не найден",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "500": {
+              "description": "Внутренняя ошибка сервера",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            }
+          }
+        }
+    },
+    "/pipelines": {
+        "post": {
+          "description": "Создание pipeline - списка шагов, которые выполняются по очереди. Каждый шаг создается как отдельная команда со ссылкой на pipeline (поле pipeline_id) после завершения предыдущего шага и выполняется через общую очередь, поэтому его статус и вывод доступны через GET /commands/{command_id} и GET /commands/output/{command_id}. Шаг может получить сохраненный stdout предыдущего шага через stdin (input stdin) или в переменной окружения (input env, переменная input_env, по умолчанию PREVIOUS_STDOUT). После шага, завершившегося неуспешно (ненулевой код выхода, таймаут, ошибка запуска), оставшиеся шаги пропускаются, если не указан continue_on_error. Остановка шага через GET /commands/stop/{command_id} всегда завершает pipeline",
+          "tags": [
+              "Pipelines"
+          ],
+          "summary": "Создание pipeline",
+          "parameters": [],
+          "requestBody": {
+            "required": true,
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object",
+                  "properties": {
+                    "name": {
+                      "type": "string",
+                      "description": "Название pipeline",
+                      "example": "report"
+                    },
+                    "continue_on_error": {
+                      "type": "boolean",
+                      "description": "Продолжать выполнение после неуспешного шага (по умолчанию false)",
+                      "example": false
+                    },
+                    "steps": {
+                      "type": "array",
+                      "description": "Шаги pipeline (от 1 до 100) в порядке выполнения",
+                      "items": {
+                        "type": "object",
+                        "properties": {
+                          "name": {
+                            "type": "string",
+                            "description": "Название шага"
+                          },
+                          "input": {
+                            "type": "string",
+                            "description": "Как передать шагу stdout предыдущего шага: stdin - на вход команды (собственный stdin шага тогда не указывается), env - в переменной окружения. Не поддерживается для первого шага",
+                            "enum": ["stdin", "env"]
+                          },
+                          "input_env": {
+                            "type": "string",
+                            "description": "Название переменной окружения для input env (по умолчанию PREVIOUS_STDOUT), не должно совпадать с переменными из env шага"
+                          },
+                          "command": {
+                            "type": "object",
+                            "description": "Параметры команды, как в теле POST /commands, кроме run_at и delay_seconds"
+                          }
+                        }
+                      },
+                      "example": [
+                        {"name": "list", "command": {"command": "ls /tmp"}},
+                        {"name": "count", "input": "stdin", "command": {"argv": ["wc", "-l"]}},
+                        {"name": "print", "input": "env", "input_env": "COUNT", "command": {"command": "echo files: $COUNT"}}
+                      ]
+                    }
+                  }
+                }
+              }
+            }
+          },
+          "responses": {
+            "201": {
+              "description": "Pipeline создан, первый шаг поставлен в очередь",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "pipeline_id": {
+                        "type": "integer"
+                      }
+                    }
+                  },
+                  "example": {
+                    "pipeline_id": 1
+                  }
+                }
+              }
+            },
+            "400": {
+              "description": "Некорректные данные",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "500": {
+              "description": "Внутренняя ошибка сервера",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            }
+          }
+        },
+        "get": {
+          "description": "Получение списка pipeline без шагов",
+          "tags": [
+              "Pipelines"
+          ],
+          "summary": "Получение pipeline",
+          "parameters": [],
+          "responses": {
+            "200": {
+              "description": "Список pipeline в порядке создания",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "array",
+                    "items": {
+                      "type": "object",
+                      "properties": {
+                      "pipeline_id": {
+                        "type": "integer",
+                        "description": "Идентификатор pipeline"
+                      },
+                      "name": {
+                        "type": "string",
+                        "description": "Название pipeline"
+                      },
+                      "continue_on_error": {
+                        "type": "boolean",
+                        "description": "Продолжать ли выполнение после неуспешного шага"
+                      },
+                      "status": {
+                        "type": "string",
+                        "description": "Общий статус: running - есть незавершенные шаги, failed - все шаги завершены или пропущены и хотя бы один завершился неуспешно, succeeded - иначе",
+                        "enum": ["running", "succeeded", "failed"]
+                      },
+                      "created_at": {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Время создания pipeline"
+                      },
+                      "finished_at": {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Время завершения pipeline, null - еще выполняется"
+                      }
+                      }
+                    }
+                  },
+                  "example": [
+                    {
+                      "pipeline_id": 1,
+                      "name": "report",
+                      "continue_on_error": false,
+                      "status": "running",
+                      "created_at": "2024-05-01T12:00:00Z",
+                      "finished_at": null
+                    }
+                  ]
+                }
+              }
+            },
+            "500": {
+              "description": "Внутренняя ошибка сервера",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            }
+          }
+        }
+    },
+    "/pipelines/{pipeline_id}": {
+        "get": {
+          "description": "Получение pipeline по id со статусами шагов",
+          "tags": [
+              "Pipelines"
+          ],
+          "summary": "Получение pipeline",
+          "parameters": [
+              {
+                  "in": "path",
+                  "name": "pipeline_id",
+                  "required": true,
+                  "schema": {
+                    "type": "integer",
+                    "description": "Идентификатор pipeline"
+                  }
+              }
+          ],
+          "responses": {
+            "200": {
+              "description": "Pipeline",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "pipeline_id": {
+                        "type": "integer",
+                        "description": "Идентификатор pipeline"
+                      },
+                      "name": {
+                        "type": "string",
+                        "description": "Название pipeline"
+                      },
+                      "continue_on_error": {
+                        "type": "boolean",
+                        "description": "Продолжать ли выполнение после неуспешного шага"
+                      },
+                      "status": {
+                        "type": "string",
+                        "description": "Общий статус: running - есть незавершенные шаги, failed - все шаги завершены или пропущены и хотя бы один завершился неуспешно, succeeded - иначе",
+                        "enum": ["running", "succeeded", "failed"]
+                      },
+                      "created_at": {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Время создания pipeline"
+                      },
+                      "finished_at": {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Время завершения pipeline, null - еще выполняется"
+                      },
+                      "steps": {
+                        "type": "array",
+                        "description": "Шаги в порядке выполнения",
+                        "items": {
+                          "type": "object",
+                          "properties": {
+                            "name": {
+                              "type": "string",
+                              "description": "Название шага"
+                            },
+                            "input": {
+                              "type": "string",
+                              "description": "Как шаг получает stdout предыдущего шага, пустая строка - никак",
+                              "enum": ["", "stdin", "env"]
+                            },
+                            "input_env": {
+                              "type": "string",
+                              "description": "Переменная окружения для input env"
+                            },
+                            "status": {
+                              "type": "string",
+                              "description": "pending - шаг ожидает предыдущий, skipped - шаг пропущен после неуспешного или остановленного шага, иначе статус команды шага"
+                            },
+                            "command_id": {
+                              "type": "integer",
+                              "description": "Идентификатор команды шага (для GET /commands/{command_id} и GET /commands/output/{command_id}), null - шаг еще не запущен или пропущен"
+                            },
+                            "exit_status": {
+                              "type": "integer",
+                              "description": "Код завершения команды шага или null"
+                            }
+                          }
+                        }
+                      }
+                    }
+                  },
+                  "example": {
+                      "pipeline_id": 1,
+                      "name": "report",
+                      "continue_on_error": false,
+                      "status": "running",
+                      "created_at": "2024-05-01T12:00:00Z",
+                      "finished_at": null,
+                      "steps": [
+                        {"name": "list", "input": "", "input_env": "", "status": "done", "command_id": 20, "exit_status": 0},
+                        {"name": "count", "input": "stdin", "input_env": "", "status": "started", "command_id": 21, "exit_status": null},
+                        {"name": "print", "input": "env", "input_env": "COUNT", "status": "pending", "command_id": null, "exit_status": null}
+                      ]
+                    }
+                }
+              }
+            },
+            "400": {
+              "description": "Некорректные данные",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "404": {
+              "description": "Pipeline с таким id не найден",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            },
+            "500": {
+              "description": "Внутренняя ошибка сервера",
+              "content": {
+                "application/json": {
+                  "schema": {
+                    "type": "object",
+                    "properties": {
+                      "error": {
+                        "type": "string"
+                      }
+                    }
+                  }
+                }
+              }
+            }
+          }
+        }
+    }
   }
 }`
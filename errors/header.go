@@ -0,0 +1,7 @@
+package errors
+
+import "errors"
+
+var (
+	ErrWrongLastEventID = errors.New("Last-Event-ID should be a non-negative number")
+)
@@ -3,5 +3,28 @@ package errors
 import "errors"
 
 var (
-	ErrEmptyCommand = errors.New("empty command provided")
+	ErrEmptyCommand      = errors.New("empty command provided")
+	ErrEmptyScript       = errors.New("empty script provided")
+	ErrScriptTooLarge    = errors.New("script is too large")
+	ErrWrongScript       = errors.New("script should be provided as a text/x-shellscript body or as a script part of a multipart/form-data body")
+	ErrWrongArgv         = errors.New("argv should start with a non-empty program and should not be combined with command or interpreter")
+	ErrWrongInterpreter  = errors.New("interpreter should be one of sh, bash, zsh, python3 or a path allowed by the server, and it should be installed")
+	ErrWrongTimeout      = errors.New("timeout_seconds should be a positive number not exceeding the server maximum")
+	ErrWrongKillGrace    = errors.New("kill_grace_seconds should be a non-negative number")
+	ErrWrongEnv          = errors.New("env keys should be non-empty and should not contain '=' or NUL characters")
+	ErrWrongWorkdir      = errors.New("workdir should be an existing directory")
+	ErrWrongLabels       = errors.New("labels should be non-empty strings without leading or trailing spaces")
+	ErrWrongMaxOutput    = errors.New("max_output_bytes should be a positive number not exceeding the server maximum")
+	ErrWrongOutputPolicy = errors.New("output_policy should be one of head, tail or kill")
+	ErrWrongCallback     = errors.New("callback_url should be an absolute http or https URL, callback_secret can only be provided with callback_url")
+	ErrWrongPriority     = errors.New("priority should be a number in range [-1000:1000]")
+	ErrWrongStdin        = errors.New("only one of stdin and stdin_base64 should be provided, stdin_base64 should be valid base64")
+	ErrWrongRunAt        = errors.New("only one of run_at (RFC 3339 timestamp) and delay_seconds (positive number) should be provided, they can not be used in a schedule command")
+	ErrWrongRetry        = errors.New("retry.max_attempts should be a number in range [1:100], retry.backoff should be one of fixed, exponential, retry.delay_seconds and retry.max_delay_seconds should be non-negative, retry.exit_codes should be in range [1:255]")
+	ErrWrongWorkflow     = errors.New("workflow should have from 1 to 100 nodes with unique non-empty names, depends_on should reference other nodes of the workflow, condition should be one of on_success, on_failure, always, run_at and delay_seconds are not supported for nodes")
+	ErrWorkflowCycle     = errors.New("workflow nodes should not depend on each other in a cycle")
+	ErrWrongPipeline     = errors.New("pipeline should have from 1 to 100 steps, input should be one of stdin, env and is not supported for the first step, a step with stdin input should not have its own stdin, input_env should be a valid environment variable name not set in the step env and is used only with env input, run_at and delay_seconds are not supported for steps")
+	ErrWrongCron         = errors.New("cron should be a 5-field cron expression (minute, hour, day of month, month, day of week) or one of @yearly, @monthly, @weekly, @daily, @hourly with at least one upcoming run")
+	ErrWrongTimezone     = errors.New("timezone should be an IANA time zone name, like Europe/Moscow")
+	ErrWrongOverlap      = errors.New("overlap should be one of skip, queue, replace")
 )
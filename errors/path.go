@@ -5,4 +5,8 @@ import "errors"
 var (
 	ErrWrongID = errors.New("command_id should be a number and more than zero")
 	ErrEmptyID = errors.New("command_id should be provided as path value")
+
+	ErrWrongScheduleID = errors.New("schedule_id should be a number and more than zero")
+	ErrWrongWorkflowID = errors.New("workflow_id should be a number and more than zero")
+	ErrWrongPipelineID = errors.New("pipeline_id should be a number and more than zero")
 )
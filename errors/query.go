@@ -3,6 +3,22 @@ package errors
 import "errors"
 
 var (
-	ErrWrongLimit  = errors.New("limit should be a number in range [1:50]")
-	ErrWrongOffset = errors.New("offset should be a non-negative number")
+	ErrWrongLimit          = errors.New("limit should be a number in range [1:50]")
+	ErrWrongOffset         = errors.New("offset should be a non-negative number")
+	ErrWrongOutputLimit    = errors.New("limit should be a positive number of bytes or lines")
+	ErrWrongOutputUnit     = errors.New("unit should be one of bytes, lines")
+	ErrWrongTail           = errors.New("tail should be a positive number of lines and should not be combined with offset and limit")
+	ErrWrongDownload       = errors.New("download should be a boolean value")
+	ErrRangeNotSatisfiable = errors.New("requested range is not satisfiable")
+	ErrWrongWaitTimeout    = errors.New("timeout should be a positive duration (like 30s) not exceeding the server maximum")
+	ErrWrongCombined       = errors.New("combined should be a boolean value")
+	ErrWrongSignal         = errors.New("signal should be one of TERM, INT, HUP, KILL")
+	ErrWrongStatus         = errors.New("status should be one of scheduled, created, started, done, stopped, timed_out, output_limit_exceeded, failed, lost")
+	ErrWrongExitStatus     = errors.New("exit_status should be a number")
+	ErrWrongCreatedRange   = errors.New("created_from and created_to should be RFC 3339 timestamps, created_from should not be after created_to")
+	ErrWrongCommandRegex   = errors.New("command_regex should be a valid regular expression")
+	ErrWrongSort           = errors.New("sort should be one of id, created_at, duration")
+	ErrWrongOrder          = errors.New("order should be one of asc, desc")
+	ErrWrongIncludeOutput  = errors.New("include_output should be a boolean value")
+	ErrWrongCursor         = errors.New("after and before should be cursors returned for the same sort and order, they should not be combined with each other or with offset")
 )
@@ -3,5 +3,8 @@ package errors
 import "errors"
 
 var (
-	ErrCommandNotFound = errors.New("command with requested id not found")
+	ErrCommandNotFound  = errors.New("command with requested id not found")
+	ErrScheduleNotFound = errors.New("schedule with requested id not found")
+	ErrWorkflowNotFound = errors.New("workflow with requested id not found")
+	ErrPipelineNotFound = errors.New("pipeline with requested id not found")
 )
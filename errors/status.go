@@ -3,6 +3,12 @@ package errors
 import "errors"
 
 var (
-	ErrCommandNotRunning = errors.New("the command is not running already")
-	ErrCommandStopped    = errors.New("the command is stopped")
+	ErrCommandNotRunning   = errors.New("the command is not running already")
+	ErrCommandStopped      = errors.New("the command is stopped")
+	ErrCommandTimedOut     = errors.New("the command timed out")
+	ErrOutputLimitExceeded = errors.New("the command exceeded the output limit")
+	ErrProcessGroupAlive   = errors.New("some processes of the command are still running after the stop signal")
+	ErrWrongTransition     = errors.New("the command status can't be changed this way")
+	ErrStatusConflict      = errors.New("the command status was changed concurrently")
+	ErrWrongRecoverPolicy  = errors.New("RECOVER_CREATED_POLICY should be one of requeue, expire")
 )
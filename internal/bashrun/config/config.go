@@ -3,16 +3,38 @@ package config
 import "fmt"
 
 type Config struct {
-	PostgresHost          string `env:"POSTGRES_HOST" envDefault:"localhost"`
-	PostgresUser          string `env:"POSTGRES_USER"         envDefault:"bashrun"`
-	PostgresPassword      string `env:"POSTGRES_PASSWORD"     envDefault:"bashrun"`
-	PostgresDB            string `env:"POSTGRES_DB"           envDefault:"bashrun"`
-	PostgresPort          int    `env:"POSTGRES_PORT"         envDefault:"5432"`
-	ServicePort           int    `env:"SERVICE_PORT"          envDefault:"8080"`
-	ServiceHost           string `env:"SERVICE_HOST"          envDefault:"0.0.0.0"`
-	MigrationsPath        string `env:"MIGRATIONS_PATH"       envDefault:"migrations"`
-	LogFilePath           string `env:"LOG_FILE_PATH"         envDefault:"logfile.log"`
-	MaxConcurrentCommands int64  `env:"MAX_CONCURRENT_COMMANDS" envDefault:"100"`
+	PostgresHost          string   `env:"POSTGRES_HOST" envDefault:"localhost"`
+	PostgresUser          string   `env:"POSTGRES_USER"         envDefault:"bashrun"`
+	PostgresPassword      string   `env:"POSTGRES_PASSWORD"     envDefault:"bashrun"`
+	PostgresDB            string   `env:"POSTGRES_DB"           envDefault:"bashrun"`
+	PostgresPort          int      `env:"POSTGRES_PORT"         envDefault:"5432"`
+	ServicePort           int      `env:"SERVICE_PORT"          envDefault:"8080"`
+	ServiceHost           string   `env:"SERVICE_HOST"          envDefault:"0.0.0.0"`
+	MigrationsPath        string   `env:"MIGRATIONS_PATH"       envDefault:"migrations"`
+	LogFilePath           string   `env:"LOG_FILE_PATH"         envDefault:"logfile.log"`
+	MaxConcurrentCommands int64    `env:"MAX_CONCURRENT_COMMANDS" envDefault:"100"`
+	DefaultCommandTimeout int      `env:"DEFAULT_COMMAND_TIMEOUT_SECONDS" envDefault:"0"`
+	MaxCommandTimeout     int      `env:"MAX_COMMAND_TIMEOUT_SECONDS" envDefault:"0"`
+	DefaultKillGrace      int      `env:"DEFAULT_KILL_GRACE_SECONDS" envDefault:"5"`
+	StopVerifyTimeout     int      `env:"STOP_VERIFY_TIMEOUT_SECONDS" envDefault:"10"`
+	RedactedEnvKeys       []string `env:"REDACTED_ENV_KEYS" envSeparator:"," envDefault:"PASSWORD,SECRET,TOKEN,KEY"`
+	AllowedInterpreters   []string `env:"ALLOWED_INTERPRETERS" envSeparator:","`
+	ScriptDir             string   `env:"SCRIPT_DIR"`
+	OutputFlushBytes      int      `env:"OUTPUT_FLUSH_BYTES" envDefault:"65536"`
+	OutputFlushIntervalMS int      `env:"OUTPUT_FLUSH_INTERVAL_MS" envDefault:"200"`
+	OutputBufferBytes     int64    `env:"OUTPUT_BUFFER_BYTES" envDefault:"8388608"`
+	MaxOutputBytes        int64    `env:"MAX_OUTPUT_BYTES" envDefault:"0"`
+	DefaultOutputPolicy   string   `env:"DEFAULT_OUTPUT_POLICY" envDefault:"head"`
+	MaxWaitSeconds        int      `env:"MAX_WAIT_SECONDS" envDefault:"300"`
+	CallbackTimeout       int      `env:"CALLBACK_TIMEOUT_SECONDS" envDefault:"10"`
+	CallbackMaxAttempts   int      `env:"CALLBACK_MAX_ATTEMPTS" envDefault:"10"`
+	CallbackPollInterval  int      `env:"CALLBACK_POLL_INTERVAL_MS" envDefault:"1000"`
+	RecoverCreatedPolicy  string   `env:"RECOVER_CREATED_POLICY" envDefault:"requeue"`
+	RecoverMaxAgeSeconds  int      `env:"RECOVER_MAX_AGE_SECONDS" envDefault:"3600"`
+	InstanceID            string   `env:"INSTANCE_ID"`
+	LeaseSeconds          int      `env:"LEASE_SECONDS" envDefault:"30"`
+	QueuePollInterval     int      `env:"QUEUE_POLL_INTERVAL_MS" envDefault:"1000"`
+	SchedulePollInterval  int      `env:"SCHEDULE_POLL_INTERVAL_MS" envDefault:"1000"`
 }
 
 func (c *Config) DSN() string {
@@ -1,27 +1,90 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type BashrunService interface {
 	Ping(ctx context.Context) error
-	CreateCommand(ctx context.Context, command string) (int, error)
-	ListCommands(ctx context.Context, limit int, offset int) ([]CommandFromDB, error)
-	StopCommand(ctx context.Context, id int) error
+	CreateCommand(ctx context.Context, command CommandFromUser) (int, error)
+	ListCommands(ctx context.Context, filter CommandFilter) (CommandPage, error)
+	StopCommand(ctx context.Context, id int, signal string) error
 	ReadCommand(ctx context.Context, id int) (CommandFromDB, error)
-	ReadOutput(ctx context.Context, id int) (string, error)
+	ReadOutput(ctx context.Context, id int, rng OutputRange) (OutputSlice, error)
+	ReadStderr(ctx context.Context, id int, rng OutputRange) (OutputSlice, error)
+	ReadCombinedOutput(ctx context.Context, id int, rng OutputRange) (OutputSlice, error)
+	StreamCommand(ctx context.Context, id int, lastEventID int) (<-chan CommandEvent, error)
+	WaitCommand(ctx context.Context, id int, timeout time.Duration) (CommandFromDB, bool, error)
+	ListDeliveries(ctx context.Context, id int) ([]Delivery, error)
+	ListAttempts(ctx context.Context, id int) ([]CommandFromDB, error)
+	CreateSchedule(ctx context.Context, schedule ScheduleFromUser) (int, error)
+	ListSchedules(ctx context.Context) ([]Schedule, error)
+	ReadSchedule(ctx context.Context, id int) (Schedule, error)
+	PauseSchedule(ctx context.Context, id int) error
+	ResumeSchedule(ctx context.Context, id int) error
+	DeleteSchedule(ctx context.Context, id int) error
+	CreateWorkflow(ctx context.Context, workflow WorkflowFromUser) (int, error)
+	ListWorkflows(ctx context.Context) ([]Workflow, error)
+	ReadWorkflow(ctx context.Context, id int) (Workflow, error)
+	CreatePipeline(ctx context.Context, pipeline PipelineFromUser) (int, error)
+	ListPipelines(ctx context.Context) ([]Pipeline, error)
+	ReadPipeline(ctx context.Context, id int) (Pipeline, error)
 }
 
 //go:generate mockgen -destination=mocks/repo_mock.gen.go -package=mocks . BashrunRepository
 type BashrunRepository interface {
 	Ping(ctx context.Context) error
-	CreateCommand(ctx context.Context, command string) (int, error)
-	UpdateOutput(ctx context.Context, id int, newOutputPart string) error
-	UpdateStatus(ctx context.Context, id int, newStatus string) error
+	CreateCommand(ctx context.Context, command CommandFromUser) (int, error)
+	AppendOutput(ctx context.Context, id int, chunks []OutputChunk) error
+	TrimOutput(ctx context.Context, id int, trim OutputTrim) error
+	UpdateOutputStats(ctx context.Context, id int, stats OutputStats) error
+	CreateDelivery(ctx context.Context, id int, url string, payload []byte) error
+	ListMissingDeliveries(ctx context.Context, limit int) ([]Delivery, error)
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]Delivery, error)
+	UpdateDelivery(ctx context.Context, deliveryID int64, attempt DeliveryAttempt) error
+	ListDeliveries(ctx context.Context, id int) ([]Delivery, error)
+	UpdateStatus(ctx context.Context, id int, transition StatusTransition) error
 	UpdatePID(ctx context.Context, id int, pid int) error
-	ListCommands(ctx context.Context, limit int, offset int) ([]CommandFromDB, error)
+	ListCommands(ctx context.Context, filter CommandFilter) ([]CommandFromDB, error)
+	CountCommands(ctx context.Context, filter CommandFilter) (int, error)
 	UpdateExitStatus(ctx context.Context, id int, exitStatusCode int) error
-	ReadStatus(ctx context.Context, id int) (string, error)
-	ReadPID(ctx context.Context, id int) (int, error)
+	UpdateStartTime(ctx context.Context, id int, startedAt time.Time, queued time.Duration) error
+	UpdateExecutionStats(ctx context.Context, id int, stats ExecutionStats) error
+	ReadStatus(ctx context.Context, id int) (CommandStatus, error)
 	ReadCommand(ctx context.Context, id int) (CommandFromDB, error)
-	ReadOutput(ctx context.Context, id int) (string, error)
+	ClaimCommand(ctx context.Context, owner string, lease time.Duration) (QueuedCommand, error)
+	RequeueCommand(ctx context.Context, id int, owner string) error
+	RenewLeases(ctx context.Context, owner string, lease time.Duration) error
+	RequestStop(ctx context.Context, id int, signal int) error
+	ClaimStopRequests(ctx context.Context, owner string) ([]StopRequest, error)
+	ReadQueuePosition(ctx context.Context, id int) (*int, error)
+	ListActiveCommands(ctx context.Context, owner string) ([]ActiveCommand, error)
+	ListExpiredCommands(ctx context.Context, owner string) ([]ActiveCommand, error)
+	PromoteScheduledCommands(ctx context.Context, now time.Time) ([]int, error)
+	ReadAttempt(ctx context.Context, id int) (Attempt, error)
+	CreateAttempt(ctx context.Context, attempt NewAttempt) (int, error)
+	FinishParent(ctx context.Context, attemptID int, transition StatusTransition) error
+	ReadCommandLinks(ctx context.Context, id int) (CommandLinks, error)
+	ListAttempts(ctx context.Context, id int) ([]CommandFromDB, error)
+	ListStalledAttempts(ctx context.Context) ([]int, error)
+	ReadOutput(ctx context.Context, id int, stream string, rng OutputRange) (OutputSlice, error)
+	CreateSchedule(ctx context.Context, schedule Schedule) (int, error)
+	ListSchedules(ctx context.Context) ([]Schedule, error)
+	ReadSchedule(ctx context.Context, id int) (Schedule, error)
+	PauseSchedule(ctx context.Context, id int) error
+	ResumeSchedule(ctx context.Context, id int, nextRunAt *time.Time) error
+	DeleteSchedule(ctx context.Context, id int) error
+	ListDueSchedules(ctx context.Context, now time.Time, limit int) ([]Schedule, error)
+	RunSchedule(ctx context.Context, run ScheduleRun) (ScheduleRunResult, error)
+	CreateWorkflow(ctx context.Context, workflow WorkflowFromUser, createdAt time.Time) (int, WorkflowAdvance, error)
+	AdvanceWorkflow(ctx context.Context, id int, now time.Time) (WorkflowAdvance, error)
+	ListWorkflows(ctx context.Context) ([]Workflow, error)
+	ReadWorkflow(ctx context.Context, id int) (Workflow, error)
+	ListRunningWorkflows(ctx context.Context) ([]int, error)
+	CreatePipeline(ctx context.Context, pipeline PipelineFromUser, createdAt time.Time) (int, PipelineAdvance, error)
+	AdvancePipeline(ctx context.Context, id int, now time.Time) (PipelineAdvance, error)
+	ListPipelines(ctx context.Context) ([]Pipeline, error)
+	ReadPipeline(ctx context.Context, id int) (Pipeline, error)
+	ListRunningPipelines(ctx context.Context) ([]int, error)
 }
@@ -1,14 +1,84 @@
 package domain
 
+import "time"
+
 type CommandFromUser struct {
-	Command string `json:"command"`
+	Command          string            `json:"command"`
+	Script           string            `json:"-"`
+	ScriptArgs       []string          `json:"-"`
+	Interpreter      string            `json:"interpreter,omitempty"`
+	Argv             []string          `json:"argv,omitempty"`
+	TimeoutSeconds   *int              `json:"timeout_seconds,omitempty"`
+	KillGraceSeconds *int              `json:"kill_grace_seconds,omitempty"`
+	Env              map[string]string `json:"env,omitempty"`
+	Workdir          string            `json:"workdir,omitempty"`
+	ClearEnv         bool              `json:"clear_env,omitempty"`
+	Stdin            string            `json:"stdin,omitempty"`
+	StdinBase64      string            `json:"stdin_base64,omitempty"`
+	Labels           []string          `json:"labels,omitempty"`
+	MaxOutputBytes   *int64            `json:"max_output_bytes,omitempty"`
+	OutputPolicy     string            `json:"output_policy,omitempty"`
+	CallbackURL      string            `json:"callback_url,omitempty"`
+	CallbackSecret   string            `json:"callback_secret,omitempty"`
+	Priority         int               `json:"priority,omitempty"`
+	RunAt            *time.Time        `json:"run_at,omitempty"`
+	DelaySeconds     *int              `json:"delay_seconds,omitempty"`
+	Retry            *RetryPolicy      `json:"retry,omitempty"`
+	CreatedAt        time.Time         `json:"-"`
+}
+
+// InitialStatus keeps a command with a start time in the future scheduled
+// until the time comes, other commands are queued right away.
+func (c CommandFromUser) InitialStatus() CommandStatus {
+	return initialStatus(c.CreatedAt, c.RunAt)
+}
+
+func initialStatus(createdAt time.Time, runAt *time.Time) CommandStatus {
+	if runAt != nil && runAt.After(createdAt) {
+		return StatusScheduled
+	}
+
+	return StatusCreated
 }
 
 type CommandFromDB struct {
-	ID         int    `json:"command_id"`
-	Command    string `json:"command"`
-	PID        int    `json:"pid"`
-	Output     string `json:"output"`
-	Status     string `json:"status"`
-	ExitStatus *int   `json:"exitStatus"`
+	ID               int               `json:"command_id"`
+	Command          string            `json:"command"`
+	Script           string            `json:"script"`
+	ScriptArgs       []string          `json:"script_args"`
+	Interpreter      string            `json:"interpreter"`
+	Argv             []string          `json:"argv"`
+	PID              int               `json:"pid"`
+	Output           *string           `json:"output,omitempty"`
+	Stderr           *string           `json:"stderr,omitempty"`
+	Status           CommandStatus     `json:"status"`
+	Error            string            `json:"error"`
+	ExitStatus       *int              `json:"exitStatus"`
+	TimeoutSeconds   *int              `json:"timeout_seconds"`
+	KillGraceSeconds *int              `json:"kill_grace_seconds"`
+	CreatedAt        time.Time         `json:"created_at"`
+	RunAt            *time.Time        `json:"run_at"`
+	StartedAt        *time.Time        `json:"started_at"`
+	FinishedAt       *time.Time        `json:"finished_at"`
+	QueuedMS         *int64            `json:"queued_ms"`
+	DurationMS       *int64            `json:"duration_ms"`
+	UserCPUMS        *int64            `json:"user_cpu_ms"`
+	SystemCPUMS      *int64            `json:"system_cpu_ms"`
+	Env              map[string]string `json:"env"`
+	Workdir          string            `json:"workdir"`
+	ClearEnv         bool              `json:"clear_env"`
+	Labels           []string          `json:"labels"`
+	MaxOutputBytes   *int64            `json:"max_output_bytes"`
+	OutputPolicy     string            `json:"output_policy"`
+	Truncated        bool              `json:"truncated"`
+	OutputBytes      int64             `json:"output_bytes"`
+	CallbackURL      string            `json:"callback_url"`
+	Priority         int               `json:"priority"`
+	ScheduleID       *int              `json:"schedule_id"`
+	Retry            *RetryPolicy      `json:"retry"`
+	ParentID         *int              `json:"parent_id"`
+	Attempt          *int              `json:"attempt"`
+	WorkflowID       *int              `json:"workflow_id"`
+	PipelineID       *int              `json:"pipeline_id"`
+	QueuePosition    *int              `json:"queue_position"`
 }
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusFailed    = "failed"
+)
+
+type CallbackPayload struct {
+	CommandID   int           `json:"command_id"`
+	Status      CommandStatus `json:"status"`
+	Error       string        `json:"error"`
+	ExitStatus  *int          `json:"exit_status"`
+	CreatedAt   time.Time     `json:"created_at"`
+	StartedAt   *time.Time    `json:"started_at"`
+	FinishedAt  *time.Time    `json:"finished_at"`
+	QueuedMS    *int64        `json:"queued_ms"`
+	DurationMS  *int64        `json:"duration_ms"`
+	UserCPUMS   *int64        `json:"user_cpu_ms"`
+	SystemCPUMS *int64        `json:"system_cpu_ms"`
+	Output      string        `json:"output"`
+	Stderr      string        `json:"stderr"`
+	OutputBytes int64         `json:"output_bytes"`
+	Truncated   bool          `json:"truncated"`
+}
+
+type Delivery struct {
+	ID             int64           `json:"delivery_id"`
+	CommandID      int             `json:"command_id"`
+	URL            string          `json:"url"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus *int            `json:"response_status"`
+	LastError      string          `json:"last_error"`
+	CreatedAt      time.Time       `json:"created_at"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	Secret         string          `json:"-"`
+}
+
+type DeliveryAttempt struct {
+	Status         string
+	ResponseStatus *int
+	Error          string
+	AttemptedAt    time.Time
+	NextAttemptAt  *time.Time
+}
@@ -0,0 +1,7 @@
+package domain
+
+type CommandEvent struct {
+	ID   int
+	Type string
+	Data string
+}
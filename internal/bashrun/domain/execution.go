@@ -0,0 +1,10 @@
+package domain
+
+import "time"
+
+type ExecutionStats struct {
+	FinishedAt time.Time
+	Duration   time.Duration
+	UserCPU    time.Duration
+	SystemCPU  time.Duration
+}
@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+const (
+	SortByID        = "id"
+	SortByCreatedAt = "created_at"
+	SortByDuration  = "duration"
+)
+
+type CommandFilter struct {
+	Limit        int
+	Offset       int
+	Status       CommandStatus
+	ExitStatus   *int
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	Command      string
+	CommandRegex string
+	Label        string
+	Search       string
+	OmitOutput   bool
+	ScheduleID   *int
+	WorkflowID   *int
+	PipelineID   *int
+	SortBy       string
+	Descending   bool
+	After        string
+	Before       string
+	Cursor       *Cursor
+	Backward     bool
+}
+
+type Cursor struct {
+	SortBy     string     `json:"s"`
+	Descending bool       `json:"d"`
+	ID         int        `json:"i"`
+	CreatedAt  *time.Time `json:"c,omitempty"`
+	DurationMS *int64     `json:"m,omitempty"`
+}
+
+type CommandPage struct {
+	Commands   []CommandFromDB
+	Total      int
+	NextCursor string
+	PrevCursor string
+}
@@ -3,3 +3,15 @@ package domain
 type ID struct {
 	ID int `json:"command_id"`
 }
+
+type ScheduleID struct {
+	ID int `json:"schedule_id"`
+}
+
+type WorkflowID struct {
+	ID int `json:"workflow_id"`
+}
+
+type PipelineID struct {
+	ID int `json:"pipeline_id"`
+}
@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 
@@ -36,8 +37,127 @@ func (m *MockBashrunRepository) EXPECT() *MockBashrunRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AdvancePipeline mocks base method.
+func (m *MockBashrunRepository) AdvancePipeline(arg0 context.Context, arg1 int, arg2 time.Time) (domain.PipelineAdvance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdvancePipeline", arg0, arg1, arg2)
+	ret0, _ := ret[0].(domain.PipelineAdvance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdvancePipeline indicates an expected call of AdvancePipeline.
+func (mr *MockBashrunRepositoryMockRecorder) AdvancePipeline(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdvancePipeline", reflect.TypeOf((*MockBashrunRepository)(nil).AdvancePipeline), arg0, arg1, arg2)
+}
+
+// AdvanceWorkflow mocks base method.
+func (m *MockBashrunRepository) AdvanceWorkflow(arg0 context.Context, arg1 int, arg2 time.Time) (domain.WorkflowAdvance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdvanceWorkflow", arg0, arg1, arg2)
+	ret0, _ := ret[0].(domain.WorkflowAdvance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdvanceWorkflow indicates an expected call of AdvanceWorkflow.
+func (mr *MockBashrunRepositoryMockRecorder) AdvanceWorkflow(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdvanceWorkflow", reflect.TypeOf((*MockBashrunRepository)(nil).AdvanceWorkflow), arg0, arg1, arg2)
+}
+
+// AppendOutput mocks base method.
+func (m *MockBashrunRepository) AppendOutput(arg0 context.Context, arg1 int, arg2 []domain.OutputChunk) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendOutput", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AppendOutput indicates an expected call of AppendOutput.
+func (mr *MockBashrunRepositoryMockRecorder) AppendOutput(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendOutput", reflect.TypeOf((*MockBashrunRepository)(nil).AppendOutput), arg0, arg1, arg2)
+}
+
+// ClaimCommand mocks base method.
+func (m *MockBashrunRepository) ClaimCommand(arg0 context.Context, arg1 string, arg2 time.Duration) (domain.QueuedCommand, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimCommand", arg0, arg1, arg2)
+	ret0, _ := ret[0].(domain.QueuedCommand)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimCommand indicates an expected call of ClaimCommand.
+func (mr *MockBashrunRepositoryMockRecorder) ClaimCommand(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimCommand", reflect.TypeOf((*MockBashrunRepository)(nil).ClaimCommand), arg0, arg1, arg2)
+}
+
+// ClaimDeliveries mocks base method.
+func (m *MockBashrunRepository) ClaimDeliveries(arg0 context.Context, arg1 int, arg2 time.Duration) ([]domain.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDeliveries", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]domain.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDeliveries indicates an expected call of ClaimDeliveries.
+func (mr *MockBashrunRepositoryMockRecorder) ClaimDeliveries(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDeliveries", reflect.TypeOf((*MockBashrunRepository)(nil).ClaimDeliveries), arg0, arg1, arg2)
+}
+
+// ClaimStopRequests mocks base method.
+func (m *MockBashrunRepository) ClaimStopRequests(arg0 context.Context, arg1 string) ([]domain.StopRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimStopRequests", arg0, arg1)
+	ret0, _ := ret[0].([]domain.StopRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimStopRequests indicates an expected call of ClaimStopRequests.
+func (mr *MockBashrunRepositoryMockRecorder) ClaimStopRequests(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimStopRequests", reflect.TypeOf((*MockBashrunRepository)(nil).ClaimStopRequests), arg0, arg1)
+}
+
+// CountCommands mocks base method.
+func (m *MockBashrunRepository) CountCommands(arg0 context.Context, arg1 domain.CommandFilter) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountCommands", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountCommands indicates an expected call of CountCommands.
+func (mr *MockBashrunRepositoryMockRecorder) CountCommands(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountCommands", reflect.TypeOf((*MockBashrunRepository)(nil).CountCommands), arg0, arg1)
+}
+
+// CreateAttempt mocks base method.
+func (m *MockBashrunRepository) CreateAttempt(arg0 context.Context, arg1 domain.NewAttempt) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAttempt", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAttempt indicates an expected call of CreateAttempt.
+func (mr *MockBashrunRepositoryMockRecorder) CreateAttempt(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAttempt", reflect.TypeOf((*MockBashrunRepository)(nil).CreateAttempt), arg0, arg1)
+}
+
 // CreateCommand mocks base method.
-func (m *MockBashrunRepository) CreateCommand(arg0 context.Context, arg1 string) (int, error) {
+func (m *MockBashrunRepository) CreateCommand(arg0 context.Context, arg1 domain.CommandFromUser) (int, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateCommand", arg0, arg1)
 	ret0, _ := ret[0].(int)
@@ -51,19 +171,302 @@ func (mr *MockBashrunRepositoryMockRecorder) CreateCommand(arg0, arg1 interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCommand", reflect.TypeOf((*MockBashrunRepository)(nil).CreateCommand), arg0, arg1)
 }
 
+// CreateDelivery mocks base method.
+func (m *MockBashrunRepository) CreateDelivery(arg0 context.Context, arg1 int, arg2 string, arg3 []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDelivery", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateDelivery indicates an expected call of CreateDelivery.
+func (mr *MockBashrunRepositoryMockRecorder) CreateDelivery(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDelivery", reflect.TypeOf((*MockBashrunRepository)(nil).CreateDelivery), arg0, arg1, arg2, arg3)
+}
+
+// CreatePipeline mocks base method.
+func (m *MockBashrunRepository) CreatePipeline(arg0 context.Context, arg1 domain.PipelineFromUser, arg2 time.Time) (int, domain.PipelineAdvance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePipeline", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(domain.PipelineAdvance)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreatePipeline indicates an expected call of CreatePipeline.
+func (mr *MockBashrunRepositoryMockRecorder) CreatePipeline(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePipeline", reflect.TypeOf((*MockBashrunRepository)(nil).CreatePipeline), arg0, arg1, arg2)
+}
+
+// CreateSchedule mocks base method.
+func (m *MockBashrunRepository) CreateSchedule(arg0 context.Context, arg1 domain.Schedule) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSchedule", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSchedule indicates an expected call of CreateSchedule.
+func (mr *MockBashrunRepositoryMockRecorder) CreateSchedule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSchedule", reflect.TypeOf((*MockBashrunRepository)(nil).CreateSchedule), arg0, arg1)
+}
+
+// CreateWorkflow mocks base method.
+func (m *MockBashrunRepository) CreateWorkflow(arg0 context.Context, arg1 domain.WorkflowFromUser, arg2 time.Time) (int, domain.WorkflowAdvance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWorkflow", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(domain.WorkflowAdvance)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateWorkflow indicates an expected call of CreateWorkflow.
+func (mr *MockBashrunRepositoryMockRecorder) CreateWorkflow(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWorkflow", reflect.TypeOf((*MockBashrunRepository)(nil).CreateWorkflow), arg0, arg1, arg2)
+}
+
+// DeleteSchedule mocks base method.
+func (m *MockBashrunRepository) DeleteSchedule(arg0 context.Context, arg1 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSchedule", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSchedule indicates an expected call of DeleteSchedule.
+func (mr *MockBashrunRepositoryMockRecorder) DeleteSchedule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSchedule", reflect.TypeOf((*MockBashrunRepository)(nil).DeleteSchedule), arg0, arg1)
+}
+
+// FinishParent mocks base method.
+func (m *MockBashrunRepository) FinishParent(arg0 context.Context, arg1 int, arg2 domain.StatusTransition) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishParent", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FinishParent indicates an expected call of FinishParent.
+func (mr *MockBashrunRepositoryMockRecorder) FinishParent(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishParent", reflect.TypeOf((*MockBashrunRepository)(nil).FinishParent), arg0, arg1, arg2)
+}
+
+// ListActiveCommands mocks base method.
+func (m *MockBashrunRepository) ListActiveCommands(arg0 context.Context, arg1 string) ([]domain.ActiveCommand, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListActiveCommands", arg0, arg1)
+	ret0, _ := ret[0].([]domain.ActiveCommand)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListActiveCommands indicates an expected call of ListActiveCommands.
+func (mr *MockBashrunRepositoryMockRecorder) ListActiveCommands(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListActiveCommands", reflect.TypeOf((*MockBashrunRepository)(nil).ListActiveCommands), arg0, arg1)
+}
+
+// ListAttempts mocks base method.
+func (m *MockBashrunRepository) ListAttempts(arg0 context.Context, arg1 int) ([]domain.CommandFromDB, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAttempts", arg0, arg1)
+	ret0, _ := ret[0].([]domain.CommandFromDB)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAttempts indicates an expected call of ListAttempts.
+func (mr *MockBashrunRepositoryMockRecorder) ListAttempts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAttempts", reflect.TypeOf((*MockBashrunRepository)(nil).ListAttempts), arg0, arg1)
+}
+
 // ListCommands mocks base method.
-func (m *MockBashrunRepository) ListCommands(arg0 context.Context, arg1, arg2 int) ([]domain.CommandFromDB, error) {
+func (m *MockBashrunRepository) ListCommands(arg0 context.Context, arg1 domain.CommandFilter) ([]domain.CommandFromDB, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListCommands", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "ListCommands", arg0, arg1)
 	ret0, _ := ret[0].([]domain.CommandFromDB)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListCommands indicates an expected call of ListCommands.
-func (mr *MockBashrunRepositoryMockRecorder) ListCommands(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockBashrunRepositoryMockRecorder) ListCommands(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCommands", reflect.TypeOf((*MockBashrunRepository)(nil).ListCommands), arg0, arg1)
+}
+
+// ListDeliveries mocks base method.
+func (m *MockBashrunRepository) ListDeliveries(arg0 context.Context, arg1 int) ([]domain.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeliveries", arg0, arg1)
+	ret0, _ := ret[0].([]domain.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeliveries indicates an expected call of ListDeliveries.
+func (mr *MockBashrunRepositoryMockRecorder) ListDeliveries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeliveries", reflect.TypeOf((*MockBashrunRepository)(nil).ListDeliveries), arg0, arg1)
+}
+
+// ListDueSchedules mocks base method.
+func (m *MockBashrunRepository) ListDueSchedules(arg0 context.Context, arg1 time.Time, arg2 int) ([]domain.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDueSchedules", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]domain.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDueSchedules indicates an expected call of ListDueSchedules.
+func (mr *MockBashrunRepositoryMockRecorder) ListDueSchedules(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDueSchedules", reflect.TypeOf((*MockBashrunRepository)(nil).ListDueSchedules), arg0, arg1, arg2)
+}
+
+// ListExpiredCommands mocks base method.
+func (m *MockBashrunRepository) ListExpiredCommands(arg0 context.Context, arg1 string) ([]domain.ActiveCommand, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExpiredCommands", arg0, arg1)
+	ret0, _ := ret[0].([]domain.ActiveCommand)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExpiredCommands indicates an expected call of ListExpiredCommands.
+func (mr *MockBashrunRepositoryMockRecorder) ListExpiredCommands(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpiredCommands", reflect.TypeOf((*MockBashrunRepository)(nil).ListExpiredCommands), arg0, arg1)
+}
+
+// ListMissingDeliveries mocks base method.
+func (m *MockBashrunRepository) ListMissingDeliveries(arg0 context.Context, arg1 int) ([]domain.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMissingDeliveries", arg0, arg1)
+	ret0, _ := ret[0].([]domain.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMissingDeliveries indicates an expected call of ListMissingDeliveries.
+func (mr *MockBashrunRepositoryMockRecorder) ListMissingDeliveries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMissingDeliveries", reflect.TypeOf((*MockBashrunRepository)(nil).ListMissingDeliveries), arg0, arg1)
+}
+
+// ListPipelines mocks base method.
+func (m *MockBashrunRepository) ListPipelines(arg0 context.Context) ([]domain.Pipeline, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPipelines", arg0)
+	ret0, _ := ret[0].([]domain.Pipeline)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPipelines indicates an expected call of ListPipelines.
+func (mr *MockBashrunRepositoryMockRecorder) ListPipelines(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCommands", reflect.TypeOf((*MockBashrunRepository)(nil).ListCommands), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPipelines", reflect.TypeOf((*MockBashrunRepository)(nil).ListPipelines), arg0)
+}
+
+// ListRunningPipelines mocks base method.
+func (m *MockBashrunRepository) ListRunningPipelines(arg0 context.Context) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRunningPipelines", arg0)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRunningPipelines indicates an expected call of ListRunningPipelines.
+func (mr *MockBashrunRepositoryMockRecorder) ListRunningPipelines(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRunningPipelines", reflect.TypeOf((*MockBashrunRepository)(nil).ListRunningPipelines), arg0)
+}
+
+// ListRunningWorkflows mocks base method.
+func (m *MockBashrunRepository) ListRunningWorkflows(arg0 context.Context) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRunningWorkflows", arg0)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRunningWorkflows indicates an expected call of ListRunningWorkflows.
+func (mr *MockBashrunRepositoryMockRecorder) ListRunningWorkflows(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRunningWorkflows", reflect.TypeOf((*MockBashrunRepository)(nil).ListRunningWorkflows), arg0)
+}
+
+// ListSchedules mocks base method.
+func (m *MockBashrunRepository) ListSchedules(arg0 context.Context) ([]domain.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSchedules", arg0)
+	ret0, _ := ret[0].([]domain.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSchedules indicates an expected call of ListSchedules.
+func (mr *MockBashrunRepositoryMockRecorder) ListSchedules(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSchedules", reflect.TypeOf((*MockBashrunRepository)(nil).ListSchedules), arg0)
+}
+
+// ListStalledAttempts mocks base method.
+func (m *MockBashrunRepository) ListStalledAttempts(arg0 context.Context) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStalledAttempts", arg0)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStalledAttempts indicates an expected call of ListStalledAttempts.
+func (mr *MockBashrunRepositoryMockRecorder) ListStalledAttempts(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStalledAttempts", reflect.TypeOf((*MockBashrunRepository)(nil).ListStalledAttempts), arg0)
+}
+
+// ListWorkflows mocks base method.
+func (m *MockBashrunRepository) ListWorkflows(arg0 context.Context) ([]domain.Workflow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWorkflows", arg0)
+	ret0, _ := ret[0].([]domain.Workflow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWorkflows indicates an expected call of ListWorkflows.
+func (mr *MockBashrunRepositoryMockRecorder) ListWorkflows(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWorkflows", reflect.TypeOf((*MockBashrunRepository)(nil).ListWorkflows), arg0)
+}
+
+// PauseSchedule mocks base method.
+func (m *MockBashrunRepository) PauseSchedule(arg0 context.Context, arg1 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PauseSchedule", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PauseSchedule indicates an expected call of PauseSchedule.
+func (mr *MockBashrunRepositoryMockRecorder) PauseSchedule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PauseSchedule", reflect.TypeOf((*MockBashrunRepository)(nil).PauseSchedule), arg0, arg1)
 }
 
 // Ping mocks base method.
@@ -80,6 +483,36 @@ func (mr *MockBashrunRepositoryMockRecorder) Ping(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockBashrunRepository)(nil).Ping), arg0)
 }
 
+// PromoteScheduledCommands mocks base method.
+func (m *MockBashrunRepository) PromoteScheduledCommands(arg0 context.Context, arg1 time.Time) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PromoteScheduledCommands", arg0, arg1)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PromoteScheduledCommands indicates an expected call of PromoteScheduledCommands.
+func (mr *MockBashrunRepositoryMockRecorder) PromoteScheduledCommands(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PromoteScheduledCommands", reflect.TypeOf((*MockBashrunRepository)(nil).PromoteScheduledCommands), arg0, arg1)
+}
+
+// ReadAttempt mocks base method.
+func (m *MockBashrunRepository) ReadAttempt(arg0 context.Context, arg1 int) (domain.Attempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadAttempt", arg0, arg1)
+	ret0, _ := ret[0].(domain.Attempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadAttempt indicates an expected call of ReadAttempt.
+func (mr *MockBashrunRepositoryMockRecorder) ReadAttempt(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadAttempt", reflect.TypeOf((*MockBashrunRepository)(nil).ReadAttempt), arg0, arg1)
+}
+
 // ReadCommand mocks base method.
 func (m *MockBashrunRepository) ReadCommand(arg0 context.Context, arg1 int) (domain.CommandFromDB, error) {
 	m.ctrl.T.Helper()
@@ -95,41 +528,86 @@ func (mr *MockBashrunRepositoryMockRecorder) ReadCommand(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadCommand", reflect.TypeOf((*MockBashrunRepository)(nil).ReadCommand), arg0, arg1)
 }
 
+// ReadCommandLinks mocks base method.
+func (m *MockBashrunRepository) ReadCommandLinks(arg0 context.Context, arg1 int) (domain.CommandLinks, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadCommandLinks", arg0, arg1)
+	ret0, _ := ret[0].(domain.CommandLinks)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadCommandLinks indicates an expected call of ReadCommandLinks.
+func (mr *MockBashrunRepositoryMockRecorder) ReadCommandLinks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadCommandLinks", reflect.TypeOf((*MockBashrunRepository)(nil).ReadCommandLinks), arg0, arg1)
+}
+
 // ReadOutput mocks base method.
-func (m *MockBashrunRepository) ReadOutput(arg0 context.Context, arg1 int) (string, error) {
+func (m *MockBashrunRepository) ReadOutput(arg0 context.Context, arg1 int, arg2 string, arg3 domain.OutputRange) (domain.OutputSlice, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReadOutput", arg0, arg1)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "ReadOutput", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(domain.OutputSlice)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ReadOutput indicates an expected call of ReadOutput.
-func (mr *MockBashrunRepositoryMockRecorder) ReadOutput(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockBashrunRepositoryMockRecorder) ReadOutput(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadOutput", reflect.TypeOf((*MockBashrunRepository)(nil).ReadOutput), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadOutput", reflect.TypeOf((*MockBashrunRepository)(nil).ReadOutput), arg0, arg1, arg2, arg3)
 }
 
-// ReadPID mocks base method.
-func (m *MockBashrunRepository) ReadPID(arg0 context.Context, arg1 int) (int, error) {
+// ReadPipeline mocks base method.
+func (m *MockBashrunRepository) ReadPipeline(arg0 context.Context, arg1 int) (domain.Pipeline, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReadPID", arg0, arg1)
-	ret0, _ := ret[0].(int)
+	ret := m.ctrl.Call(m, "ReadPipeline", arg0, arg1)
+	ret0, _ := ret[0].(domain.Pipeline)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ReadPID indicates an expected call of ReadPID.
-func (mr *MockBashrunRepositoryMockRecorder) ReadPID(arg0, arg1 interface{}) *gomock.Call {
+// ReadPipeline indicates an expected call of ReadPipeline.
+func (mr *MockBashrunRepositoryMockRecorder) ReadPipeline(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadPID", reflect.TypeOf((*MockBashrunRepository)(nil).ReadPID), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadPipeline", reflect.TypeOf((*MockBashrunRepository)(nil).ReadPipeline), arg0, arg1)
+}
+
+// ReadQueuePosition mocks base method.
+func (m *MockBashrunRepository) ReadQueuePosition(arg0 context.Context, arg1 int) (*int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadQueuePosition", arg0, arg1)
+	ret0, _ := ret[0].(*int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadQueuePosition indicates an expected call of ReadQueuePosition.
+func (mr *MockBashrunRepositoryMockRecorder) ReadQueuePosition(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadQueuePosition", reflect.TypeOf((*MockBashrunRepository)(nil).ReadQueuePosition), arg0, arg1)
+}
+
+// ReadSchedule mocks base method.
+func (m *MockBashrunRepository) ReadSchedule(arg0 context.Context, arg1 int) (domain.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadSchedule", arg0, arg1)
+	ret0, _ := ret[0].(domain.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadSchedule indicates an expected call of ReadSchedule.
+func (mr *MockBashrunRepositoryMockRecorder) ReadSchedule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadSchedule", reflect.TypeOf((*MockBashrunRepository)(nil).ReadSchedule), arg0, arg1)
 }
 
 // ReadStatus mocks base method.
-func (m *MockBashrunRepository) ReadStatus(arg0 context.Context, arg1 int) (string, error) {
+func (m *MockBashrunRepository) ReadStatus(arg0 context.Context, arg1 int) (domain.CommandStatus, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ReadStatus", arg0, arg1)
-	ret0, _ := ret[0].(string)
+	ret0, _ := ret[0].(domain.CommandStatus)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -140,6 +618,134 @@ func (mr *MockBashrunRepositoryMockRecorder) ReadStatus(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadStatus", reflect.TypeOf((*MockBashrunRepository)(nil).ReadStatus), arg0, arg1)
 }
 
+// ReadWorkflow mocks base method.
+func (m *MockBashrunRepository) ReadWorkflow(arg0 context.Context, arg1 int) (domain.Workflow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadWorkflow", arg0, arg1)
+	ret0, _ := ret[0].(domain.Workflow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadWorkflow indicates an expected call of ReadWorkflow.
+func (mr *MockBashrunRepositoryMockRecorder) ReadWorkflow(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadWorkflow", reflect.TypeOf((*MockBashrunRepository)(nil).ReadWorkflow), arg0, arg1)
+}
+
+// RenewLeases mocks base method.
+func (m *MockBashrunRepository) RenewLeases(arg0 context.Context, arg1 string, arg2 time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenewLeases", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenewLeases indicates an expected call of RenewLeases.
+func (mr *MockBashrunRepositoryMockRecorder) RenewLeases(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenewLeases", reflect.TypeOf((*MockBashrunRepository)(nil).RenewLeases), arg0, arg1, arg2)
+}
+
+// RequestStop mocks base method.
+func (m *MockBashrunRepository) RequestStop(arg0 context.Context, arg1 int, arg2 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestStop", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequestStop indicates an expected call of RequestStop.
+func (mr *MockBashrunRepositoryMockRecorder) RequestStop(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestStop", reflect.TypeOf((*MockBashrunRepository)(nil).RequestStop), arg0, arg1, arg2)
+}
+
+// RequeueCommand mocks base method.
+func (m *MockBashrunRepository) RequeueCommand(arg0 context.Context, arg1 int, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequeueCommand", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequeueCommand indicates an expected call of RequeueCommand.
+func (mr *MockBashrunRepositoryMockRecorder) RequeueCommand(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequeueCommand", reflect.TypeOf((*MockBashrunRepository)(nil).RequeueCommand), arg0, arg1, arg2)
+}
+
+// ResumeSchedule mocks base method.
+func (m *MockBashrunRepository) ResumeSchedule(arg0 context.Context, arg1 int, arg2 *time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResumeSchedule", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResumeSchedule indicates an expected call of ResumeSchedule.
+func (mr *MockBashrunRepositoryMockRecorder) ResumeSchedule(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResumeSchedule", reflect.TypeOf((*MockBashrunRepository)(nil).ResumeSchedule), arg0, arg1, arg2)
+}
+
+// RunSchedule mocks base method.
+func (m *MockBashrunRepository) RunSchedule(arg0 context.Context, arg1 domain.ScheduleRun) (domain.ScheduleRunResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunSchedule", arg0, arg1)
+	ret0, _ := ret[0].(domain.ScheduleRunResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunSchedule indicates an expected call of RunSchedule.
+func (mr *MockBashrunRepositoryMockRecorder) RunSchedule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunSchedule", reflect.TypeOf((*MockBashrunRepository)(nil).RunSchedule), arg0, arg1)
+}
+
+// TrimOutput mocks base method.
+func (m *MockBashrunRepository) TrimOutput(arg0 context.Context, arg1 int, arg2 domain.OutputTrim) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TrimOutput", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TrimOutput indicates an expected call of TrimOutput.
+func (mr *MockBashrunRepositoryMockRecorder) TrimOutput(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TrimOutput", reflect.TypeOf((*MockBashrunRepository)(nil).TrimOutput), arg0, arg1, arg2)
+}
+
+// UpdateDelivery mocks base method.
+func (m *MockBashrunRepository) UpdateDelivery(arg0 context.Context, arg1 int64, arg2 domain.DeliveryAttempt) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDelivery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDelivery indicates an expected call of UpdateDelivery.
+func (mr *MockBashrunRepositoryMockRecorder) UpdateDelivery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDelivery", reflect.TypeOf((*MockBashrunRepository)(nil).UpdateDelivery), arg0, arg1, arg2)
+}
+
+// UpdateExecutionStats mocks base method.
+func (m *MockBashrunRepository) UpdateExecutionStats(arg0 context.Context, arg1 int, arg2 domain.ExecutionStats) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateExecutionStats", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateExecutionStats indicates an expected call of UpdateExecutionStats.
+func (mr *MockBashrunRepositoryMockRecorder) UpdateExecutionStats(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateExecutionStats", reflect.TypeOf((*MockBashrunRepository)(nil).UpdateExecutionStats), arg0, arg1, arg2)
+}
+
 // UpdateExitStatus mocks base method.
 func (m *MockBashrunRepository) UpdateExitStatus(arg0 context.Context, arg1, arg2 int) error {
 	m.ctrl.T.Helper()
@@ -154,18 +760,18 @@ func (mr *MockBashrunRepositoryMockRecorder) UpdateExitStatus(arg0, arg1, arg2 i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateExitStatus", reflect.TypeOf((*MockBashrunRepository)(nil).UpdateExitStatus), arg0, arg1, arg2)
 }
 
-// UpdateOutput mocks base method.
-func (m *MockBashrunRepository) UpdateOutput(arg0 context.Context, arg1 int, arg2 string) error {
+// UpdateOutputStats mocks base method.
+func (m *MockBashrunRepository) UpdateOutputStats(arg0 context.Context, arg1 int, arg2 domain.OutputStats) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateOutput", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "UpdateOutputStats", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateOutput indicates an expected call of UpdateOutput.
-func (mr *MockBashrunRepositoryMockRecorder) UpdateOutput(arg0, arg1, arg2 interface{}) *gomock.Call {
+// UpdateOutputStats indicates an expected call of UpdateOutputStats.
+func (mr *MockBashrunRepositoryMockRecorder) UpdateOutputStats(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOutput", reflect.TypeOf((*MockBashrunRepository)(nil).UpdateOutput), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOutputStats", reflect.TypeOf((*MockBashrunRepository)(nil).UpdateOutputStats), arg0, arg1, arg2)
 }
 
 // UpdatePID mocks base method.
@@ -182,8 +788,22 @@ func (mr *MockBashrunRepositoryMockRecorder) UpdatePID(arg0, arg1, arg2 interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePID", reflect.TypeOf((*MockBashrunRepository)(nil).UpdatePID), arg0, arg1, arg2)
 }
 
+// UpdateStartTime mocks base method.
+func (m *MockBashrunRepository) UpdateStartTime(arg0 context.Context, arg1 int, arg2 time.Time, arg3 time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStartTime", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStartTime indicates an expected call of UpdateStartTime.
+func (mr *MockBashrunRepositoryMockRecorder) UpdateStartTime(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStartTime", reflect.TypeOf((*MockBashrunRepository)(nil).UpdateStartTime), arg0, arg1, arg2, arg3)
+}
+
 // UpdateStatus mocks base method.
-func (m *MockBashrunRepository) UpdateStatus(arg0 context.Context, arg1 int, arg2 string) error {
+func (m *MockBashrunRepository) UpdateStatus(arg0 context.Context, arg1 int, arg2 domain.StatusTransition) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "UpdateStatus", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
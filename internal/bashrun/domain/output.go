@@ -0,0 +1,44 @@
+package domain
+
+const (
+	StreamStdout   = "stdout"
+	StreamStderr   = "stderr"
+	StreamCombined = "combined"
+)
+
+const (
+	OutputPolicyHead = "head"
+	OutputPolicyTail = "tail"
+	OutputPolicyKill = "kill"
+)
+
+type OutputChunk struct {
+	Seq          int64
+	Stream       string
+	StreamOffset int64
+	Data         string
+}
+
+type OutputRange struct {
+	Offset int64
+	Limit  int64
+	Suffix int64
+	Tail   int64
+	Lines  bool
+}
+
+type OutputSlice struct {
+	Data  string
+	Start int64
+	Total int64
+}
+
+type OutputTrim struct {
+	Seq   int64
+	Bytes int64
+}
+
+type OutputStats struct {
+	Bytes     int64
+	Truncated bool
+}
@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+const (
+	PipelineInputStdin = "stdin"
+	PipelineInputEnv   = "env"
+
+	// maxEnvEntrySize is MAX_ARG_STRLEN of Linux, the limit of a single
+	// "NAME=value" string with its terminating NUL passed to a new process.
+	maxEnvEntrySize = 128 * 1024
+)
+
+type PipelineStepFromUser struct {
+	Name     string          `json:"name,omitempty"`
+	Input    string          `json:"input,omitempty"`
+	InputEnv string          `json:"input_env,omitempty"`
+	Command  CommandFromUser `json:"command"`
+}
+
+type PipelineFromUser struct {
+	Name            string                 `json:"name,omitempty"`
+	ContinueOnError bool                   `json:"continue_on_error,omitempty"`
+	Steps           []PipelineStepFromUser `json:"steps"`
+}
+
+type PipelineStep struct {
+	Name       string          `json:"name"`
+	Input      string          `json:"input"`
+	InputEnv   string          `json:"input_env"`
+	Status     string          `json:"status"`
+	CommandID  *int            `json:"command_id"`
+	ExitStatus *int            `json:"exit_status"`
+	Command    CommandFromUser `json:"-"`
+}
+
+func (s PipelineStep) resolved() bool {
+	return stepResolved(s.Status)
+}
+
+func (s PipelineStep) failed() bool {
+	return stepFailed(s.Status, s.ExitStatus)
+}
+
+// Pipeline uses the statuses of a workflow, its steps are pending or skipped
+// until they get a command.
+type Pipeline struct {
+	ID              int            `json:"pipeline_id"`
+	Name            string         `json:"name"`
+	ContinueOnError bool           `json:"continue_on_error"`
+	Status          string         `json:"status"`
+	CreatedAt       time.Time      `json:"created_at"`
+	FinishedAt      *time.Time     `json:"finished_at"`
+	Steps           []PipelineStep `json:"steps,omitempty"`
+}
+
+// Advance returns the index of the next step to start or -1 if the previous
+// step is still running or nothing is left. After a failed step the rest of
+// the pipeline is skipped unless ContinueOnError is set, a stopped step ends
+// the pipeline in any case. The statuses of the returned steps are updated in p.
+func (p *Pipeline) Advance() (run int, skip []int) {
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		if step.Status != NodeStatusPending {
+			continue
+		}
+
+		if i > 0 {
+			previous := p.Steps[i-1]
+			if !previous.resolved() {
+				return -1, skip
+			}
+
+			if previous.Status == NodeStatusSkipped || previous.Status == string(StatusStopped) || previous.failed() && !p.ContinueOnError {
+				step.Status = NodeStatusSkipped
+				skip = append(skip, i)
+				continue
+			}
+		}
+
+		step.Status = string(StatusCreated)
+		return i, skip
+	}
+
+	return -1, skip
+}
+
+// AggregateStatus is running until every step is finished or skipped, then
+// failed if any step failed, even when the pipeline continued after it.
+func (p Pipeline) AggregateStatus() string {
+	status := WorkflowStatusSucceeded
+	for _, step := range p.Steps {
+		if !step.resolved() {
+			return WorkflowStatusRunning
+		}
+
+		if step.failed() {
+			status = WorkflowStatusFailed
+		}
+	}
+
+	return status
+}
+
+// EnvInputError returns the reason a step with input env fails before start
+// when the stdout of the previous step can not be passed in the variable name,
+// or an empty string.
+func EnvInputError(name string, stdout []byte) string {
+	if bytes.IndexByte(stdout, 0) >= 0 {
+		return fmt.Sprintf("the stdout of the previous step contains a NUL byte and can not be passed in the environment variable %s", name)
+	}
+
+	if size := len(name) + len(stdout) + 2; size > maxEnvEntrySize {
+		return fmt.Sprintf("the stdout of the previous step is too large for the environment variable %s: %d bytes, at most %d bytes are allowed",
+			name, len(stdout), maxEnvEntrySize-len(name)-2)
+	}
+
+	return ""
+}
+
+type PipelineAdvance struct {
+	Status  string
+	Started *StartedCommand
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPipeline(continueOnError bool) Pipeline {
+	return Pipeline{ContinueOnError: continueOnError, Steps: []PipelineStep{
+		{Name: "fetch", Status: NodeStatusPending},
+		{Name: "parse", Input: PipelineInputStdin, Status: NodeStatusPending},
+		{Name: "report", Input: PipelineInputEnv, InputEnv: "PREVIOUS_STDOUT", Status: NodeStatusPending},
+	}}
+}
+
+func TestPipelineAdvance(t *testing.T) {
+	zero := 0
+
+	pipeline := newTestPipeline(false)
+
+	run, skip := pipeline.Advance()
+	require.Equal(t, 0, run)
+	require.Empty(t, skip)
+	require.Equal(t, WorkflowStatusRunning, pipeline.AggregateStatus())
+
+	run, skip = pipeline.Advance()
+	require.Equal(t, -1, run)
+	require.Empty(t, skip)
+
+	pipeline.Steps[0].Status, pipeline.Steps[0].ExitStatus = string(StatusDone), &zero
+	run, _ = pipeline.Advance()
+	require.Equal(t, 1, run)
+
+	pipeline.Steps[1].Status, pipeline.Steps[1].ExitStatus = string(StatusDone), &zero
+	run, _ = pipeline.Advance()
+	require.Equal(t, 2, run)
+
+	pipeline.Steps[2].Status, pipeline.Steps[2].ExitStatus = string(StatusDone), &zero
+	run, skip = pipeline.Advance()
+	require.Equal(t, -1, run)
+	require.Empty(t, skip)
+	require.Equal(t, WorkflowStatusSucceeded, pipeline.AggregateStatus())
+}
+
+func TestPipelineAdvanceFailure(t *testing.T) {
+	zero, one := 0, 1
+
+	pipeline := newTestPipeline(false)
+	pipeline.Steps[0].Status, pipeline.Steps[0].ExitStatus = string(StatusDone), &one
+
+	run, skip := pipeline.Advance()
+	require.Equal(t, -1, run)
+	require.Equal(t, []int{1, 2}, skip)
+	require.Equal(t, NodeStatusSkipped, pipeline.Steps[2].Status)
+	require.Equal(t, WorkflowStatusFailed, pipeline.AggregateStatus())
+
+	pipeline = newTestPipeline(true)
+	pipeline.Steps[0].Status, pipeline.Steps[0].ExitStatus = string(StatusDone), &one
+
+	run, skip = pipeline.Advance()
+	require.Equal(t, 1, run)
+	require.Empty(t, skip)
+
+	pipeline.Steps[1].Status = string(StatusStopped)
+	run, skip = pipeline.Advance()
+	require.Equal(t, -1, run)
+	require.Equal(t, []int{2}, skip)
+
+	pipeline = newTestPipeline(true)
+	pipeline.Steps[0].Status, pipeline.Steps[0].ExitStatus = string(StatusTimedOut), nil
+	pipeline.Steps[1].Status, pipeline.Steps[1].ExitStatus = string(StatusDone), &zero
+	pipeline.Steps[2].Status, pipeline.Steps[2].ExitStatus = string(StatusDone), &zero
+	require.Equal(t, WorkflowStatusFailed, pipeline.AggregateStatus())
+}
+
+func TestEnvInputError(t *testing.T) {
+	require.Empty(t, EnvInputError("PREVIOUS_STDOUT", []byte("a\nb\n")))
+	require.Empty(t, EnvInputError("OUT", []byte(strings.Repeat("a", 128*1024-5))))
+
+	require.Contains(t, EnvInputError("OUT", []byte("a\x00b")), "NUL byte")
+	require.Contains(t, EnvInputError("OUT", []byte(strings.Repeat("a", 128*1024-4))), "too large")
+}
@@ -0,0 +1,15 @@
+package domain
+
+type QueuedCommand struct {
+	ID         int
+	ParentID   *int
+	WorkflowID *int
+	PipelineID *int
+	Command    CommandFromUser
+}
+
+// StopRequest asks the instance that started a command to signal its process.
+type StopRequest struct {
+	ID     int
+	Signal int
+}
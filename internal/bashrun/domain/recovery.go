@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+const (
+	RecoverPolicyRequeue = "requeue"
+	RecoverPolicyExpire  = "expire"
+)
+
+type ActiveCommand struct {
+	ID          int
+	Status      CommandStatus
+	PID         int
+	CreatedAt   time.Time
+	StartedAt   *time.Time
+	CallbackURL string
+	ParentID    *int
+	WorkflowID  *int
+	PipelineID  *int
+	Owner       string
+}
+
+type RecoverySummary struct {
+	Lost       int
+	Reattached int
+	Requeued   int
+	Expired    int
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	BackoffFixed       = "fixed"
+	BackoffExponential = "exponential"
+)
+
+type RetryPolicy struct {
+	MaxAttempts     int    `json:"max_attempts"`
+	Backoff         string `json:"backoff,omitempty"`
+	DelaySeconds    int    `json:"delay_seconds,omitempty"`
+	MaxDelaySeconds int    `json:"max_delay_seconds,omitempty"`
+	Jitter          bool   `json:"jitter,omitempty"`
+	ExitCodes       []int  `json:"exit_codes,omitempty"`
+}
+
+// Retryable reports whether an attempt finished with an exit code worth
+// another try: any non-zero code or only the listed ones.
+func (p RetryPolicy) Retryable(status CommandStatus, exitStatus *int) bool {
+	if status != StatusDone || exitStatus == nil || *exitStatus == 0 {
+		return false
+	}
+
+	return len(p.ExitCodes) == 0 || slices.Contains(p.ExitCodes, *exitStatus)
+}
+
+type Attempt struct {
+	ID                int
+	ParentID          int
+	Number            int
+	Status            CommandStatus
+	ExitStatus        *int
+	Retry             RetryPolicy
+	ParentStatus      CommandStatus
+	ParentCallbackURL string
+	ParentWorkflowID  *int
+	ParentPipelineID  *int
+}
+
+type NewAttempt struct {
+	ParentID  int
+	Number    int
+	CreatedAt time.Time
+	RunAt     *time.Time
+}
+
+// InitialStatus keeps an attempt waiting for its backoff delay scheduled.
+func (a NewAttempt) InitialStatus() CommandStatus {
+	return initialStatus(a.CreatedAt, a.RunAt)
+}
+
+// CommandLinks describes what else has to be updated when a command is
+// stopped before it gets a process.
+type CommandLinks struct {
+	Parent        bool
+	Attempt       bool
+	ActiveAttempt *int
+	WorkflowID    *int
+	PipelineID    *int
+	CallbackURL   string
+}
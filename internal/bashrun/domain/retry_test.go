@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicyRetryable(t *testing.T) {
+	zero, one, two := 0, 1, 2
+
+	anyCode := RetryPolicy{MaxAttempts: 3}
+	require.True(t, anyCode.Retryable(StatusDone, &one))
+	require.True(t, anyCode.Retryable(StatusDone, &two))
+	require.False(t, anyCode.Retryable(StatusDone, &zero))
+	require.False(t, anyCode.Retryable(StatusDone, nil))
+	require.False(t, anyCode.Retryable(StatusTimedOut, &one))
+	require.False(t, anyCode.Retryable(StatusStopped, &one))
+
+	listed := RetryPolicy{MaxAttempts: 3, ExitCodes: []int{2}}
+	require.True(t, listed.Retryable(StatusDone, &two))
+	require.False(t, listed.Retryable(StatusDone, &one))
+}
@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+const (
+	OverlapSkip    = "skip"
+	OverlapQueue   = "queue"
+	OverlapReplace = "replace"
+)
+
+type ScheduleFromUser struct {
+	Cron     string          `json:"cron"`
+	Timezone string          `json:"timezone,omitempty"`
+	Overlap  string          `json:"overlap,omitempty"`
+	Command  CommandFromUser `json:"command"`
+}
+
+type Schedule struct {
+	ID            int             `json:"schedule_id"`
+	Cron          string          `json:"cron"`
+	Timezone      string          `json:"timezone"`
+	Overlap       string          `json:"overlap"`
+	Command       CommandFromUser `json:"command"`
+	Paused        bool            `json:"paused"`
+	NextRunAt     *time.Time      `json:"next_run_at"`
+	LastRunAt     *time.Time      `json:"last_run_at"`
+	LastCommandID *int            `json:"last_command_id"`
+	SkippedRuns   int             `json:"skipped_runs"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+type ScheduleRun struct {
+	ScheduleID int
+	Overlap    string
+	Command    CommandFromUser
+	RunAt      time.Time
+	NextRunAt  *time.Time
+}
+
+type ScheduleRunResult struct {
+	CommandID int
+	Skipped   bool
+	Replaced  []int
+}
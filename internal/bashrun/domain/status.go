@@ -0,0 +1,64 @@
+package domain
+
+type CommandStatus string
+
+const (
+	StatusScheduled           CommandStatus = "scheduled"
+	StatusCreated             CommandStatus = "created"
+	StatusStarted             CommandStatus = "started"
+	StatusDone                CommandStatus = "done"
+	StatusStopped             CommandStatus = "stopped"
+	StatusTimedOut            CommandStatus = "timed_out"
+	StatusOutputLimitExceeded CommandStatus = "output_limit_exceeded"
+	StatusFailed              CommandStatus = "failed"
+	StatusLost                CommandStatus = "lost"
+)
+
+var statusTransitions = map[CommandStatus][]CommandStatus{
+	StatusScheduled: {StatusCreated, StatusStopped},
+	StatusCreated:   {StatusStarted, StatusStopped, StatusFailed, StatusLost},
+	StatusStarted:   {StatusDone, StatusStopped, StatusTimedOut, StatusOutputLimitExceeded, StatusFailed, StatusLost},
+}
+
+var knownStatuses = []CommandStatus{
+	StatusScheduled,
+	StatusCreated,
+	StatusStarted,
+	StatusDone,
+	StatusStopped,
+	StatusTimedOut,
+	StatusOutputLimitExceeded,
+	StatusFailed,
+	StatusLost,
+}
+
+type StatusTransition struct {
+	From  CommandStatus
+	To    CommandStatus
+	Error string
+}
+
+func (s CommandStatus) Valid() bool {
+	for _, status := range knownStatuses {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s CommandStatus) Final() bool {
+	_, ok := statusTransitions[s]
+	return s.Valid() && !ok
+}
+
+func (s CommandStatus) CanTransitionTo(next CommandStatus) bool {
+	for _, status := range statusTransitions[s] {
+		if status == next {
+			return true
+		}
+	}
+
+	return false
+}
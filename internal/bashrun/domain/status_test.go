@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandStatusTransitions(t *testing.T) {
+	require.True(t, StatusCreated.CanTransitionTo(StatusStarted))
+	require.True(t, StatusCreated.CanTransitionTo(StatusStopped))
+	require.True(t, StatusStarted.CanTransitionTo(StatusTimedOut))
+	require.False(t, StatusCreated.CanTransitionTo(StatusDone))
+	require.False(t, StatusStarted.CanTransitionTo(StatusCreated))
+	require.False(t, StatusStopped.CanTransitionTo(StatusDone))
+	require.False(t, StatusDone.CanTransitionTo(StatusStopped))
+	require.True(t, StatusStarted.CanTransitionTo(StatusLost))
+	require.False(t, StatusLost.CanTransitionTo(StatusStarted))
+	require.True(t, StatusScheduled.CanTransitionTo(StatusCreated))
+	require.True(t, StatusScheduled.CanTransitionTo(StatusStopped))
+	require.False(t, StatusScheduled.CanTransitionTo(StatusStarted))
+
+	require.True(t, StatusDone.Final())
+	require.True(t, StatusFailed.Final())
+	require.True(t, StatusLost.Final())
+	require.False(t, StatusStarted.Final())
+	require.False(t, StatusScheduled.Final())
+	require.False(t, CommandStatus("failed to create pipe").Final())
+	require.False(t, CommandStatus("failed to create pipe").Valid())
+}
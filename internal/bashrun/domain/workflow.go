@@ -0,0 +1,185 @@
+package domain
+
+import "time"
+
+const (
+	ConditionOnSuccess = "on_success"
+	ConditionOnFailure = "on_failure"
+	ConditionAlways    = "always"
+
+	WorkflowStatusRunning   = "running"
+	WorkflowStatusSucceeded = "succeeded"
+	WorkflowStatusFailed    = "failed"
+
+	NodeStatusPending = "pending"
+	NodeStatusSkipped = "skipped"
+)
+
+type WorkflowNodeFromUser struct {
+	Name      string          `json:"name"`
+	DependsOn []string        `json:"depends_on,omitempty"`
+	Condition string          `json:"condition,omitempty"`
+	Command   CommandFromUser `json:"command"`
+}
+
+type WorkflowFromUser struct {
+	Name  string                 `json:"name,omitempty"`
+	Nodes []WorkflowNodeFromUser `json:"nodes"`
+}
+
+// Acyclic reports whether the nodes can be ordered so that every node comes
+// after its dependencies. Dependencies should reference existing nodes.
+func (w WorkflowFromUser) Acyclic() bool {
+	pending := make(map[string]int, len(w.Nodes))
+	dependents := make(map[string][]string, len(w.Nodes))
+	for _, node := range w.Nodes {
+		pending[node.Name] = len(node.DependsOn)
+		for _, dependency := range node.DependsOn {
+			dependents[dependency] = append(dependents[dependency], node.Name)
+		}
+	}
+
+	var ready []string
+	for name, count := range pending {
+		if count == 0 {
+			ready = append(ready, name)
+		}
+	}
+
+	ordered := 0
+	for len(ready) > 0 {
+		name := ready[len(ready)-1]
+		ready = ready[:len(ready)-1]
+		ordered++
+
+		for _, dependent := range dependents[name] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	return ordered == len(w.Nodes)
+}
+
+type WorkflowNode struct {
+	Name       string          `json:"name"`
+	DependsOn  []string        `json:"depends_on"`
+	Condition  string          `json:"condition"`
+	Status     string          `json:"status"`
+	CommandID  *int            `json:"command_id"`
+	ExitStatus *int            `json:"exit_status"`
+	Command    CommandFromUser `json:"-"`
+}
+
+func (n WorkflowNode) resolved() bool {
+	return stepResolved(n.Status)
+}
+
+func (n WorkflowNode) succeeded() bool {
+	return stepSucceeded(n.Status, n.ExitStatus)
+}
+
+func (n WorkflowNode) failed() bool {
+	return stepFailed(n.Status, n.ExitStatus)
+}
+
+// stepResolved, stepSucceeded and stepFailed describe the outcome of a
+// workflow node or a pipeline step by the status and exit code of its command.
+func stepResolved(status string) bool {
+	return status == NodeStatusSkipped || CommandStatus(status).Final()
+}
+
+func stepSucceeded(status string, exitStatus *int) bool {
+	return status == string(StatusDone) && exitStatus != nil && *exitStatus == 0
+}
+
+func stepFailed(status string, exitStatus *int) bool {
+	return CommandStatus(status).Final() && !stepSucceeded(status, exitStatus)
+}
+
+type Workflow struct {
+	ID         int            `json:"workflow_id"`
+	Name       string         `json:"name"`
+	Status     string         `json:"status"`
+	CreatedAt  time.Time      `json:"created_at"`
+	FinishedAt *time.Time     `json:"finished_at"`
+	Nodes      []WorkflowNode `json:"nodes,omitempty"`
+}
+
+// Advance decides what happens to pending nodes whose dependencies are all
+// resolved: on_success runs when every dependency succeeded, on_failure when
+// at least one failed and always in any case, otherwise the node is skipped.
+// A skipped node resolves its dependents in the same call, the statuses of
+// both returned lists are updated in w.
+func (w *Workflow) Advance() (run []int, skip []int) {
+	index := make(map[string]int, len(w.Nodes))
+	for i, node := range w.Nodes {
+		index[node.Name] = i
+	}
+
+	for changed := true; changed; {
+		changed = false
+
+		for i := range w.Nodes {
+			node := &w.Nodes[i]
+			if node.Status != NodeStatusPending {
+				continue
+			}
+
+			resolved, succeeded, failed := true, true, false
+			for _, dependency := range node.DependsOn {
+				dependencyNode := w.Nodes[index[dependency]]
+				resolved = resolved && dependencyNode.resolved()
+				succeeded = succeeded && dependencyNode.succeeded()
+				failed = failed || dependencyNode.failed()
+			}
+
+			if !resolved {
+				continue
+			}
+
+			switch {
+			case node.Condition == ConditionAlways,
+				node.Condition == ConditionOnFailure && failed,
+				node.Condition == ConditionOnSuccess && succeeded:
+				node.Status = string(StatusCreated)
+				run = append(run, i)
+			default:
+				node.Status = NodeStatusSkipped
+				skip = append(skip, i)
+				changed = true
+			}
+		}
+	}
+
+	return run, skip
+}
+
+// AggregateStatus is running until every node is finished or skipped, then
+// failed if any node failed, even when an on_failure node handled it.
+func (w Workflow) AggregateStatus() string {
+	status := WorkflowStatusSucceeded
+	for _, node := range w.Nodes {
+		if !node.resolved() {
+			return WorkflowStatusRunning
+		}
+
+		if node.failed() {
+			status = WorkflowStatusFailed
+		}
+	}
+
+	return status
+}
+
+type WorkflowAdvance struct {
+	Status  string
+	Started []StartedCommand
+}
+
+type StartedCommand struct {
+	ID     int
+	Status CommandStatus
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflowFromUserAcyclic(t *testing.T) {
+	chain := WorkflowFromUser{Nodes: []WorkflowNodeFromUser{
+		{Name: "c", DependsOn: []string{"a", "b"}},
+		{Name: "a"},
+		{Name: "b", DependsOn: []string{"a"}},
+	}}
+	require.True(t, chain.Acyclic())
+
+	cycle := WorkflowFromUser{Nodes: []WorkflowNodeFromUser{
+		{Name: "a"},
+		{Name: "b", DependsOn: []string{"a", "c"}},
+		{Name: "c", DependsOn: []string{"b"}},
+	}}
+	require.False(t, cycle.Acyclic())
+}
+
+func TestWorkflowAdvance(t *testing.T) {
+	zero, one := 0, 1
+
+	workflow := Workflow{Nodes: []WorkflowNode{
+		{Name: "build", Condition: ConditionOnSuccess, Status: NodeStatusPending},
+		{Name: "test", DependsOn: []string{"build"}, Condition: ConditionOnSuccess, Status: NodeStatusPending},
+		{Name: "deploy", DependsOn: []string{"test"}, Condition: ConditionOnSuccess, Status: NodeStatusPending},
+		{Name: "notify", DependsOn: []string{"test"}, Condition: ConditionOnFailure, Status: NodeStatusPending},
+		{Name: "cleanup", DependsOn: []string{"deploy", "notify"}, Condition: ConditionAlways, Status: NodeStatusPending},
+	}}
+
+	run, skip := workflow.Advance()
+	require.Equal(t, []int{0}, run)
+	require.Empty(t, skip)
+	require.Equal(t, WorkflowStatusRunning, workflow.AggregateStatus())
+
+	run, skip = workflow.Advance()
+	require.Empty(t, run)
+	require.Empty(t, skip)
+
+	workflow.Nodes[0].Status, workflow.Nodes[0].ExitStatus = string(StatusDone), &zero
+	run, skip = workflow.Advance()
+	require.Equal(t, []int{1}, run)
+	require.Empty(t, skip)
+
+	workflow.Nodes[1].Status, workflow.Nodes[1].ExitStatus = string(StatusDone), &one
+	run, skip = workflow.Advance()
+	require.Equal(t, []int{3}, run)
+	require.Equal(t, []int{2}, skip)
+	require.Equal(t, NodeStatusSkipped, workflow.Nodes[2].Status)
+
+	workflow.Nodes[3].Status, workflow.Nodes[3].ExitStatus = string(StatusDone), &zero
+	run, skip = workflow.Advance()
+	require.Equal(t, []int{4}, run)
+	require.Empty(t, skip)
+
+	workflow.Nodes[4].Status, workflow.Nodes[4].ExitStatus = string(StatusDone), &zero
+	require.Equal(t, WorkflowStatusFailed, workflow.AggregateStatus())
+}
+
+func TestWorkflowAdvanceSkipPropagation(t *testing.T) {
+	zero := 0
+
+	workflow := Workflow{Nodes: []WorkflowNode{
+		{Name: "build", Condition: ConditionOnSuccess, Status: string(StatusDone), ExitStatus: &zero},
+		{Name: "rollback", DependsOn: []string{"build"}, Condition: ConditionOnFailure, Status: NodeStatusPending},
+		{Name: "report", DependsOn: []string{"rollback"}, Condition: ConditionOnSuccess, Status: NodeStatusPending},
+		{Name: "cleanup", DependsOn: []string{"report"}, Condition: ConditionAlways, Status: NodeStatusPending},
+	}}
+
+	run, skip := workflow.Advance()
+	require.Equal(t, []int{3}, run)
+	require.Equal(t, []int{1, 2}, skip)
+
+	workflow.Nodes[3].Status = string(StatusTimedOut)
+	require.Equal(t, WorkflowStatusFailed, workflow.AggregateStatus())
+
+	workflow.Nodes[3].Status, workflow.Nodes[3].ExitStatus = string(StatusDone), &zero
+	require.Equal(t, WorkflowStatusSucceeded, workflow.AggregateStatus())
+}
@@ -1,10 +1,17 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	appErrors "github.com/PoorMercymain/bashrun/errors"
 	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
@@ -13,6 +20,47 @@ import (
 	"github.com/PoorMercymain/bashrun/pkg/reqval"
 )
 
+const (
+	keepAliveInterval = 15 * time.Second
+	maxScriptSize     = 1 << 20
+)
+
+var (
+	lineBreakReplacer = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+	commandValidationErrors = []error{
+		appErrors.ErrWrongTimeout,
+		appErrors.ErrWrongKillGrace,
+		appErrors.ErrWrongMaxOutput,
+		appErrors.ErrWrongOutputPolicy,
+		appErrors.ErrWrongCallback,
+		appErrors.ErrWrongPriority,
+		appErrors.ErrWrongEnv,
+		appErrors.ErrWrongWorkdir,
+		appErrors.ErrWrongStdin,
+		appErrors.ErrWrongArgv,
+		appErrors.ErrWrongInterpreter,
+		appErrors.ErrWrongLabels,
+		appErrors.ErrWrongRunAt,
+		appErrors.ErrWrongRetry,
+	}
+
+	scheduleValidationErrors = append([]error{
+		appErrors.ErrWrongCron,
+		appErrors.ErrWrongTimezone,
+		appErrors.ErrWrongOverlap,
+	}, commandValidationErrors...)
+
+	workflowValidationErrors = append([]error{
+		appErrors.ErrWrongWorkflow,
+		appErrors.ErrWorkflowCycle,
+	}, commandValidationErrors...)
+
+	pipelineValidationErrors = append([]error{
+		appErrors.ErrWrongPipeline,
+	}, commandValidationErrors...)
+)
+
 type bashrunHandlers struct {
 	srv domain.BashrunService
 }
@@ -53,14 +101,21 @@ func (h *bashrunHandlers) CreateCommand(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if command.Command == "" {
+	if command.Command == "" && len(command.Argv) == 0 {
 		errwriter.WriteHTTPError(w, appErrors.ErrEmptyCommand, http.StatusBadRequest, logPrefix)
 		return
 	}
 
 	var commandID domain.ID
-	commandID.ID, err = h.srv.CreateCommand(r.Context(), command.Command)
+	commandID.ID, err = h.srv.CreateCommand(r.Context(), command)
 	if err != nil {
+		for _, validationErr := range commandValidationErrors {
+			if errors.Is(err, validationErr) {
+				errwriter.WriteHTTPError(w, validationErr, http.StatusBadRequest, logPrefix)
+				return
+			}
+		}
+
 		errwriter.WriteHTTPError(w, err, http.StatusInternalServerError, logPrefix)
 		return
 	}
@@ -73,52 +128,425 @@ func (h *bashrunHandlers) CreateCommand(w http.ResponseWriter, r *http.Request)
 	}
 }
 
-func (h *bashrunHandlers) ListCommands(w http.ResponseWriter, r *http.Request) {
-	const logPrefix = "handlers.ListCommands"
+func (h *bashrunHandlers) CreateScript(w http.ResponseWriter, r *http.Request) {
+	const logPrefix = "handlers.CreateScript"
 	defer r.Body.Close()
 
-	strLimit := r.URL.Query().Get("limit")
-	strOffset := r.URL.Query().Get("offset")
+	mediaType, err := reqval.ValidateScriptRequest(r)
+	if err != nil {
+		errwriter.WriteHTTPError(w, err, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxScriptSize)
 
-	if strLimit == "" {
-		strLimit = "15"
+	var command domain.CommandFromUser
+	var values url.Values
+	if mediaType == "multipart/form-data" {
+		command.Script, values, err = readMultipartScript(r)
+	} else {
+		command.Script, err = readScriptBody(r.Body)
+		values = r.URL.Query()
 	}
 
-	if strOffset == "" {
-		strOffset = "0"
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			errwriter.WriteHTTPError(w, appErrors.ErrScriptTooLarge, http.StatusRequestEntityTooLarge, logPrefix)
+			return
+		}
+
+		errwriter.WriteHTTPError(w, appErrors.ErrWrongScript, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	if strings.TrimSpace(command.Script) == "" {
+		errwriter.WriteHTTPError(w, appErrors.ErrEmptyScript, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	err = parseScriptOptions(values, &command)
+	if err != nil {
+		errwriter.WriteHTTPError(w, err, http.StatusBadRequest, logPrefix)
+		return
 	}
 
-	limit, err := strconv.Atoi(strLimit)
-	if err != nil || (limit < 1 || limit > 50) {
-		errwriter.WriteHTTPError(w, appErrors.ErrWrongLimit, http.StatusBadRequest, logPrefix)
+	var commandID domain.ID
+	commandID.ID, err = h.srv.CreateCommand(r.Context(), command)
+	if err != nil {
+		for _, validationErr := range commandValidationErrors {
+			if errors.Is(err, validationErr) {
+				errwriter.WriteHTTPError(w, validationErr, http.StatusBadRequest, logPrefix)
+				return
+			}
+		}
+
+		errwriter.WriteHTTPError(w, err, http.StatusInternalServerError, logPrefix)
 		return
 	}
 
-	offset, err := strconv.Atoi(strOffset)
-	if err != nil || (offset < 0) {
-		errwriter.WriteHTTPError(w, appErrors.ErrWrongOffset, http.StatusBadRequest, logPrefix)
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+
+	if err = json.NewEncoder(w).Encode(commandID); err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+	}
+}
+
+func readScriptBody(body io.Reader) (string, error) {
+	script, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(script), nil
+}
+
+func readMultipartScript(r *http.Request) (string, url.Values, error) {
+	err := r.ParseMultipartForm(maxScriptSize)
+	if err != nil {
+		return "", nil, err
+	}
+
+	values := url.Values(r.MultipartForm.Value)
+
+	files := r.MultipartForm.File["script"]
+	if len(files) == 0 {
+		if len(values["script"]) != 1 {
+			return "", nil, appErrors.ErrWrongScript
+		}
+
+		return values.Get("script"), values, nil
+	}
+
+	if len(files) > 1 || len(values["script"]) > 0 {
+		return "", nil, appErrors.ErrWrongScript
+	}
+
+	file, err := files[0].Open()
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+
+	script, err := readScriptBody(file)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return script, values, nil
+}
+
+func parseScriptOptions(values url.Values, command *domain.CommandFromUser) error {
+	command.ScriptArgs = values["arg"]
+	command.Interpreter = values.Get("interpreter")
+	command.Workdir = values.Get("workdir")
+	command.Labels = values["label"]
+
+	if values.Has("timeout_seconds") {
+		timeout, err := strconv.Atoi(values.Get("timeout_seconds"))
+		if err != nil {
+			return appErrors.ErrWrongTimeout
+		}
+
+		command.TimeoutSeconds = &timeout
+	}
+
+	if values.Has("kill_grace_seconds") {
+		killGrace, err := strconv.Atoi(values.Get("kill_grace_seconds"))
+		if err != nil {
+			return appErrors.ErrWrongKillGrace
+		}
+
+		command.KillGraceSeconds = &killGrace
+	}
+
+	if values.Has("priority") {
+		priority, err := strconv.Atoi(values.Get("priority"))
+		if err != nil {
+			return appErrors.ErrWrongPriority
+		}
+
+		command.Priority = priority
+	}
+
+	if values.Has("delay_seconds") {
+		delay, err := strconv.Atoi(values.Get("delay_seconds"))
+		if err != nil {
+			return appErrors.ErrWrongRunAt
+		}
+
+		command.DelaySeconds = &delay
+	}
+
+	runAt, err := parseTimeParam(values, "run_at")
+	if err != nil {
+		return appErrors.ErrWrongRunAt
+	}
+
+	command.RunAt = runAt
+
+	if values.Has("max_output_bytes") {
+		maxOutput, err := strconv.ParseInt(values.Get("max_output_bytes"), 10, 64)
+		if err != nil {
+			return appErrors.ErrWrongMaxOutput
+		}
+
+		command.MaxOutputBytes = &maxOutput
+	}
+
+	command.OutputPolicy = values.Get("output_policy")
+	command.CallbackURL = values.Get("callback_url")
+	command.CallbackSecret = values.Get("callback_secret")
+
+	command.Retry, err = parseRetryOptions(values)
+	if err != nil {
+		return appErrors.ErrWrongRetry
+	}
+
+	return nil
+}
+
+func parseRetryOptions(values url.Values) (*domain.RetryPolicy, error) {
+	if !values.Has("retry_max_attempts") {
+		return nil, nil
+	}
+
+	var err error
+	policy := &domain.RetryPolicy{Backoff: values.Get("retry_backoff")}
+
+	policy.MaxAttempts, err = strconv.Atoi(values.Get("retry_max_attempts"))
+	if err != nil {
+		return nil, err
+	}
+
+	if values.Has("retry_delay_seconds") {
+		policy.DelaySeconds, err = strconv.Atoi(values.Get("retry_delay_seconds"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if values.Has("retry_max_delay_seconds") {
+		policy.MaxDelaySeconds, err = strconv.Atoi(values.Get("retry_max_delay_seconds"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if values.Has("retry_jitter") {
+		policy.Jitter, err = strconv.ParseBool(values.Get("retry_jitter"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, value := range values["retry_exit_code"] {
+		code, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, err
+		}
+
+		policy.ExitCodes = append(policy.ExitCodes, code)
+	}
+
+	return policy, nil
+}
+
+func (h *bashrunHandlers) ListCommands(w http.ResponseWriter, r *http.Request) {
+	const logPrefix = "handlers.ListCommands"
+	defer r.Body.Close()
+
+	filter, err := parseListFilter(r.URL.Query())
+	if err != nil {
+		errwriter.WriteHTTPError(w, err, http.StatusBadRequest, logPrefix)
 		return
 	}
 
-	commands, err := h.srv.ListCommands(r.Context(), limit, offset)
+	page, err := h.srv.ListCommands(r.Context(), filter)
 	if err != nil {
 		if errors.Is(err, appErrors.ErrNoRows) {
 			w.WriteHeader(http.StatusNoContent)
 			return
 		}
 
+		if errors.Is(err, appErrors.ErrWrongCommandRegex) {
+			errwriter.WriteHTTPError(w, appErrors.ErrWrongCommandRegex, http.StatusBadRequest, logPrefix)
+			return
+		}
+
+		if errors.Is(err, appErrors.ErrWrongCursor) {
+			errwriter.WriteHTTPError(w, appErrors.ErrWrongCursor, http.StatusBadRequest, logPrefix)
+			return
+		}
+
 		errwriter.WriteHTTPError(w, err, http.StatusInternalServerError, logPrefix)
 		return
 	}
 
+	var links []string
+	if page.NextCursor != "" {
+		links = append(links, fmt.Sprintf("<%s>; rel=\"next\"", pageURL(r.URL, "after", page.NextCursor)))
+	}
+
+	if page.PrevCursor != "" {
+		links = append(links, fmt.Sprintf("<%s>; rel=\"prev\"", pageURL(r.URL, "before", page.PrevCursor)))
+	}
+
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
 	w.Header().Add("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
-	if err = json.NewEncoder(w).Encode(commands); err != nil {
+	if err = json.NewEncoder(w).Encode(page.Commands); err != nil {
 		logger.Logger().Error(logPrefix, ": ", err.Error())
 	}
 }
 
+func pageURL(current *url.URL, cursorParam string, cursor string) string {
+	query := current.Query()
+	query.Del("offset")
+	query.Del("after")
+	query.Del("before")
+	query.Set(cursorParam, cursor)
+
+	return (&url.URL{Path: current.Path, RawQuery: query.Encode()}).String()
+}
+
+func parseListFilter(query url.Values) (domain.CommandFilter, error) {
+	strLimit := query.Get("limit")
+	strOffset := query.Get("offset")
+
+	if strLimit == "" {
+		strLimit = "15"
+	}
+
+	if strOffset == "" {
+		strOffset = "0"
+	}
+
+	var filter domain.CommandFilter
+	var err error
+
+	filter.Limit, err = strconv.Atoi(strLimit)
+	if err != nil || (filter.Limit < 1 || filter.Limit > 50) {
+		return domain.CommandFilter{}, appErrors.ErrWrongLimit
+	}
+
+	filter.Offset, err = strconv.Atoi(strOffset)
+	if err != nil || (filter.Offset < 0) {
+		return domain.CommandFilter{}, appErrors.ErrWrongOffset
+	}
+
+	if query.Has("exit_status") {
+		exitStatus, err := strconv.Atoi(query.Get("exit_status"))
+		if err != nil {
+			return domain.CommandFilter{}, appErrors.ErrWrongExitStatus
+		}
+
+		filter.ExitStatus = &exitStatus
+	}
+
+	filter.CreatedFrom, err = parseTimeParam(query, "created_from")
+	if err != nil {
+		return domain.CommandFilter{}, appErrors.ErrWrongCreatedRange
+	}
+
+	filter.CreatedTo, err = parseTimeParam(query, "created_to")
+	if err != nil {
+		return domain.CommandFilter{}, appErrors.ErrWrongCreatedRange
+	}
+
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && filter.CreatedFrom.After(*filter.CreatedTo) {
+		return domain.CommandFilter{}, appErrors.ErrWrongCreatedRange
+	}
+
+	// the regex is checked by PostgreSQL, its syntax differs from Go regexp
+	filter.CommandRegex = query.Get("command_regex")
+
+	filter.SortBy = query.Get("sort")
+	switch filter.SortBy {
+	case "":
+		filter.SortBy = domain.SortByID
+	case domain.SortByID, domain.SortByCreatedAt, domain.SortByDuration:
+	default:
+		return domain.CommandFilter{}, appErrors.ErrWrongSort
+	}
+
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		filter.Descending = true
+	default:
+		return domain.CommandFilter{}, appErrors.ErrWrongOrder
+	}
+
+	filter.Status = domain.CommandStatus(query.Get("status"))
+	if filter.Status != "" && !filter.Status.Valid() {
+		return domain.CommandFilter{}, appErrors.ErrWrongStatus
+	}
+
+	if query.Has("schedule_id") {
+		scheduleID, err := strconv.Atoi(query.Get("schedule_id"))
+		if err != nil || scheduleID < 1 {
+			return domain.CommandFilter{}, appErrors.ErrWrongScheduleID
+		}
+
+		filter.ScheduleID = &scheduleID
+	}
+
+	if query.Has("workflow_id") {
+		workflowID, err := strconv.Atoi(query.Get("workflow_id"))
+		if err != nil || workflowID < 1 {
+			return domain.CommandFilter{}, appErrors.ErrWrongWorkflowID
+		}
+
+		filter.WorkflowID = &workflowID
+	}
+
+	if query.Has("pipeline_id") {
+		pipelineID, err := strconv.Atoi(query.Get("pipeline_id"))
+		if err != nil || pipelineID < 1 {
+			return domain.CommandFilter{}, appErrors.ErrWrongPipelineID
+		}
+
+		filter.PipelineID = &pipelineID
+	}
+
+	filter.Command = query.Get("command")
+	filter.Label = query.Get("label")
+	filter.Search = query.Get("q")
+	filter.After = query.Get("after")
+	filter.Before = query.Get("before")
+
+	if query.Has("include_output") {
+		includeOutput, err := strconv.ParseBool(query.Get("include_output"))
+		if err != nil {
+			return domain.CommandFilter{}, appErrors.ErrWrongIncludeOutput
+		}
+
+		filter.OmitOutput = !includeOutput
+	}
+
+	return filter, nil
+}
+
+func parseTimeParam(query url.Values, name string) (*time.Time, error) {
+	if !query.Has(name) {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, query.Get(name))
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
 func (h *bashrunHandlers) StopCommand(w http.ResponseWriter, r *http.Request) {
 	const logPrefix = "handlers.StopCommand"
 	defer r.Body.Close()
@@ -129,8 +557,13 @@ func (h *bashrunHandlers) StopCommand(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.srv.StopCommand(r.Context(), id)
+	err = h.srv.StopCommand(r.Context(), id, r.URL.Query().Get("signal"))
 	if err != nil {
+		if errors.Is(err, appErrors.ErrWrongSignal) {
+			errwriter.WriteHTTPError(w, appErrors.ErrWrongSignal, http.StatusBadRequest, logPrefix)
+			return
+		}
+
 		if errors.Is(err, appErrors.ErrNoRows) {
 			errwriter.WriteHTTPError(w, appErrors.ErrCommandNotFound, http.StatusNotFound, logPrefix)
 			return
@@ -141,6 +574,11 @@ func (h *bashrunHandlers) StopCommand(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if errors.Is(err, appErrors.ErrStatusConflict) {
+			errwriter.WriteHTTPError(w, appErrors.ErrStatusConflict, http.StatusConflict, logPrefix)
+			return
+		}
+
 		errwriter.WriteHTTPError(w, err, http.StatusInternalServerError, logPrefix)
 		return
 	}
@@ -187,7 +625,36 @@ func (h *bashrunHandlers) ReadOutput(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	output, err := h.srv.ReadOutput(r.Context(), id)
+	combined := false
+	if strCombined := r.URL.Query().Get("combined"); strCombined != "" {
+		combined, err = strconv.ParseBool(strCombined)
+		if err != nil {
+			errwriter.WriteHTTPError(w, appErrors.ErrWrongCombined, http.StatusBadRequest, logPrefix)
+			return
+		}
+	}
+
+	rng, partial, err := parseOutputRange(r)
+	if err != nil {
+		errwriter.WriteHTTPError(w, err, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	download, err := parseDownload(r.URL.Query())
+	if err != nil {
+		errwriter.WriteHTTPError(w, err, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	var output domain.OutputSlice
+	stream := domain.StreamStdout
+	if combined {
+		stream = domain.StreamCombined
+		output, err = h.srv.ReadCombinedOutput(r.Context(), id, rng)
+	} else {
+		output, err = h.srv.ReadOutput(r.Context(), id, rng)
+	}
+
 	if err != nil {
 		if errors.Is(err, appErrors.ErrNoRows) {
 			errwriter.WriteHTTPError(w, appErrors.ErrCommandNotFound, http.StatusNotFound, logPrefix)
@@ -198,15 +665,736 @@ func (h *bashrunHandlers) ReadOutput(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(output) == 0 {
+	if download {
+		setDownloadFilename(w, id, stream)
+	}
+
+	writeOutput(w, r, output, partial, logPrefix)
+}
+
+func writeOutput(w http.ResponseWriter, r *http.Request, output domain.OutputSlice, partial bool, logPrefix string) {
+	w.Header().Set("Accept-Ranges", "bytes")
+
+	if partial && len(output.Data) == 0 {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", output.Total))
+		errwriter.WriteHTTPError(w, appErrors.ErrRangeNotSatisfiable, http.StatusRequestedRangeNotSatisfiable, logPrefix)
+		return
+	}
+
+	if len(output.Data) == 0 {
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
-	w.Header().Add("Content-Type", "text/plain")
+	w.Header().Add("Content-Type", outputContentType(r))
+
+	status := http.StatusOK
+	if partial {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", output.Start, output.Start+int64(len(output.Data))-1, output.Total))
+		status = http.StatusPartialContent
+	}
+
+	w.WriteHeader(status)
+
+	if _, err := w.Write([]byte(output.Data)); err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+	}
+}
+
+func parseDownload(query url.Values) (bool, error) {
+	if !query.Has("download") {
+		return false, nil
+	}
+
+	download, err := strconv.ParseBool(query.Get("download"))
+	if err != nil {
+		return false, appErrors.ErrWrongDownload
+	}
+
+	return download, nil
+}
+
+func setDownloadFilename(w http.ResponseWriter, id int, stream string) {
+	filename := fmt.Sprintf("command-%d-%s.log", id, stream)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+}
+
+func outputContentType(r *http.Request) string {
+	if r.URL.Query().Get("format") == "raw" {
+		return "application/octet-stream"
+	}
+
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err == nil && mediaType == "application/octet-stream" {
+			return "application/octet-stream"
+		}
+	}
+
+	return "text/plain"
+}
+
+func parseOutputRange(r *http.Request) (domain.OutputRange, bool, error) {
+	query := r.URL.Query()
+	if !query.Has("offset") && !query.Has("limit") && !query.Has("tail") && !query.Has("unit") {
+		rng, ok := parseRangeHeader(r.Header.Get("Range"))
+		return rng, ok, nil
+	}
+
+	var rng domain.OutputRange
+	var err error
+
+	if query.Has("offset") {
+		rng.Offset, err = strconv.ParseInt(query.Get("offset"), 10, 64)
+		if err != nil || rng.Offset < 0 {
+			return domain.OutputRange{}, false, appErrors.ErrWrongOffset
+		}
+	}
+
+	if query.Has("limit") {
+		rng.Limit, err = strconv.ParseInt(query.Get("limit"), 10, 64)
+		if err != nil || rng.Limit < 1 {
+			return domain.OutputRange{}, false, appErrors.ErrWrongOutputLimit
+		}
+	}
+
+	switch query.Get("unit") {
+	case "", "bytes":
+	case "lines":
+		rng.Lines = true
+	default:
+		return domain.OutputRange{}, false, appErrors.ErrWrongOutputUnit
+	}
+
+	if query.Has("tail") {
+		rng.Tail, err = strconv.ParseInt(query.Get("tail"), 10, 64)
+		if err != nil || rng.Tail < 1 || query.Has("offset") || query.Has("limit") {
+			return domain.OutputRange{}, false, appErrors.ErrWrongTail
+		}
+	}
+
+	return rng, false, nil
+}
+
+func parseRangeHeader(header string) (domain.OutputRange, bool) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return domain.OutputRange{}, false
+	}
+
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return domain.OutputRange{}, false
+	}
+
+	if first == "" {
+		suffix, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || suffix < 1 {
+			return domain.OutputRange{}, false
+		}
+
+		return domain.OutputRange{Suffix: suffix}, true
+	}
+
+	offset, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || offset < 0 {
+		return domain.OutputRange{}, false
+	}
+
+	rng := domain.OutputRange{Offset: offset}
+	if last != "" {
+		end, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || end < offset {
+			return domain.OutputRange{}, false
+		}
+
+		rng.Limit = end - offset + 1
+	}
+
+	return rng, true
+}
+
+func (h *bashrunHandlers) CommandSubresource(w http.ResponseWriter, r *http.Request) {
+	switch r.PathValue("subresource") {
+	case "stderr":
+		h.ReadStderr(w, r)
+	case "stream":
+		h.StreamCommand(w, r)
+	case "wait":
+		h.WaitCommand(w, r)
+	case "deliveries":
+		h.ListDeliveries(w, r)
+	case "attempts":
+		h.ListAttempts(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *bashrunHandlers) ReadStderr(w http.ResponseWriter, r *http.Request) {
+	const logPrefix = "handlers.ReadStderr"
+	defer r.Body.Close()
+
+	id, err := strconv.Atoi(r.PathValue("command_id"))
+	if err != nil || id < 1 {
+		errwriter.WriteHTTPError(w, appErrors.ErrWrongID, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	rng, partial, err := parseOutputRange(r)
+	if err != nil {
+		errwriter.WriteHTTPError(w, err, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	download, err := parseDownload(r.URL.Query())
+	if err != nil {
+		errwriter.WriteHTTPError(w, err, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	stderr, err := h.srv.ReadStderr(r.Context(), id, rng)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrNoRows) {
+			errwriter.WriteHTTPError(w, appErrors.ErrCommandNotFound, http.StatusNotFound, logPrefix)
+			return
+		}
+
+		errwriter.WriteHTTPError(w, err, http.StatusInternalServerError, logPrefix)
+		return
+	}
+
+	if download {
+		setDownloadFilename(w, id, domain.StreamStderr)
+	}
+
+	writeOutput(w, r, stderr, partial, logPrefix)
+}
+
+func (h *bashrunHandlers) WaitCommand(w http.ResponseWriter, r *http.Request) {
+	const logPrefix = "handlers.WaitCommand"
+	defer r.Body.Close()
+
+	id, err := strconv.Atoi(r.PathValue("command_id"))
+	if err != nil || id < 1 {
+		errwriter.WriteHTTPError(w, appErrors.ErrWrongID, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	timeout, err := parseWaitTimeout(r.URL.Query())
+	if err != nil {
+		errwriter.WriteHTTPError(w, err, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	command, finished, err := h.srv.WaitCommand(r.Context(), id, timeout)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrNoRows) {
+			errwriter.WriteHTTPError(w, appErrors.ErrCommandNotFound, http.StatusNotFound, logPrefix)
+			return
+		}
+
+		if errors.Is(err, appErrors.ErrWrongWaitTimeout) {
+			errwriter.WriteHTTPError(w, err, http.StatusBadRequest, logPrefix)
+			return
+		}
+
+		errwriter.WriteHTTPError(w, err, http.StatusInternalServerError, logPrefix)
+		return
+	}
+
+	status := http.StatusOK
+	if !finished {
+		status = http.StatusAccepted
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err = json.NewEncoder(w).Encode(command); err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+	}
+}
+
+func parseWaitTimeout(query url.Values) (time.Duration, error) {
+	if !query.Has("timeout") {
+		return 0, nil
+	}
+
+	strTimeout := query.Get("timeout")
+	if seconds, err := strconv.Atoi(strTimeout); err == nil {
+		strTimeout = strconv.Itoa(seconds) + "s"
+	}
+
+	timeout, err := time.ParseDuration(strTimeout)
+	if err != nil || timeout <= 0 {
+		return 0, appErrors.ErrWrongWaitTimeout
+	}
+
+	return timeout, nil
+}
+
+func (h *bashrunHandlers) StreamCommand(w http.ResponseWriter, r *http.Request) {
+	const logPrefix = "handlers.StreamCommand"
+	defer r.Body.Close()
+
+	id, err := strconv.Atoi(r.PathValue("command_id"))
+	if err != nil || id < 1 {
+		errwriter.WriteHTTPError(w, appErrors.ErrWrongID, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	lastEventID := 0
+	if strLastEventID := r.Header.Get("Last-Event-ID"); strLastEventID != "" {
+		lastEventID, err = strconv.Atoi(strLastEventID)
+		if err != nil || lastEventID < 0 {
+			errwriter.WriteHTTPError(w, appErrors.ErrWrongLastEventID, http.StatusBadRequest, logPrefix)
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		errwriter.WriteHTTPError(w, errors.New("streaming is not supported by response writer"), http.StatusInternalServerError, logPrefix)
+		return
+	}
+
+	events, err := h.srv.StreamCommand(r.Context(), id, lastEventID)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrNoRows) {
+			errwriter.WriteHTTPError(w, appErrors.ErrCommandNotFound, http.StatusNotFound, logPrefix)
+			return
+		}
+
+		errwriter.WriteHTTPError(w, err, http.StatusInternalServerError, logPrefix)
+		return
+	}
+
+	w.Header().Add("Content-Type", "text/event-stream")
+	w.Header().Add("Cache-Control", "no-cache")
+	w.Header().Add("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			if err = writeEvent(w, event); err != nil {
+				logger.Logger().Error(logPrefix, ": ", err.Error())
+				return
+			}
+		case <-keepAlive.C:
+			if _, err = io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				logger.Logger().Error(logPrefix, ": ", err.Error())
+				return
+			}
+		}
+
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w io.Writer, event domain.CommandEvent) error {
+	if event.ID > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", event.ID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprintf(w, "event: %s\n", event.Type); err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(lineBreakReplacer.Replace(event.Data), "\n") {
+		if _, err := fmt.Fprintf(w, "data: %s\n", line); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
+
+func (h *bashrunHandlers) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	const logPrefix = "handlers.ListDeliveries"
+	defer r.Body.Close()
+
+	id, err := strconv.Atoi(r.PathValue("command_id"))
+	if err != nil || id < 1 {
+		errwriter.WriteHTTPError(w, appErrors.ErrWrongID, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	deliveries, err := h.srv.ListDeliveries(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrNoRows) {
+			errwriter.WriteHTTPError(w, appErrors.ErrCommandNotFound, http.StatusNotFound, logPrefix)
+			return
+		}
+
+		errwriter.WriteHTTPError(w, err, http.StatusInternalServerError, logPrefix)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(deliveries); err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+	}
+}
+
+func (h *bashrunHandlers) ListAttempts(w http.ResponseWriter, r *http.Request) {
+	const logPrefix = "handlers.ListAttempts"
+	defer r.Body.Close()
+
+	id, err := strconv.Atoi(r.PathValue("command_id"))
+	if err != nil || id < 1 {
+		errwriter.WriteHTTPError(w, appErrors.ErrWrongID, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	attempts, err := h.srv.ListAttempts(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrNoRows) {
+			errwriter.WriteHTTPError(w, appErrors.ErrCommandNotFound, http.StatusNotFound, logPrefix)
+			return
+		}
+
+		errwriter.WriteHTTPError(w, err, http.StatusInternalServerError, logPrefix)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(attempts); err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+	}
+}
+
+func (h *bashrunHandlers) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	const logPrefix = "handlers.CreateSchedule"
+	defer r.Body.Close()
+
+	err := reqval.ValidateJSONRequest(r)
+	if err != nil {
+		errwriter.WriteHTTPError(w, err, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+
+	var schedule domain.ScheduleFromUser
+	if err = d.Decode(&schedule); err != nil {
+		errwriter.WriteHTTPError(w, err, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	if schedule.Command.Command == "" && len(schedule.Command.Argv) == 0 {
+		errwriter.WriteHTTPError(w, appErrors.ErrEmptyCommand, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	var scheduleID domain.ScheduleID
+	scheduleID.ID, err = h.srv.CreateSchedule(r.Context(), schedule)
+	if err != nil {
+		for _, validationErr := range scheduleValidationErrors {
+			if errors.Is(err, validationErr) {
+				errwriter.WriteHTTPError(w, validationErr, http.StatusBadRequest, logPrefix)
+				return
+			}
+		}
+
+		errwriter.WriteHTTPError(w, err, http.StatusInternalServerError, logPrefix)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err = json.NewEncoder(w).Encode(scheduleID); err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+	}
+}
+
+func (h *bashrunHandlers) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	const logPrefix = "handlers.ListSchedules"
+	defer r.Body.Close()
+
+	schedules, err := h.srv.ListSchedules(r.Context())
+	if err != nil {
+		errwriter.WriteHTTPError(w, err, http.StatusInternalServerError, logPrefix)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(schedules); err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+	}
+}
+
+func (h *bashrunHandlers) ReadSchedule(w http.ResponseWriter, r *http.Request) {
+	const logPrefix = "handlers.ReadSchedule"
+	defer r.Body.Close()
+
+	id, err := strconv.Atoi(r.PathValue("schedule_id"))
+	if err != nil || id < 1 {
+		errwriter.WriteHTTPError(w, appErrors.ErrWrongScheduleID, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	schedule, err := h.srv.ReadSchedule(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrNoRows) {
+			errwriter.WriteHTTPError(w, appErrors.ErrScheduleNotFound, http.StatusNotFound, logPrefix)
+			return
+		}
+
+		errwriter.WriteHTTPError(w, err, http.StatusInternalServerError, logPrefix)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(schedule); err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+	}
+}
+
+func (h *bashrunHandlers) PauseSchedule(w http.ResponseWriter, r *http.Request) {
+	h.changeSchedule(w, r, h.srv.PauseSchedule, "handlers.PauseSchedule")
+}
+
+func (h *bashrunHandlers) ResumeSchedule(w http.ResponseWriter, r *http.Request) {
+	h.changeSchedule(w, r, h.srv.ResumeSchedule, "handlers.ResumeSchedule")
+}
+
+func (h *bashrunHandlers) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	h.changeSchedule(w, r, h.srv.DeleteSchedule, "handlers.DeleteSchedule")
+}
+
+func (h *bashrunHandlers) changeSchedule(w http.ResponseWriter, r *http.Request, change func(context.Context, int) error, logPrefix string) {
+	defer r.Body.Close()
+
+	id, err := strconv.Atoi(r.PathValue("schedule_id"))
+	if err != nil || id < 1 {
+		errwriter.WriteHTTPError(w, appErrors.ErrWrongScheduleID, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	err = change(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrNoRows) {
+			errwriter.WriteHTTPError(w, appErrors.ErrScheduleNotFound, http.StatusNotFound, logPrefix)
+			return
+		}
+
+		errwriter.WriteHTTPError(w, err, http.StatusInternalServerError, logPrefix)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *bashrunHandlers) CreateWorkflow(w http.ResponseWriter, r *http.Request) {
+	const logPrefix = "handlers.CreateWorkflow"
+	defer r.Body.Close()
+
+	err := reqval.ValidateJSONRequest(r)
+	if err != nil {
+		errwriter.WriteHTTPError(w, err, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+
+	var workflow domain.WorkflowFromUser
+	if err = d.Decode(&workflow); err != nil {
+		errwriter.WriteHTTPError(w, err, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	for _, node := range workflow.Nodes {
+		if node.Command.Command == "" && len(node.Command.Argv) == 0 {
+			errwriter.WriteHTTPError(w, appErrors.ErrEmptyCommand, http.StatusBadRequest, logPrefix)
+			return
+		}
+	}
+
+	var workflowID domain.WorkflowID
+	workflowID.ID, err = h.srv.CreateWorkflow(r.Context(), workflow)
+	if err != nil {
+		for _, validationErr := range workflowValidationErrors {
+			if errors.Is(err, validationErr) {
+				errwriter.WriteHTTPError(w, validationErr, http.StatusBadRequest, logPrefix)
+				return
+			}
+		}
+
+		errwriter.WriteHTTPError(w, err, http.StatusInternalServerError, logPrefix)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err = json.NewEncoder(w).Encode(workflowID); err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+	}
+}
+
+func (h *bashrunHandlers) ListWorkflows(w http.ResponseWriter, r *http.Request) {
+	const logPrefix = "handlers.ListWorkflows"
+	defer r.Body.Close()
+
+	workflows, err := h.srv.ListWorkflows(r.Context())
+	if err != nil {
+		errwriter.WriteHTTPError(w, err, http.StatusInternalServerError, logPrefix)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(workflows); err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+	}
+}
+
+func (h *bashrunHandlers) ReadWorkflow(w http.ResponseWriter, r *http.Request) {
+	const logPrefix = "handlers.ReadWorkflow"
+	defer r.Body.Close()
+
+	id, err := strconv.Atoi(r.PathValue("workflow_id"))
+	if err != nil || id < 1 {
+		errwriter.WriteHTTPError(w, appErrors.ErrWrongWorkflowID, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	workflow, err := h.srv.ReadWorkflow(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrNoRows) {
+			errwriter.WriteHTTPError(w, appErrors.ErrWorkflowNotFound, http.StatusNotFound, logPrefix)
+			return
+		}
+
+		errwriter.WriteHTTPError(w, err, http.StatusInternalServerError, logPrefix)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(workflow); err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+	}
+}
+
+func (h *bashrunHandlers) CreatePipeline(w http.ResponseWriter, r *http.Request) {
+	const logPrefix = "handlers.CreatePipeline"
+	defer r.Body.Close()
+
+	err := reqval.ValidateJSONRequest(r)
+	if err != nil {
+		errwriter.WriteHTTPError(w, err, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+
+	var pipeline domain.PipelineFromUser
+	if err = d.Decode(&pipeline); err != nil {
+		errwriter.WriteHTTPError(w, err, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	for _, step := range pipeline.Steps {
+		if step.Command.Command == "" && len(step.Command.Argv) == 0 {
+			errwriter.WriteHTTPError(w, appErrors.ErrEmptyCommand, http.StatusBadRequest, logPrefix)
+			return
+		}
+	}
+
+	var pipelineID domain.PipelineID
+	pipelineID.ID, err = h.srv.CreatePipeline(r.Context(), pipeline)
+	if err != nil {
+		for _, validationErr := range pipelineValidationErrors {
+			if errors.Is(err, validationErr) {
+				errwriter.WriteHTTPError(w, validationErr, http.StatusBadRequest, logPrefix)
+				return
+			}
+		}
+
+		errwriter.WriteHTTPError(w, err, http.StatusInternalServerError, logPrefix)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err = json.NewEncoder(w).Encode(pipelineID); err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+	}
+}
+
+func (h *bashrunHandlers) ListPipelines(w http.ResponseWriter, r *http.Request) {
+	const logPrefix = "handlers.ListPipelines"
+	defer r.Body.Close()
+
+	pipelines, err := h.srv.ListPipelines(r.Context())
+	if err != nil {
+		errwriter.WriteHTTPError(w, err, http.StatusInternalServerError, logPrefix)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(pipelines); err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+	}
+}
+
+func (h *bashrunHandlers) ReadPipeline(w http.ResponseWriter, r *http.Request) {
+	const logPrefix = "handlers.ReadPipeline"
+	defer r.Body.Close()
+
+	id, err := strconv.Atoi(r.PathValue("pipeline_id"))
+	if err != nil || id < 1 {
+		errwriter.WriteHTTPError(w, appErrors.ErrWrongPipelineID, http.StatusBadRequest, logPrefix)
+		return
+	}
+
+	pipeline, err := h.srv.ReadPipeline(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrNoRows) {
+			errwriter.WriteHTTPError(w, appErrors.ErrPipelineNotFound, http.StatusNotFound, logPrefix)
+			return
+		}
+
+		errwriter.WriteHTTPError(w, err, http.StatusInternalServerError, logPrefix)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
-	if _, err = w.Write([]byte(output)); err != nil {
+	if err = json.NewEncoder(w).Encode(pipeline); err != nil {
 		logger.Logger().Error(logPrefix, ": ", err.Error())
 	}
 }
@@ -1,27 +1,44 @@
 package handler
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"regexp"
 	"strings"
 	"sync"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
-	"golang.org/x/sync/semaphore"
 
 	appErrors "github.com/PoorMercymain/bashrun/errors"
+	"github.com/PoorMercymain/bashrun/internal/bashrun/config"
 	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
 	"github.com/PoorMercymain/bashrun/internal/bashrun/domain/mocks"
 	"github.com/PoorMercymain/bashrun/internal/bashrun/service"
 )
 
+type limitMatcher int
+
+func (m limitMatcher) Matches(x interface{}) bool {
+	filter, ok := x.(domain.CommandFilter)
+	return ok && filter.Limit == int(m)
+}
+
+func (m limitMatcher) String() string {
+	return fmt.Sprintf("has limit %d", int(m))
+}
+
 type testTableElem struct {
 	caseName       string
 	httpMethod     string
@@ -42,9 +59,31 @@ func testRouter(t *testing.T) *http.ServeMux {
 	var wg sync.WaitGroup
 
 	ar := mocks.NewMockBashrunRepository(ctrl)
-	as := service.New(context.Background(), ar, semaphore.NewWeighted(4), &wg)
+	as := service.New(context.Background(), ar, &wg, &config.Config{RedactedEnvKeys: []string{"PASSWORD"}, MaxConcurrentCommands: 1})
 	ah := New(as)
 
+	queue := make(chan domain.QueuedCommand, 16)
+	ar.EXPECT().ClaimCommand(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, string, time.Duration) (domain.QueuedCommand, error) {
+		select {
+		case queued := <-queue:
+			return queued, nil
+		default:
+			return domain.QueuedCommand{}, appErrors.ErrNoRows
+		}
+	}).AnyTimes()
+
+	workerContext, stopWorkers := context.WithCancel(context.Background())
+	workersDone := make(chan struct{})
+	go func() {
+		as.RunWorkers(workerContext)
+		close(workersDone)
+	}()
+
+	t.Cleanup(func() {
+		stopWorkers()
+		<-workersDone
+	})
+
 	ar.EXPECT().Ping(gomock.Any()).Return(errors.New("")).MaxTimes(1)
 	ar.EXPECT().Ping(gomock.Any()).Return(nil).AnyTimes()
 
@@ -52,67 +91,73 @@ func testRouter(t *testing.T) *http.ServeMux {
 	ar.EXPECT().CreateCommand(gomock.Any(), gomock.Any()).Return(0, errors.New("")).MaxTimes(1)
 
 	//2
-	ar.EXPECT().CreateCommand(gomock.Any(), gomock.Any()).Return(1, nil).MaxTimes(7)
-	ar.EXPECT().ReadStatus(gomock.Any(), 1).Return("stopped", nil).MaxTimes(1)
-	ar.EXPECT().UpdateStatus(gomock.Any(), 1, gomock.Any()).Return(nil).MaxTimes(1)
+	ar.EXPECT().CreateCommand(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, command domain.CommandFromUser) (int, error) {
+		queue <- domain.QueuedCommand{ID: 1, Command: command}
+		return 1, nil
+	}).MaxTimes(7)
+	ar.EXPECT().ReadStatus(gomock.Any(), 1).Return(domain.StatusStopped, nil).MaxTimes(1)
 
 	//3
-	ar.EXPECT().ReadStatus(gomock.Any(), 1).Return("", errors.New("")).MaxTimes(1)
+	ar.EXPECT().ReadStatus(gomock.Any(), 1).Return(domain.CommandStatus(""), errors.New("")).MaxTimes(1)
 	ar.EXPECT().UpdateStatus(gomock.Any(), 1, gomock.Any()).Return(nil).MaxTimes(1)
 
 	//4
-	ar.EXPECT().ReadStatus(gomock.Any(), 1).Return("", nil).MaxTimes(1)
+	ar.EXPECT().ReadStatus(gomock.Any(), 1).Return(domain.StatusCreated, nil).MaxTimes(1)
 	ar.EXPECT().UpdatePID(gomock.Any(), 1, gomock.Any()).Return(errors.New("")).MaxTimes(1)
 	ar.EXPECT().UpdateStatus(gomock.Any(), 1, gomock.Any()).Return(nil).MaxTimes(1)
 
 	//5
-	ar.EXPECT().ReadStatus(gomock.Any(), 1).Return("", nil).MaxTimes(1)
+	ar.EXPECT().ReadStatus(gomock.Any(), 1).Return(domain.StatusCreated, nil).MaxTimes(1)
 	ar.EXPECT().UpdatePID(gomock.Any(), 1, gomock.Any()).Return(nil).AnyTimes()
 	ar.EXPECT().UpdateStatus(gomock.Any(), 1, gomock.Any()).Return(errors.New("")).MaxTimes(1)
 	ar.EXPECT().UpdateStatus(gomock.Any(), 1, gomock.Any()).Return(nil).MaxTimes(1)
 
 	//6
-	ar.EXPECT().ReadStatus(gomock.Any(), 1).Return("", nil).MaxTimes(1)
+	ar.EXPECT().ReadStatus(gomock.Any(), 1).Return(domain.StatusCreated, nil).MaxTimes(1)
 	ar.EXPECT().UpdateStatus(gomock.Any(), 1, gomock.Any()).Return(nil).MaxTimes(1)
-	ar.EXPECT().UpdateOutput(gomock.Any(), 1, gomock.Any()).Return(errors.New("")).MaxTimes(1)
+	ar.EXPECT().AppendOutput(gomock.Any(), 1, gomock.Any()).Return(errors.New("")).MaxTimes(1)
 	ar.EXPECT().UpdateStatus(gomock.Any(), 1, gomock.Any()).Return(nil).MaxTimes(1)
 
 	//7
-	ar.EXPECT().ReadStatus(gomock.Any(), 1).Return("", nil).MaxTimes(1)
+	ar.EXPECT().ReadStatus(gomock.Any(), 1).Return(domain.StatusCreated, nil).MaxTimes(1)
 	ar.EXPECT().UpdateStatus(gomock.Any(), 1, gomock.Any()).Return(nil).MaxTimes(1)
-	ar.EXPECT().UpdateOutput(gomock.Any(), 1, gomock.Any()).Return(nil).AnyTimes()
+	ar.EXPECT().AppendOutput(gomock.Any(), 1, gomock.Any()).Return(nil).AnyTimes()
 	ar.EXPECT().UpdateExitStatus(gomock.Any(), 1, gomock.Any()).Return(errors.New("")).MaxTimes(1)
 	ar.EXPECT().UpdateStatus(gomock.Any(), 1, gomock.Any()).Return(nil).MaxTimes(1)
 
+	var exitStatus int
+	ar.EXPECT().ListCommands(gomock.Any(), limitMatcher(2)).Return([]domain.CommandFromDB{{ID: 1, Command: "ls", Status: "done", ExitStatus: &exitStatus},
+		{ID: 2, Command: "pwd", Status: "done", ExitStatus: &exitStatus}}, nil).AnyTimes()
+
 	//8
-	ar.EXPECT().ListCommands(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("")).MaxTimes(1)
+	ar.EXPECT().ListCommands(gomock.Any(), gomock.Any()).Return(nil, errors.New("")).MaxTimes(1)
 
 	//9
-	ar.EXPECT().ListCommands(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, appErrors.ErrNoRows).MaxTimes(1)
+	ar.EXPECT().ListCommands(gomock.Any(), gomock.Any()).Return(nil, appErrors.ErrNoRows).MaxTimes(1)
 
-	var exitStatus int
+	queuedMS := int64(1000)
 	//10
-	ar.EXPECT().ListCommands(gomock.Any(), gomock.Any(), gomock.Any()).Return([]domain.CommandFromDB{{ID: 1, Command: "ls", PID: 5, Output: "", Status: "done", ExitStatus: &exitStatus}}, nil).AnyTimes()
+	ar.EXPECT().ListCommands(gomock.Any(), gomock.Any()).Return([]domain.CommandFromDB{{ID: 1, Command: "ls", PID: 5, Status: "done", ExitStatus: &exitStatus}}, nil).AnyTimes()
 
 	//11
-	ar.EXPECT().ReadStatus(gomock.Any(), 5).Return("", errors.New("")).MaxTimes(1)
+	ar.EXPECT().ReadStatus(gomock.Any(), 5).Return(domain.CommandStatus(""), errors.New("")).MaxTimes(1)
 
 	//12
-	ar.EXPECT().ReadStatus(gomock.Any(), 5).Return("", appErrors.ErrNoRows).MaxTimes(1)
+	ar.EXPECT().ReadStatus(gomock.Any(), 5).Return(domain.CommandStatus(""), appErrors.ErrNoRows).MaxTimes(1)
 
 	//13
-	ar.EXPECT().ReadStatus(gomock.Any(), 5).Return("created", nil).MaxTimes(2)
+	ar.EXPECT().ReadStatus(gomock.Any(), 5).Return(domain.StatusCreated, nil).MaxTimes(2)
 	ar.EXPECT().UpdateStatus(gomock.Any(), 5, gomock.Any()).Return(errors.New("")).MaxTimes(1)
 
 	//14
 	ar.EXPECT().UpdateStatus(gomock.Any(), 5, gomock.Any()).Return(nil).MaxTimes(1)
 
 	//15
-	ar.EXPECT().ReadStatus(gomock.Any(), 5).Return("", nil).MaxTimes(1)
+	ar.EXPECT().ReadStatus(gomock.Any(), 5).Return(domain.CommandStatus(""), nil).MaxTimes(1)
 
 	//16
-	ar.EXPECT().ReadStatus(gomock.Any(), 5).Return("started", nil).MaxTimes(1)
-	ar.EXPECT().ReadPID(gomock.Any(), 5).Return(5, errors.New("")).MaxTimes(1)
+	ar.EXPECT().ReadStatus(gomock.Any(), 5).Return(domain.StatusStarted, nil).MaxTimes(1)
+	ar.EXPECT().RequestStop(gomock.Any(), 5, gomock.Any()).Return(errors.New("")).MaxTimes(1)
 
 	//17
 	ar.EXPECT().ReadCommand(gomock.Any(), gomock.Any()).Return(domain.CommandFromDB{}, errors.New("")).MaxTimes(1)
@@ -121,26 +166,157 @@ func testRouter(t *testing.T) *http.ServeMux {
 	ar.EXPECT().ReadCommand(gomock.Any(), gomock.Any()).Return(domain.CommandFromDB{}, appErrors.ErrNoRows).MaxTimes(1)
 
 	//19
-	ar.EXPECT().ReadCommand(gomock.Any(), gomock.Any()).Return(domain.CommandFromDB{ID: 1, Command: "ls", PID: 5, Output: "", Status: "done", ExitStatus: &exitStatus}, nil).AnyTimes()
+	ar.EXPECT().ReadCommand(gomock.Any(), gomock.Any()).Return(domain.CommandFromDB{ID: 1, Command: "ls", PID: 5, Status: "done", ExitStatus: &exitStatus,
+		Env: map[string]string{"DB_PASSWORD": "abc", "LANG": "C"}, CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), QueuedMS: &queuedMS}, nil).AnyTimes()
 
 	//20
-	ar.EXPECT().ReadOutput(gomock.Any(), gomock.Any()).Return("", errors.New("")).MaxTimes(1)
+	ar.EXPECT().ReadOutput(gomock.Any(), gomock.Any(), domain.StreamStdout, gomock.Any()).Return(domain.OutputSlice{}, errors.New("")).MaxTimes(1)
 
 	//21
-	ar.EXPECT().ReadOutput(gomock.Any(), gomock.Any()).Return("", appErrors.ErrNoRows).MaxTimes(1)
+	ar.EXPECT().ReadOutput(gomock.Any(), gomock.Any(), domain.StreamStdout, gomock.Any()).Return(domain.OutputSlice{}, appErrors.ErrNoRows).MaxTimes(1)
 
 	//22
-	ar.EXPECT().ReadOutput(gomock.Any(), gomock.Any()).Return("", nil).MaxTimes(1)
+	ar.EXPECT().ReadOutput(gomock.Any(), gomock.Any(), domain.StreamStdout, gomock.Any()).Return(domain.OutputSlice{}, nil).MaxTimes(1)
 
 	//23
-	ar.EXPECT().ReadOutput(gomock.Any(), gomock.Any()).Return("a", nil).AnyTimes()
+	ar.EXPECT().ReadOutput(gomock.Any(), gomock.Any(), domain.StreamStdout, gomock.Any()).Return(domain.OutputSlice{Data: "a", Total: 1}, nil).AnyTimes()
+
+	//24
+	ar.EXPECT().ReadOutput(gomock.Any(), gomock.Any(), domain.StreamCombined, gomock.Any()).Return(domain.OutputSlice{Data: "a\nb", Total: 3}, nil).AnyTimes()
+
+	//25
+	ar.EXPECT().ReadOutput(gomock.Any(), gomock.Any(), domain.StreamStderr, gomock.Any()).Return(domain.OutputSlice{}, errors.New("")).MaxTimes(1)
+
+	//26
+	ar.EXPECT().ReadOutput(gomock.Any(), gomock.Any(), domain.StreamStderr, gomock.Any()).Return(domain.OutputSlice{}, appErrors.ErrNoRows).MaxTimes(1)
+
+	//27
+	ar.EXPECT().ReadOutput(gomock.Any(), gomock.Any(), domain.StreamStderr, gomock.Any()).Return(domain.OutputSlice{}, nil).MaxTimes(1)
+
+	//28
+	ar.EXPECT().ReadOutput(gomock.Any(), gomock.Any(), domain.StreamStderr, gomock.Any()).Return(domain.OutputSlice{Data: "b", Total: 1}, nil).AnyTimes()
+
+	//29
+	ar.EXPECT().ListDeliveries(gomock.Any(), gomock.Any()).Return(nil, errors.New("")).MaxTimes(1)
+
+	//30
+	ar.EXPECT().ListDeliveries(gomock.Any(), gomock.Any()).Return(nil, appErrors.ErrNoRows).MaxTimes(1)
+
+	//31
+	ar.EXPECT().ListDeliveries(gomock.Any(), gomock.Any()).Return([]domain.Delivery{{ID: 1, CommandID: 1, URL: "http://example.com/hook", Status: domain.DeliveryStatusDelivered, Attempts: 1}}, nil).AnyTimes()
+
+	//32
+	ar.EXPECT().CreateSchedule(gomock.Any(), gomock.Any()).Return(0, errors.New("")).MaxTimes(1)
+
+	//33
+	ar.EXPECT().CreateSchedule(gomock.Any(), gomock.Any()).Return(1, nil).AnyTimes()
+
+	//34
+	ar.EXPECT().ListSchedules(gomock.Any()).Return(nil, errors.New("")).MaxTimes(1)
+
+	//35
+	ar.EXPECT().ListSchedules(gomock.Any()).Return([]domain.Schedule{{ID: 1, Cron: "@daily", Timezone: "UTC", Overlap: domain.OverlapSkip,
+		Command: domain.CommandFromUser{Command: "ls", Env: map[string]string{"DB_PASSWORD": "abc"}, CallbackURL: "http://example.com/hook", CallbackSecret: "secret"}}}, nil).AnyTimes()
+
+	//36
+	ar.EXPECT().ReadSchedule(gomock.Any(), gomock.Any()).Return(domain.Schedule{}, errors.New("")).MaxTimes(1)
+
+	//37
+	ar.EXPECT().ReadSchedule(gomock.Any(), gomock.Any()).Return(domain.Schedule{}, appErrors.ErrNoRows).MaxTimes(1)
+
+	//38
+	ar.EXPECT().ReadSchedule(gomock.Any(), gomock.Any()).Return(domain.Schedule{ID: 1, Cron: "@daily", Timezone: "UTC", Overlap: domain.OverlapSkip,
+		Command: domain.CommandFromUser{Command: "ls"}}, nil).AnyTimes()
+
+	//39
+	ar.EXPECT().PauseSchedule(gomock.Any(), gomock.Any()).Return(appErrors.ErrNoRows).MaxTimes(1)
+	ar.EXPECT().PauseSchedule(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	ar.EXPECT().ResumeSchedule(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	ar.EXPECT().DeleteSchedule(gomock.Any(), gomock.Any()).Return(appErrors.ErrNoRows).MaxTimes(1)
+	ar.EXPECT().DeleteSchedule(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	parentID, attempt := 1, 1
+	//40
+	ar.EXPECT().ListAttempts(gomock.Any(), gomock.Any()).Return(nil, errors.New("")).MaxTimes(1)
+
+	//41
+	ar.EXPECT().ListAttempts(gomock.Any(), gomock.Any()).Return(nil, appErrors.ErrNoRows).MaxTimes(1)
+
+	//42
+	ar.EXPECT().ListAttempts(gomock.Any(), gomock.Any()).Return([]domain.CommandFromDB{{ID: 2, Command: "ls", Status: domain.StatusDone, ExitStatus: &exitStatus,
+		ParentID: &parentID, Attempt: &attempt, Env: map[string]string{"DB_PASSWORD": "abc"}}}, nil).AnyTimes()
+
+	//43
+	ar.EXPECT().CreateWorkflow(gomock.Any(), gomock.Any(), gomock.Any()).Return(0, domain.WorkflowAdvance{}, errors.New("")).MaxTimes(1)
+
+	//44
+	ar.EXPECT().CreateWorkflow(gomock.Any(), gomock.Any(), gomock.Any()).Return(1, domain.WorkflowAdvance{Status: domain.WorkflowStatusRunning}, nil).AnyTimes()
+
+	//45
+	ar.EXPECT().ListWorkflows(gomock.Any()).Return(nil, errors.New("")).MaxTimes(1)
+	ar.EXPECT().ListWorkflows(gomock.Any()).Return([]domain.Workflow{{ID: 1, Status: domain.WorkflowStatusRunning}}, nil).AnyTimes()
+
+	//46
+	ar.EXPECT().ReadWorkflow(gomock.Any(), gomock.Any()).Return(domain.Workflow{}, appErrors.ErrNoRows).MaxTimes(1)
+
+	//47
+	ar.EXPECT().ReadWorkflow(gomock.Any(), gomock.Any()).Return(domain.Workflow{ID: 1, Status: domain.WorkflowStatusRunning,
+		Nodes: []domain.WorkflowNode{{Name: "build", DependsOn: []string{}, Condition: domain.ConditionOnSuccess, Status: domain.NodeStatusPending}}}, nil).AnyTimes()
+
+	//48
+	ar.EXPECT().CreatePipeline(gomock.Any(), gomock.Any(), gomock.Any()).Return(0, domain.PipelineAdvance{}, errors.New("")).MaxTimes(1)
+
+	//49
+	ar.EXPECT().CreatePipeline(gomock.Any(), gomock.Any(), gomock.Any()).Return(1, domain.PipelineAdvance{Status: domain.WorkflowStatusRunning}, nil).AnyTimes()
+
+	//50
+	ar.EXPECT().ListPipelines(gomock.Any()).Return(nil, errors.New("")).MaxTimes(1)
+	ar.EXPECT().ListPipelines(gomock.Any()).Return([]domain.Pipeline{{ID: 1, Status: domain.WorkflowStatusRunning}}, nil).AnyTimes()
+
+	//51
+	ar.EXPECT().ReadPipeline(gomock.Any(), gomock.Any()).Return(domain.Pipeline{}, appErrors.ErrNoRows).MaxTimes(1)
+
+	//52
+	ar.EXPECT().ReadPipeline(gomock.Any(), gomock.Any()).Return(domain.Pipeline{ID: 1, Status: domain.WorkflowStatusRunning,
+		Steps: []domain.PipelineStep{{Name: "fetch", Status: domain.NodeStatusPending}}}, nil).AnyTimes()
+
+	//53
+	ar.EXPECT().ReadStatus(gomock.Any(), 5).Return(domain.StatusStarted, nil).MaxTimes(1)
+	ar.EXPECT().RequestStop(gomock.Any(), 5, 9).Return(nil).MaxTimes(1)
+
+	ar.EXPECT().ReadCommandLinks(gomock.Any(), gomock.Any()).Return(domain.CommandLinks{}, nil).AnyTimes()
+	ar.EXPECT().ClaimStopRequests(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	ar.EXPECT().CountCommands(gomock.Any(), gomock.Any()).Return(2, nil).AnyTimes()
+	ar.EXPECT().PromoteScheduledCommands(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	ar.EXPECT().RenewLeases(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	ar.EXPECT().ListExpiredCommands(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	ar.EXPECT().ListMissingDeliveries(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	ar.EXPECT().AppendOutput(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	ar.EXPECT().TrimOutput(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	ar.EXPECT().UpdateOutputStats(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	ar.EXPECT().UpdateStartTime(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	ar.EXPECT().UpdateExecutionStats(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 
 	mux.Handle("GET /ping", http.HandlerFunc(ah.Ping))
 	mux.Handle("POST /commands", http.HandlerFunc(ah.CreateCommand))
+	mux.Handle("POST /commands/script", http.HandlerFunc(ah.CreateScript))
 	mux.Handle("GET /commands", http.HandlerFunc(ah.ListCommands))
 	mux.Handle("GET /commands/stop/{command_id}", http.HandlerFunc(ah.StopCommand))
 	mux.Handle("GET /commands/{command_id}", http.HandlerFunc(ah.ReadCommand))
 	mux.Handle("GET /commands/output/{command_id}", http.HandlerFunc(ah.ReadOutput))
+	mux.Handle("GET /commands/{command_id}/{subresource}", http.HandlerFunc(ah.CommandSubresource))
+	mux.Handle("POST /schedules", http.HandlerFunc(ah.CreateSchedule))
+	mux.Handle("GET /schedules", http.HandlerFunc(ah.ListSchedules))
+	mux.Handle("GET /schedules/{schedule_id}", http.HandlerFunc(ah.ReadSchedule))
+	mux.Handle("POST /schedules/{schedule_id}/pause", http.HandlerFunc(ah.PauseSchedule))
+	mux.Handle("POST /schedules/{schedule_id}/resume", http.HandlerFunc(ah.ResumeSchedule))
+	mux.Handle("DELETE /schedules/{schedule_id}", http.HandlerFunc(ah.DeleteSchedule))
+	mux.Handle("POST /workflows", http.HandlerFunc(ah.CreateWorkflow))
+	mux.Handle("GET /workflows", http.HandlerFunc(ah.ListWorkflows))
+	mux.Handle("GET /workflows/{workflow_id}", http.HandlerFunc(ah.ReadWorkflow))
+	mux.Handle("POST /pipelines", http.HandlerFunc(ah.CreatePipeline))
+	mux.Handle("GET /pipelines", http.HandlerFunc(ah.ListPipelines))
+	mux.Handle("GET /pipelines/{pipeline_id}", http.HandlerFunc(ah.ReadPipeline))
 
 	return mux
 }
@@ -229,6 +405,66 @@ func Test_bashrunHandlers_CreateCommand(t *testing.T) {
 
 	var id domain.ID
 	tests := []testTableElem{
+		{
+			caseName:       "wrong run_at",
+			httpMethod:     http.MethodPost,
+			route:          "/commands",
+			body:           `{"command": "ls", "run_at": "tomorrow"}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "non-positive delay",
+			httpMethod:     http.MethodPost,
+			route:          "/commands",
+			body:           `{"command": "ls", "delay_seconds": 0}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "both run_at and delay",
+			httpMethod:     http.MethodPost,
+			route:          "/commands",
+			body:           `{"command": "ls", "run_at": "2030-01-01T00:00:00Z", "delay_seconds": 60}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "no retry attempts",
+			httpMethod:     http.MethodPost,
+			route:          "/commands",
+			body:           `{"command": "ls", "retry": {"max_attempts": 0}}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong retry backoff",
+			httpMethod:     http.MethodPost,
+			route:          "/commands",
+			body:           `{"command": "ls", "retry": {"max_attempts": 3, "backoff": "linear"}}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong retry exit code",
+			httpMethod:     http.MethodPost,
+			route:          "/commands",
+			body:           `{"command": "ls", "retry": {"max_attempts": 3, "exit_codes": [0]}}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
 		{
 			caseName:       "unknown key in JSON",
 			httpMethod:     http.MethodPost,
@@ -279,6 +515,126 @@ func Test_bashrunHandlers_CreateCommand(t *testing.T) {
 			requireParsing: false,
 			parsedBody:     nil,
 		},
+		{
+			caseName:       "interpreter is not allowed",
+			httpMethod:     http.MethodPost,
+			route:          "/commands",
+			body:           "{\"command\": \"abc\", \"interpreter\": \"/bin/abc\"}",
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "argv with command",
+			httpMethod:     http.MethodPost,
+			route:          "/commands",
+			body:           "{\"command\": \"abc\", \"argv\": [\"echo\", \"abc\"]}",
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "non-positive timeout",
+			httpMethod:     http.MethodPost,
+			route:          "/commands",
+			body:           "{\"command\": \"abc\", \"timeout_seconds\": 0}",
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong env key",
+			httpMethod:     http.MethodPost,
+			route:          "/commands",
+			body:           "{\"command\": \"abc\", \"env\": {\"A=B\": \"c\"}}",
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "workdir does not exist",
+			httpMethod:     http.MethodPost,
+			route:          "/commands",
+			body:           "{\"command\": \"abc\", \"workdir\": \"/non/existent/dir\"}",
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong base64 stdin",
+			httpMethod:     http.MethodPost,
+			route:          "/commands",
+			body:           "{\"command\": \"abc\", \"stdin_base64\": \"@@@\"}",
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "negative kill grace",
+			httpMethod:     http.MethodPost,
+			route:          "/commands",
+			body:           "{\"command\": \"abc\", \"timeout_seconds\": 1, \"kill_grace_seconds\": -1}",
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "non-positive max output",
+			httpMethod:     http.MethodPost,
+			route:          "/commands",
+			body:           "{\"command\": \"abc\", \"max_output_bytes\": 0}",
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "priority out of range",
+			httpMethod:     http.MethodPost,
+			route:          "/commands",
+			body:           "{\"command\": \"abc\", \"priority\": 5000}",
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong output policy",
+			httpMethod:     http.MethodPost,
+			route:          "/commands",
+			body:           "{\"command\": \"abc\", \"output_policy\": \"middle\"}",
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong callback url",
+			httpMethod:     http.MethodPost,
+			route:          "/commands",
+			body:           "{\"command\": \"abc\", \"callback_url\": \"ftp://example.com/hook\"}",
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "callback secret without url",
+			httpMethod:     http.MethodPost,
+			route:          "/commands",
+			body:           "{\"command\": \"abc\", \"callback_secret\": \"s3cr3t\"}",
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
 		{ //1
 			caseName:       "server error",
 			httpMethod:     http.MethodPost,
@@ -371,216 +727,344 @@ func Test_bashrunHandlers_CreateCommand(t *testing.T) {
 	}
 }
 
-func Test_bashrunHandlers_ListCommands(t *testing.T) {
+func multipartBody(t *testing.T, fields [][2]string, script string) (string, string) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for _, field := range fields {
+		require.NoError(t, writer.WriteField(field[0], field[1]))
+	}
+
+	if script != "" {
+		part, err := writer.CreateFormFile("script", "script.sh")
+		require.NoError(t, err)
+
+		_, err = part.Write([]byte(script))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, writer.Close())
+
+	return body.String(), writer.FormDataContentType()
+}
+
+func Test_bashrunHandlers_CreateScript(t *testing.T) {
 	ts := httptest.NewServer(testRouter(t))
 	defer ts.Close()
 
 	client := http.Client{}
 
-	var commands []domain.CommandFromDB
+	noScriptBody, noScriptContentType := multipartBody(t, [][2]string{{"arg", "a"}}, "")
+	fileBody, fileContentType := multipartBody(t, [][2]string{{"arg", "a"}, {"arg", "b"}, {"interpreter", "sh"}}, "echo $1\necho $2\n")
+	fieldBody, fieldContentType := multipartBody(t, [][2]string{{"script", "echo 1"}, {"timeout_seconds", "abc"}}, "")
+
+	var id domain.ID
 	tests := []testTableElem{
 		{
-			caseName:       "non-numeric limit",
-			httpMethod:     http.MethodGet,
-			route:          "/commands?limit=a",
-			body:           "",
-			headers:        [][2]string{},
+			caseName:       "wrong content type",
+			httpMethod:     http.MethodPost,
+			route:          "/commands/script",
+			body:           "echo 1",
+			headers:        [][2]string{{"Content-Type", "application/json"}},
 			expectedStatus: http.StatusBadRequest,
 			requireParsing: false,
 			parsedBody:     nil,
 		},
 		{
-			caseName:       "non-numeric offset",
-			httpMethod:     http.MethodGet,
-			route:          "/commands?offset=a",
-			body:           "",
-			headers:        [][2]string{},
+			caseName:       "empty script",
+			httpMethod:     http.MethodPost,
+			route:          "/commands/script",
+			body:           " \n",
+			headers:        [][2]string{{"Content-Type", "text/x-shellscript"}},
 			expectedStatus: http.StatusBadRequest,
 			requireParsing: false,
 			parsedBody:     nil,
 		},
-		{ //8
-			caseName:       "server error",
-			httpMethod:     http.MethodGet,
-			route:          "/commands",
-			body:           "",
-			headers:        [][2]string{},
-			expectedStatus: http.StatusInternalServerError,
+		{
+			caseName:       "too large script",
+			httpMethod:     http.MethodPost,
+			route:          "/commands/script",
+			body:           strings.Repeat("#", maxScriptSize+1),
+			headers:        [][2]string{{"Content-Type", "text/x-shellscript"}},
+			expectedStatus: http.StatusRequestEntityTooLarge,
 			requireParsing: false,
 			parsedBody:     nil,
 		},
-		{ //9
-			caseName:       "rows not found",
-			httpMethod:     http.MethodGet,
-			route:          "/commands",
-			body:           "",
-			headers:        [][2]string{},
-			expectedStatus: http.StatusNoContent,
+		{
+			caseName:       "no script part",
+			httpMethod:     http.MethodPost,
+			route:          "/commands/script",
+			body:           noScriptBody,
+			headers:        [][2]string{{"Content-Type", noScriptContentType}},
+			expectedStatus: http.StatusBadRequest,
 			requireParsing: false,
 			parsedBody:     nil,
 		},
-		{ //10
-			caseName:       "ok",
-			httpMethod:     http.MethodGet,
-			route:          "/commands",
-			body:           "",
-			headers:        [][2]string{},
-			expectedStatus: http.StatusOK,
-			requireParsing: true,
-			parsedBody:     &commands,
+		{
+			caseName:       "wrong timeout",
+			httpMethod:     http.MethodPost,
+			route:          "/commands/script",
+			body:           fieldBody,
+			headers:        [][2]string{{"Content-Type", fieldContentType}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
 		},
-	}
-
-	for _, testCase := range tests {
-		t.Log(testCase.caseName)
-
-		req, err := buildRequest(testCase.httpMethod, testCase.route, testCase.body, testCase.headers, ts.URL)
-		require.NoError(t, err)
+		{
+			caseName:       "wrong interpreter",
+			httpMethod:     http.MethodPost,
+			route:          "/commands/script?interpreter=unknown",
+			body:           "echo 1",
+			headers:        [][2]string{{"Content-Type", "text/x-shellscript"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong retry attempts",
+			httpMethod:     http.MethodPost,
+			route:          "/commands/script?retry_max_attempts=many",
+			body:           "echo 1",
+			headers:        [][2]string{{"Content-Type", "text/x-shellscript"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong retry exit code",
+			httpMethod:     http.MethodPost,
+			route:          "/commands/script?retry_max_attempts=3&retry_exit_code=256",
+			body:           "echo 1",
+			headers:        [][2]string{{"Content-Type", "text/x-shellscript"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong priority",
+			httpMethod:     http.MethodPost,
+			route:          "/commands/script?priority=high",
+			body:           "echo 1",
+			headers:        [][2]string{{"Content-Type", "text/x-shellscript"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //1
+			caseName:       "create command error",
+			httpMethod:     http.MethodPost,
+			route:          "/commands/script",
+			body:           "echo 1",
+			headers:        [][2]string{{"Content-Type", "text/x-shellscript"}},
+			expectedStatus: http.StatusInternalServerError,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //2
+			caseName:       "ok (text body)",
+			httpMethod:     http.MethodPost,
+			route:          "/commands/script?arg=a&arg=b",
+			body:           "#!/bin/sh\necho $1 $2\n",
+			headers:        [][2]string{{"Content-Type", "text/x-shellscript; charset=utf-8"}},
+			expectedStatus: http.StatusAccepted,
+			requireParsing: true,
+			parsedBody:     &id,
+		},
+		{ //3
+			caseName:       "ok (multipart body)",
+			httpMethod:     http.MethodPost,
+			route:          "/commands/script",
+			body:           fileBody,
+			headers:        [][2]string{{"Content-Type", fileContentType}},
+			expectedStatus: http.StatusAccepted,
+			requireParsing: true,
+			parsedBody:     &id,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Log(testCase.caseName)
+
+		req, err := buildRequest(testCase.httpMethod, testCase.route, testCase.body, testCase.headers, ts.URL)
+		require.NoError(t, err)
 
 		sendReq(t, &client, req, testCase.expectedStatus, testCase.parsedBody, testCase.requireParsing)
 
-		if testCase.caseName == "ok" {
-			require.Equal(t, 1, len(commands))
+		if strings.HasPrefix(testCase.caseName, "ok") {
+			require.Equal(t, 1, id.ID)
 		}
+
+		<-time.After(time.Millisecond * 100)
 	}
 }
 
-func Test_bashrunHandlers_StopCommand(t *testing.T) {
+func Test_bashrunHandlers_ListCommands(t *testing.T) {
 	ts := httptest.NewServer(testRouter(t))
 	defer ts.Close()
 
 	client := http.Client{}
 
+	var commands []domain.CommandFromDB
 	tests := []testTableElem{
 		{
-			caseName:       "non-numeric id",
+			caseName:       "non-numeric limit",
 			httpMethod:     http.MethodGet,
-			route:          "/commands/stop/a",
+			route:          "/commands?limit=a",
 			body:           "",
 			headers:        [][2]string{},
 			expectedStatus: http.StatusBadRequest,
 			requireParsing: false,
 			parsedBody:     nil,
 		},
-		{ //11
-			caseName:       "server error",
+		{
+			caseName:       "non-numeric offset",
 			httpMethod:     http.MethodGet,
-			route:          "/commands/stop/5",
+			route:          "/commands?offset=a",
 			body:           "",
 			headers:        [][2]string{},
-			expectedStatus: http.StatusInternalServerError,
+			expectedStatus: http.StatusBadRequest,
 			requireParsing: false,
 			parsedBody:     nil,
 		},
-		{ //12
-			caseName:       "rows not found",
+		{
+			caseName:       "wrong schedule id",
 			httpMethod:     http.MethodGet,
-			route:          "/commands/stop/5",
+			route:          "/commands?schedule_id=0",
 			body:           "",
 			headers:        [][2]string{},
-			expectedStatus: http.StatusNotFound,
+			expectedStatus: http.StatusBadRequest,
 			requireParsing: false,
 			parsedBody:     nil,
 		},
-		{ //13
-			caseName:       "failed to update status",
+		{
+			caseName:       "wrong workflow id",
 			httpMethod:     http.MethodGet,
-			route:          "/commands/stop/5",
+			route:          "/commands?workflow_id=a",
 			body:           "",
 			headers:        [][2]string{},
-			expectedStatus: http.StatusInternalServerError,
+			expectedStatus: http.StatusBadRequest,
 			requireParsing: false,
 			parsedBody:     nil,
 		},
-		{ //14
-			caseName:       "command status is \"created\"",
+		{
+			caseName:       "wrong pipeline id",
 			httpMethod:     http.MethodGet,
-			route:          "/commands/stop/5",
+			route:          "/commands?pipeline_id=-1",
 			body:           "",
 			headers:        [][2]string{},
-			expectedStatus: http.StatusAccepted,
+			expectedStatus: http.StatusBadRequest,
 			requireParsing: false,
 			parsedBody:     nil,
 		},
-		{ //15
-			caseName:       "command not started",
+		{
+			caseName:       "wrong status",
 			httpMethod:     http.MethodGet,
-			route:          "/commands/stop/5",
+			route:          "/commands?status=failed+to+create+pipe",
 			body:           "",
 			headers:        [][2]string{},
 			expectedStatus: http.StatusBadRequest,
 			requireParsing: false,
 			parsedBody:     nil,
 		},
-		{ //16
-			caseName:       "read PID failed",
+		{
+			caseName:       "wrong exit status",
 			httpMethod:     http.MethodGet,
-			route:          "/commands/stop/5",
+			route:          "/commands?exit_status=a",
 			body:           "",
 			headers:        [][2]string{},
-			expectedStatus: http.StatusInternalServerError,
+			expectedStatus: http.StatusBadRequest,
 			requireParsing: false,
 			parsedBody:     nil,
 		},
-	}
-
-	for _, testCase := range tests {
-		t.Log(testCase.caseName)
-
-		req, err := buildRequest(testCase.httpMethod, testCase.route, testCase.body, testCase.headers, ts.URL)
-		require.NoError(t, err)
-
-		sendReq(t, &client, req, testCase.expectedStatus, testCase.parsedBody, testCase.requireParsing)
-	}
-}
-
-func Test_bashrunHandlers_ReadCommand(t *testing.T) {
-	ts := httptest.NewServer(testRouter(t))
-	defer ts.Close()
-
-	client := http.Client{}
-
-	var command domain.CommandFromDB
-	tests := []testTableElem{
 		{
-			caseName:       "non-numeric id",
+			caseName:       "wrong created range",
 			httpMethod:     http.MethodGet,
-			route:          "/commands/a",
+			route:          "/commands?created_from=2024-05-02T00:00:00Z&created_to=2024-05-01T00:00:00Z",
 			body:           "",
 			headers:        [][2]string{},
 			expectedStatus: http.StatusBadRequest,
 			requireParsing: false,
 			parsedBody:     nil,
 		},
-		{ //17
+		{
+			caseName:       "wrong created time",
+			httpMethod:     http.MethodGet,
+			route:          "/commands?created_from=yesterday",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong sort",
+			httpMethod:     http.MethodGet,
+			route:          "/commands?sort=pid",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong order",
+			httpMethod:     http.MethodGet,
+			route:          "/commands?order=up",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong include_output",
+			httpMethod:     http.MethodGet,
+			route:          "/commands?include_output=no",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //8
 			caseName:       "server error",
 			httpMethod:     http.MethodGet,
-			route:          "/commands/1",
+			route:          "/commands",
 			body:           "",
 			headers:        [][2]string{},
 			expectedStatus: http.StatusInternalServerError,
 			requireParsing: false,
 			parsedBody:     nil,
 		},
-		{ //18
+		{ //9
 			caseName:       "rows not found",
 			httpMethod:     http.MethodGet,
-			route:          "/commands/1",
+			route:          "/commands",
 			body:           "",
 			headers:        [][2]string{},
-			expectedStatus: http.StatusNotFound,
+			expectedStatus: http.StatusNoContent,
 			requireParsing: false,
 			parsedBody:     nil,
 		},
-		{ //19
+		{ //10
 			caseName:       "ok",
 			httpMethod:     http.MethodGet,
-			route:          "/commands/1",
+			route:          "/commands",
 			body:           "",
 			headers:        [][2]string{},
 			expectedStatus: http.StatusOK,
 			requireParsing: true,
-			parsedBody:     &command,
+			parsedBody:     &commands,
+		},
+		{ //10
+			caseName:       "ok (with filters)",
+			httpMethod:     http.MethodGet,
+			route:          "/commands?status=done&exit_status=0&label=nightly&command=ls&command_regex=%5Els&q=error&sort=duration&order=desc&include_output=false",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusOK,
+			requireParsing: true,
+			parsedBody:     &commands,
 		},
 	}
 
@@ -592,13 +1076,101 @@ func Test_bashrunHandlers_ReadCommand(t *testing.T) {
 
 		sendReq(t, &client, req, testCase.expectedStatus, testCase.parsedBody, testCase.requireParsing)
 
-		if testCase.caseName == "ok" {
-			require.Equal(t, 1, command.ID)
+		if strings.HasPrefix(testCase.caseName, "ok") {
+			require.Equal(t, 1, len(commands))
 		}
 	}
 }
 
-func Test_bashrunHandlers_ReadOutput(t *testing.T) {
+func Test_bashrunHandlers_ListCommandsWrongRegex(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var wg sync.WaitGroup
+
+	ar := mocks.NewMockBashrunRepository(ctrl)
+	ah := New(service.New(context.Background(), ar, &wg, &config.Config{}))
+
+	// the regex is rejected by PostgreSQL, the repository maps its error
+	ar.EXPECT().ListCommands(gomock.Any(), domain.CommandFilter{Limit: 16, CommandRegex: "(", SortBy: domain.SortByID}).Return(nil, appErrors.ErrWrongCommandRegex).Times(1)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /commands", http.HandlerFunc(ah.ListCommands))
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	client := http.Client{}
+
+	req, err := buildRequest(http.MethodGet, "/commands?command_regex=%28", "", [][2]string{}, ts.URL)
+	require.NoError(t, err)
+
+	sendReq(t, &client, req, http.StatusBadRequest, nil, false)
+}
+
+func Test_bashrunHandlers_ListCommandsPagination(t *testing.T) {
+	ts := httptest.NewServer(testRouter(t))
+	defer ts.Close()
+
+	client := http.Client{}
+
+	linkRe := regexp.MustCompile(`<([^>]+)>; rel="(next|prev)"`)
+	getPage := func(route string, expectedStatus int) map[string]string {
+		req, err := buildRequest(http.MethodGet, route, "", nil, ts.URL)
+		require.NoError(t, err)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, expectedStatus, resp.StatusCode)
+		if expectedStatus != http.StatusOK {
+			return nil
+		}
+
+		require.Equal(t, "2", resp.Header.Get("X-Total-Count"))
+
+		var commands []domain.CommandFromDB
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&commands))
+		require.Equal(t, 1, len(commands))
+
+		links := make(map[string]string)
+		for _, match := range linkRe.FindAllStringSubmatch(resp.Header.Get("Link"), -1) {
+			links[match[2]] = match[1]
+		}
+
+		return links
+	}
+
+	links := getPage("/commands?limit=1", http.StatusOK)
+	require.Contains(t, links, "next")
+	require.NotContains(t, links, "prev")
+	require.Contains(t, links["next"], "after=")
+
+	links = getPage(links["next"], http.StatusOK)
+	require.Contains(t, links, "next")
+	require.Contains(t, links, "prev")
+	require.Contains(t, links["prev"], "before=")
+
+	links = getPage(links["prev"], http.StatusOK)
+	require.Contains(t, links, "next")
+	require.Contains(t, links, "prev")
+
+	links = getPage("/commands?limit=1&offset=1", http.StatusOK)
+	require.Contains(t, links, "prev")
+	require.NotContains(t, links["next"], "offset=")
+
+	next, err := url.Parse(links["next"])
+	require.NoError(t, err)
+	after := url.QueryEscape(next.Query().Get("after"))
+
+	getPage("/commands?limit=1&after=abc", http.StatusBadRequest)
+	getPage("/commands?limit=1&sort=created_at&after="+after, http.StatusBadRequest)
+	getPage("/commands?limit=1&offset=1&after="+after, http.StatusBadRequest)
+	getPage("/commands?limit=1&after="+after+"&before="+after, http.StatusBadRequest)
+}
+
+func Test_bashrunHandlers_StopCommand(t *testing.T) {
 	ts := httptest.NewServer(testRouter(t))
 	defer ts.Close()
 
@@ -608,50 +1180,1641 @@ func Test_bashrunHandlers_ReadOutput(t *testing.T) {
 		{
 			caseName:       "non-numeric id",
 			httpMethod:     http.MethodGet,
-			route:          "/commands/output/a",
+			route:          "/commands/stop/a",
 			body:           "",
 			headers:        [][2]string{},
 			expectedStatus: http.StatusBadRequest,
 			requireParsing: false,
 			parsedBody:     nil,
 		},
-		{ //20
+		{
+			caseName:       "wrong signal",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/stop/5?signal=abc",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //11
 			caseName:       "server error",
 			httpMethod:     http.MethodGet,
-			route:          "/commands/output/1",
+			route:          "/commands/stop/5",
 			body:           "",
 			headers:        [][2]string{},
 			expectedStatus: http.StatusInternalServerError,
 			requireParsing: false,
 			parsedBody:     nil,
 		},
-		{ //21
+		{ //12
 			caseName:       "rows not found",
 			httpMethod:     http.MethodGet,
-			route:          "/commands/output/1",
+			route:          "/commands/stop/5",
 			body:           "",
 			headers:        [][2]string{},
 			expectedStatus: http.StatusNotFound,
 			requireParsing: false,
 			parsedBody:     nil,
 		},
-		{ //22
-			caseName:       "empty output",
+		{ //13
+			caseName:       "failed to update status",
 			httpMethod:     http.MethodGet,
-			route:          "/commands/output/1",
+			route:          "/commands/stop/5",
 			body:           "",
 			headers:        [][2]string{},
-			expectedStatus: http.StatusNoContent,
+			expectedStatus: http.StatusInternalServerError,
 			requireParsing: false,
 			parsedBody:     nil,
 		},
-		{ //23
-			caseName:       "ok",
+		{ //14
+			caseName:       "command status is \"created\"",
 			httpMethod:     http.MethodGet,
-			route:          "/commands/output/1",
+			route:          "/commands/stop/5",
 			body:           "",
 			headers:        [][2]string{},
-			expectedStatus: http.StatusOK,
+			expectedStatus: http.StatusAccepted,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //15
+			caseName:       "command not started",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/stop/5",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //16
+			caseName:       "stop request failed",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/stop/5",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusInternalServerError,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //53
+			caseName:       "stop requested from the instance running the command",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/stop/5",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusAccepted,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Log(testCase.caseName)
+
+		req, err := buildRequest(testCase.httpMethod, testCase.route, testCase.body, testCase.headers, ts.URL)
+		require.NoError(t, err)
+
+		sendReq(t, &client, req, testCase.expectedStatus, testCase.parsedBody, testCase.requireParsing)
+	}
+}
+
+func Test_bashrunHandlers_ReadCommand(t *testing.T) {
+	ts := httptest.NewServer(testRouter(t))
+	defer ts.Close()
+
+	client := http.Client{}
+
+	var command domain.CommandFromDB
+	tests := []testTableElem{
+		{
+			caseName:       "non-numeric id",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/a",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //17
+			caseName:       "server error",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/1",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusInternalServerError,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //18
+			caseName:       "rows not found",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/1",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusNotFound,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //19
+			caseName:       "ok",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/1",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusOK,
+			requireParsing: true,
+			parsedBody:     &command,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Log(testCase.caseName)
+
+		req, err := buildRequest(testCase.httpMethod, testCase.route, testCase.body, testCase.headers, ts.URL)
+		require.NoError(t, err)
+
+		sendReq(t, &client, req, testCase.expectedStatus, testCase.parsedBody, testCase.requireParsing)
+
+		if testCase.caseName == "ok" {
+			require.Equal(t, 1, command.ID)
+			require.Equal(t, "***", command.Env["DB_PASSWORD"])
+			require.Equal(t, "C", command.Env["LANG"])
+			require.True(t, command.CreatedAt.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)))
+			require.Equal(t, int64(1000), *command.QueuedMS)
+			require.Nil(t, command.StartedAt)
+		}
+	}
+}
+
+func Test_bashrunHandlers_ReadOutput(t *testing.T) {
+	ts := httptest.NewServer(testRouter(t))
+	defer ts.Close()
+
+	client := http.Client{}
+
+	tests := []testTableElem{
+		{
+			caseName:       "non-numeric id",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/output/a",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //20
+			caseName:       "server error",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/output/1",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusInternalServerError,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //21
+			caseName:       "rows not found",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/output/1",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusNotFound,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //22
+			caseName:       "empty output",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/output/1",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusNoContent,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //23
+			caseName:       "ok",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/output/1",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusOK,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong combined value",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/output/1?combined=a",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong offset",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/output/1?offset=-1",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong limit",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/output/1?limit=0",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //23
+			caseName:       "ok (range)",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/output/1?offset=0&limit=1",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusOK,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //24
+			caseName:       "ok (combined)",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/output/1?combined=true",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusOK,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong unit",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/output/1?unit=words",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong tail",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/output/1?tail=0",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "tail with offset",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/output/1?tail=5&offset=1",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong download",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/output/1?download=yes",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "ok (tail)",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/output/1?tail=5",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusOK,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "ok (lines)",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/output/1?unit=lines&offset=1&limit=2",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusOK,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "ok (download)",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/output/1?download=true",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusOK,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "ok (range)",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/output/1",
+			body:           "",
+			headers:        [][2]string{{"Range", "bytes=0-0"}},
+			expectedStatus: http.StatusPartialContent,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "ok (raw format)",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/output/1?format=raw",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusOK,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "ok (octet-stream accepted)",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/output/1",
+			body:           "",
+			headers:        [][2]string{{"Accept", "text/plain;q=0.5, application/octet-stream"}},
+			expectedStatus: http.StatusOK,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Log(testCase.caseName)
+
+		req, err := buildRequest(testCase.httpMethod, testCase.route, testCase.body, testCase.headers, ts.URL)
+		require.NoError(t, err)
+
+		sendReq(t, &client, req, testCase.expectedStatus, testCase.parsedBody, testCase.requireParsing)
+	}
+}
+
+func Test_bashrunHandlers_ReadStderr(t *testing.T) {
+	ts := httptest.NewServer(testRouter(t))
+	defer ts.Close()
+
+	client := http.Client{}
+
+	tests := []testTableElem{
+		{
+			caseName:       "non-numeric id",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/a/stderr",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "unknown subresource",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/1/abc",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusNotFound,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //25
+			caseName:       "server error",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/1/stderr",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusInternalServerError,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //26
+			caseName:       "rows not found",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/1/stderr",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusNotFound,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //27
+			caseName:       "empty stderr",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/1/stderr",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusNoContent,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //28
+			caseName:       "ok",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/1/stderr",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusOK,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Log(testCase.caseName)
+
+		req, err := buildRequest(testCase.httpMethod, testCase.route, testCase.body, testCase.headers, ts.URL)
+		require.NoError(t, err)
+
+		sendReq(t, &client, req, testCase.expectedStatus, testCase.parsedBody, testCase.requireParsing)
+	}
+}
+
+func Test_bashrunHandlers_WaitCommand(t *testing.T) {
+	ts := httptest.NewServer(testRouter(t))
+	defer ts.Close()
+
+	client := http.Client{}
+
+	tests := []testTableElem{
+		{
+			caseName:       "non-numeric id",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/a/wait",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong timeout",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/1/wait?timeout=abc",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "non-positive timeout",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/1/wait?timeout=0s",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "server error",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/1/wait",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusInternalServerError,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "rows not found",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/1/wait?timeout=5",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusNotFound,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "ok",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/1/wait?timeout=30s",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusOK,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Log(testCase.caseName)
+
+		req, err := buildRequest(testCase.httpMethod, testCase.route, testCase.body, testCase.headers, ts.URL)
+		require.NoError(t, err)
+
+		sendReq(t, &client, req, testCase.expectedStatus, testCase.parsedBody, testCase.requireParsing)
+	}
+}
+
+func Test_bashrunHandlers_WaitStoppedCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var wg sync.WaitGroup
+
+	ar := mocks.NewMockBashrunRepository(ctrl)
+	ah := New(service.New(context.Background(), ar, &wg, &config.Config{}))
+
+	callbackURL := "http://example.com/hook"
+	ar.EXPECT().CreateCommand(gomock.Any(), gomock.Any()).Return(7, nil).Times(1)
+	ar.EXPECT().ReadStatus(gomock.Any(), 7).Return(domain.StatusCreated, nil).Times(1)
+	ar.EXPECT().ReadCommandLinks(gomock.Any(), 7).Return(domain.CommandLinks{CallbackURL: callbackURL}, nil).Times(1)
+	ar.EXPECT().UpdateStatus(gomock.Any(), 7, domain.StatusTransition{From: domain.StatusCreated, To: domain.StatusStopped}).Return(nil).Times(1)
+	ar.EXPECT().ReadCommand(gomock.Any(), 7).Return(domain.CommandFromDB{ID: 7, Command: "sleep 60", Status: domain.StatusStopped}, nil).MinTimes(1)
+	ar.EXPECT().ReadOutput(gomock.Any(), 7, gomock.Any(), gomock.Any()).Return(domain.OutputSlice{}, nil).Times(2)
+	ar.EXPECT().CreateDelivery(gomock.Any(), 7, callbackURL, gomock.Any()).Return(nil).Times(1)
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /commands", http.HandlerFunc(ah.CreateCommand))
+	mux.Handle("GET /commands/stop/{command_id}", http.HandlerFunc(ah.StopCommand))
+	mux.Handle("GET /commands/{command_id}/{subresource}", http.HandlerFunc(ah.CommandSubresource))
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	client := http.Client{}
+
+	req, err := buildRequest(http.MethodPost, "/commands", `{"command":"sleep 60","callback_url":"`+callbackURL+`"}`, [][2]string{{"Content-Type", "application/json"}}, ts.URL)
+	require.NoError(t, err)
+	sendReq(t, &client, req, http.StatusAccepted, nil, false)
+
+	req, err = buildRequest(http.MethodGet, "/commands/stop/7", "", [][2]string{}, ts.URL)
+	require.NoError(t, err)
+	sendReq(t, &client, req, http.StatusAccepted, nil, false)
+
+	start := time.Now()
+
+	req, err = buildRequest(http.MethodGet, "/commands/7/wait?timeout=5s", "", [][2]string{}, ts.URL)
+	require.NoError(t, err)
+
+	var command domain.CommandFromDB
+	sendReq(t, &client, req, http.StatusOK, &command, true)
+
+	require.Equal(t, domain.StatusStopped, command.Status)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+func Test_bashrunHandlers_FailedPIDUpdateStopsProcess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var wg sync.WaitGroup
+
+	ar := mocks.NewMockBashrunRepository(ctrl)
+	as := service.New(context.Background(), ar, &wg, &config.Config{MaxConcurrentCommands: 1})
+	ah := New(as)
+
+	queue := make(chan domain.QueuedCommand, 1)
+	ar.EXPECT().ClaimCommand(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, string, time.Duration) (domain.QueuedCommand, error) {
+		select {
+		case queued := <-queue:
+			return queued, nil
+		default:
+			return domain.QueuedCommand{}, appErrors.ErrNoRows
+		}
+	}).AnyTimes()
+	ar.EXPECT().ClaimStopRequests(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	ar.EXPECT().PromoteScheduledCommands(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	ar.EXPECT().RenewLeases(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	ar.EXPECT().ListExpiredCommands(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	ar.EXPECT().ListMissingDeliveries(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+
+	pids := make(chan int, 1)
+	failed := make(chan struct{})
+	ar.EXPECT().CreateCommand(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, command domain.CommandFromUser) (int, error) {
+		queue <- domain.QueuedCommand{ID: 9, Command: command}
+		return 9, nil
+	}).Times(1)
+	ar.EXPECT().ReadStatus(gomock.Any(), 9).Return(domain.StatusCreated, nil).Times(1)
+	ar.EXPECT().UpdatePID(gomock.Any(), 9, gomock.Any()).DoAndReturn(func(_ context.Context, _ int, pid int) error {
+		pids <- pid
+		return errors.New("")
+	}).Times(1)
+	ar.EXPECT().UpdateStatus(gomock.Any(), 9, gomock.Any()).DoAndReturn(func(_ context.Context, _ int, transition domain.StatusTransition) error {
+		require.Equal(t, domain.StatusFailed, transition.To)
+		close(failed)
+		return nil
+	}).Times(1)
+
+	workerContext, stopWorkers := context.WithCancel(context.Background())
+	workersDone := make(chan struct{})
+	go func() {
+		as.RunWorkers(workerContext)
+		close(workersDone)
+	}()
+
+	defer func() {
+		stopWorkers()
+		<-workersDone
+	}()
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /commands", http.HandlerFunc(ah.CreateCommand))
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	client := http.Client{}
+
+	req, err := buildRequest(http.MethodPost, "/commands", `{"command":"sleep 30"}`, [][2]string{{"Content-Type", "application/json"}}, ts.URL)
+	require.NoError(t, err)
+	sendReq(t, &client, req, http.StatusAccepted, nil, false)
+
+	select {
+	case <-failed:
+	case <-time.After(10 * time.Second):
+		t.Fatal("command was not failed")
+	}
+
+	// the process is stopped and reaped before the failure is saved
+	pid := <-pids
+	require.ErrorIs(t, syscall.Kill(pid, 0), syscall.ESRCH)
+}
+
+func Test_bashrunHandlers_WaitPolledCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var wg sync.WaitGroup
+
+	ar := mocks.NewMockBashrunRepository(ctrl)
+	ah := New(service.New(context.Background(), ar, &wg, &config.Config{}))
+
+	// the command is run by another instance, so it has no local events
+	var exitStatus int
+	gomock.InOrder(
+		ar.EXPECT().ReadCommand(gomock.Any(), 3).Return(domain.CommandFromDB{ID: 3, Status: domain.StatusStarted}, nil).Times(2),
+		ar.EXPECT().ReadCommand(gomock.Any(), 3).Return(domain.CommandFromDB{ID: 3, Status: domain.StatusDone, ExitStatus: &exitStatus}, nil).Times(1),
+	)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /commands/{command_id}/{subresource}", http.HandlerFunc(ah.CommandSubresource))
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	client := http.Client{}
+
+	req, err := buildRequest(http.MethodGet, "/commands/3/wait?timeout=5s", "", [][2]string{}, ts.URL)
+	require.NoError(t, err)
+
+	var command domain.CommandFromDB
+	sendReq(t, &client, req, http.StatusOK, &command, true)
+
+	require.Equal(t, domain.StatusDone, command.Status)
+}
+
+func Test_bashrunHandlers_ListDeliveries(t *testing.T) {
+	ts := httptest.NewServer(testRouter(t))
+	defer ts.Close()
+
+	client := http.Client{}
+
+	tests := []testTableElem{
+		{
+			caseName:       "non-numeric id",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/a/deliveries",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //29
+			caseName:       "server error",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/1/deliveries",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusInternalServerError,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //30
+			caseName:       "rows not found",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/1/deliveries",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusNotFound,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //31
+			caseName:       "ok",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/1/deliveries",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusOK,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Log(testCase.caseName)
+
+		req, err := buildRequest(testCase.httpMethod, testCase.route, testCase.body, testCase.headers, ts.URL)
+		require.NoError(t, err)
+
+		sendReq(t, &client, req, testCase.expectedStatus, testCase.parsedBody, testCase.requireParsing)
+	}
+}
+
+func Test_bashrunHandlers_ListAttempts(t *testing.T) {
+	ts := httptest.NewServer(testRouter(t))
+	defer ts.Close()
+
+	client := http.Client{}
+
+	var attempts []domain.CommandFromDB
+	tests := []testTableElem{
+		{
+			caseName:       "non-numeric id",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/a/attempts",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //40
+			caseName:       "server error",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/1/attempts",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusInternalServerError,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //41
+			caseName:       "rows not found",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/1/attempts",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusNotFound,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //42
+			caseName:       "ok",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/1/attempts",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusOK,
+			requireParsing: true,
+			parsedBody:     &attempts,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Log(testCase.caseName)
+
+		req, err := buildRequest(testCase.httpMethod, testCase.route, testCase.body, testCase.headers, ts.URL)
+		require.NoError(t, err)
+
+		sendReq(t, &client, req, testCase.expectedStatus, testCase.parsedBody, testCase.requireParsing)
+	}
+
+	require.Len(t, attempts, 1)
+	require.Equal(t, 1, *attempts[0].Attempt)
+	require.Equal(t, 1, *attempts[0].ParentID)
+	require.Equal(t, "***", attempts[0].Env["DB_PASSWORD"])
+}
+
+func Test_bashrunHandlers_CreateSchedule(t *testing.T) {
+	ts := httptest.NewServer(testRouter(t))
+	defer ts.Close()
+
+	client := http.Client{}
+
+	tests := []testTableElem{
+		{
+			caseName:       "wrong content type",
+			httpMethod:     http.MethodPost,
+			route:          "/schedules",
+			body:           `{"cron": "@daily", "command": {"command": "ls"}}`,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "empty command",
+			httpMethod:     http.MethodPost,
+			route:          "/schedules",
+			body:           `{"cron": "@daily", "command": {}}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "unknown field",
+			httpMethod:     http.MethodPost,
+			route:          "/schedules",
+			body:           `{"cron": "@daily", "every": "1m", "command": {"command": "ls"}}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong cron",
+			httpMethod:     http.MethodPost,
+			route:          "/schedules",
+			body:           `{"cron": "61 * * * *", "command": {"command": "ls"}}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "cron without upcoming runs",
+			httpMethod:     http.MethodPost,
+			route:          "/schedules",
+			body:           `{"cron": "0 0 30 2 *", "command": {"command": "ls"}}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong timezone",
+			httpMethod:     http.MethodPost,
+			route:          "/schedules",
+			body:           `{"cron": "@daily", "timezone": "Mars/Olympus", "command": {"command": "ls"}}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong overlap",
+			httpMethod:     http.MethodPost,
+			route:          "/schedules",
+			body:           `{"cron": "@daily", "overlap": "parallel", "command": {"command": "ls"}}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "delayed schedule command",
+			httpMethod:     http.MethodPost,
+			route:          "/schedules",
+			body:           `{"cron": "@daily", "command": {"command": "ls", "delay_seconds": 60}}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong command priority",
+			httpMethod:     http.MethodPost,
+			route:          "/schedules",
+			body:           `{"cron": "@daily", "command": {"command": "ls", "priority": 1001}}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "server error",
+			httpMethod:     http.MethodPost,
+			route:          "/schedules",
+			body:           `{"cron": "*/5 * * * *", "command": {"command": "ls"}}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusInternalServerError,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "ok",
+			httpMethod:     http.MethodPost,
+			route:          "/schedules",
+			body:           `{"cron": "0 3 * * mon-fri", "timezone": "Europe/Moscow", "overlap": "queue", "command": {"command": "ls"}}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusCreated,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Log(testCase.caseName)
+
+		req, err := buildRequest(testCase.httpMethod, testCase.route, testCase.body, testCase.headers, ts.URL)
+		require.NoError(t, err)
+
+		sendReq(t, &client, req, testCase.expectedStatus, testCase.parsedBody, testCase.requireParsing)
+	}
+}
+
+func Test_bashrunHandlers_Schedules(t *testing.T) {
+	ts := httptest.NewServer(testRouter(t))
+	defer ts.Close()
+
+	client := http.Client{}
+
+	tests := []testTableElem{
+		{
+			caseName:       "list server error",
+			httpMethod:     http.MethodGet,
+			route:          "/schedules",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusInternalServerError,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "list ok",
+			httpMethod:     http.MethodGet,
+			route:          "/schedules",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusOK,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "non-numeric id",
+			httpMethod:     http.MethodGet,
+			route:          "/schedules/a",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "read server error",
+			httpMethod:     http.MethodGet,
+			route:          "/schedules/1",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusInternalServerError,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "read not found",
+			httpMethod:     http.MethodGet,
+			route:          "/schedules/1",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusNotFound,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "read ok",
+			httpMethod:     http.MethodGet,
+			route:          "/schedules/1",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusOK,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "pause wrong id",
+			httpMethod:     http.MethodPost,
+			route:          "/schedules/0/pause",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "pause not found",
+			httpMethod:     http.MethodPost,
+			route:          "/schedules/1/pause",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusNotFound,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "pause ok",
+			httpMethod:     http.MethodPost,
+			route:          "/schedules/1/pause",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusNoContent,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "resume ok",
+			httpMethod:     http.MethodPost,
+			route:          "/schedules/1/resume",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusNoContent,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "delete not found",
+			httpMethod:     http.MethodDelete,
+			route:          "/schedules/1",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusNotFound,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "delete ok",
+			httpMethod:     http.MethodDelete,
+			route:          "/schedules/1",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusNoContent,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Log(testCase.caseName)
+
+		req, err := buildRequest(testCase.httpMethod, testCase.route, testCase.body, testCase.headers, ts.URL)
+		require.NoError(t, err)
+
+		sendReq(t, &client, req, testCase.expectedStatus, testCase.parsedBody, testCase.requireParsing)
+	}
+}
+
+func Test_bashrunHandlers_ListSchedulesRedacted(t *testing.T) {
+	ts := httptest.NewServer(testRouter(t))
+	defer ts.Close()
+
+	client := http.Client{}
+
+	req, err := buildRequest(http.MethodGet, "/schedules", "", [][2]string{}, ts.URL)
+	require.NoError(t, err)
+
+	sendReq(t, &client, req, http.StatusInternalServerError, nil, false) //34
+
+	var schedules []map[string]interface{}
+	sendReq(t, &client, req, http.StatusOK, &schedules, true)
+
+	require.Len(t, schedules, 1)
+	command := schedules[0]["command"].(map[string]interface{})
+	require.Equal(t, map[string]interface{}{"DB_PASSWORD": "***"}, command["env"])
+	require.NotContains(t, command, "callback_secret")
+}
+
+func Test_bashrunHandlers_CreateWorkflow(t *testing.T) {
+	ts := httptest.NewServer(testRouter(t))
+	defer ts.Close()
+
+	client := http.Client{}
+
+	tests := []testTableElem{
+		{
+			caseName:       "wrong content type",
+			httpMethod:     http.MethodPost,
+			route:          "/workflows",
+			body:           `{"nodes": [{"name": "a", "command": {"command": "ls"}}]}`,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "empty command",
+			httpMethod:     http.MethodPost,
+			route:          "/workflows",
+			body:           `{"nodes": [{"name": "a", "command": {}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "unknown field",
+			httpMethod:     http.MethodPost,
+			route:          "/workflows",
+			body:           `{"nodes": [{"name": "a", "needs": ["b"], "command": {"command": "ls"}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "no nodes",
+			httpMethod:     http.MethodPost,
+			route:          "/workflows",
+			body:           `{"name": "empty", "nodes": []}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "empty node name",
+			httpMethod:     http.MethodPost,
+			route:          "/workflows",
+			body:           `{"nodes": [{"name": "", "command": {"command": "ls"}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "duplicate node name",
+			httpMethod:     http.MethodPost,
+			route:          "/workflows",
+			body:           `{"nodes": [{"name": "a", "command": {"command": "ls"}}, {"name": "a", "command": {"command": "pwd"}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "unknown dependency",
+			httpMethod:     http.MethodPost,
+			route:          "/workflows",
+			body:           `{"nodes": [{"name": "a", "depends_on": ["b"], "command": {"command": "ls"}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "self dependency",
+			httpMethod:     http.MethodPost,
+			route:          "/workflows",
+			body:           `{"nodes": [{"name": "a", "depends_on": ["a"], "command": {"command": "ls"}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "cycle",
+			httpMethod:     http.MethodPost,
+			route:          "/workflows",
+			body:           `{"nodes": [{"name": "a", "depends_on": ["c"], "command": {"command": "ls"}}, {"name": "b", "depends_on": ["a"], "command": {"command": "ls"}}, {"name": "c", "depends_on": ["b"], "command": {"command": "ls"}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong condition",
+			httpMethod:     http.MethodPost,
+			route:          "/workflows",
+			body:           `{"nodes": [{"name": "a", "command": {"command": "ls"}}, {"name": "b", "depends_on": ["a"], "condition": "sometimes", "command": {"command": "ls"}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "delayed node",
+			httpMethod:     http.MethodPost,
+			route:          "/workflows",
+			body:           `{"nodes": [{"name": "a", "command": {"command": "ls", "delay_seconds": 60}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong node timeout",
+			httpMethod:     http.MethodPost,
+			route:          "/workflows",
+			body:           `{"nodes": [{"name": "a", "command": {"command": "ls", "timeout_seconds": -1}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "server error",
+			httpMethod:     http.MethodPost,
+			route:          "/workflows",
+			body:           `{"nodes": [{"name": "a", "command": {"command": "ls"}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusInternalServerError,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "ok",
+			httpMethod:     http.MethodPost,
+			route:          "/workflows",
+			body:           `{"name": "build", "nodes": [{"name": "build", "command": {"command": "make"}}, {"name": "test", "depends_on": ["build"], "command": {"argv": ["make", "test"]}}, {"name": "notify", "depends_on": ["test"], "condition": "on_failure", "command": {"command": "echo failed"}}, {"name": "cleanup", "depends_on": ["build", "test"], "condition": "always", "command": {"command": "make clean"}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusCreated,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Log(testCase.caseName)
+
+		req, err := buildRequest(testCase.httpMethod, testCase.route, testCase.body, testCase.headers, ts.URL)
+		require.NoError(t, err)
+
+		sendReq(t, &client, req, testCase.expectedStatus, testCase.parsedBody, testCase.requireParsing)
+	}
+}
+
+func Test_bashrunHandlers_Workflows(t *testing.T) {
+	ts := httptest.NewServer(testRouter(t))
+	defer ts.Close()
+
+	client := http.Client{}
+
+	tests := []testTableElem{
+		{
+			caseName:       "list server error",
+			httpMethod:     http.MethodGet,
+			route:          "/workflows",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusInternalServerError,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "list ok",
+			httpMethod:     http.MethodGet,
+			route:          "/workflows",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusOK,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "non-numeric id",
+			httpMethod:     http.MethodGet,
+			route:          "/workflows/a",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "zero id",
+			httpMethod:     http.MethodGet,
+			route:          "/workflows/0",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "read not found",
+			httpMethod:     http.MethodGet,
+			route:          "/workflows/1",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusNotFound,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "read ok",
+			httpMethod:     http.MethodGet,
+			route:          "/workflows/1",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusOK,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Log(testCase.caseName)
+
+		req, err := buildRequest(testCase.httpMethod, testCase.route, testCase.body, testCase.headers, ts.URL)
+		require.NoError(t, err)
+
+		sendReq(t, &client, req, testCase.expectedStatus, testCase.parsedBody, testCase.requireParsing)
+	}
+}
+
+func Test_bashrunHandlers_CreatePipeline(t *testing.T) {
+	ts := httptest.NewServer(testRouter(t))
+	defer ts.Close()
+
+	client := http.Client{}
+
+	tests := []testTableElem{
+		{
+			caseName:       "wrong content type",
+			httpMethod:     http.MethodPost,
+			route:          "/pipelines",
+			body:           `{"steps": [{"command": {"command": "ls"}}]}`,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "empty command",
+			httpMethod:     http.MethodPost,
+			route:          "/pipelines",
+			body:           `{"steps": [{"command": {"command": "ls"}}, {"input": "stdin", "command": {}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "unknown field",
+			httpMethod:     http.MethodPost,
+			route:          "/pipelines",
+			body:           `{"steps": [{"command": {"command": "ls"}}], "stop_on_error": true}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "no steps",
+			httpMethod:     http.MethodPost,
+			route:          "/pipelines",
+			body:           `{"name": "empty", "steps": []}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "input of the first step",
+			httpMethod:     http.MethodPost,
+			route:          "/pipelines",
+			body:           `{"steps": [{"input": "stdin", "command": {"command": "cat"}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong input",
+			httpMethod:     http.MethodPost,
+			route:          "/pipelines",
+			body:           `{"steps": [{"command": {"command": "ls"}}, {"input": "file", "command": {"command": "cat"}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "stdin input with own stdin",
+			httpMethod:     http.MethodPost,
+			route:          "/pipelines",
+			body:           `{"steps": [{"command": {"command": "ls"}}, {"input": "stdin", "command": {"command": "cat", "stdin": "abc"}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "input env without env input",
+			httpMethod:     http.MethodPost,
+			route:          "/pipelines",
+			body:           `{"steps": [{"command": {"command": "ls"}}, {"input_env": "FILES", "command": {"command": "echo $FILES"}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong input env",
+			httpMethod:     http.MethodPost,
+			route:          "/pipelines",
+			body:           `{"steps": [{"command": {"command": "ls"}}, {"input": "env", "input_env": "A=B", "command": {"command": "env"}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "input env set in step env",
+			httpMethod:     http.MethodPost,
+			route:          "/pipelines",
+			body:           `{"steps": [{"command": {"command": "ls"}}, {"input": "env", "input_env": "FILES", "command": {"command": "echo $FILES", "env": {"FILES": "a"}}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "delayed step",
+			httpMethod:     http.MethodPost,
+			route:          "/pipelines",
+			body:           `{"steps": [{"command": {"command": "ls", "delay_seconds": 60}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong step priority",
+			httpMethod:     http.MethodPost,
+			route:          "/pipelines",
+			body:           `{"steps": [{"command": {"command": "ls", "priority": 1001}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "server error",
+			httpMethod:     http.MethodPost,
+			route:          "/pipelines",
+			body:           `{"steps": [{"command": {"command": "ls"}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusInternalServerError,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "ok",
+			httpMethod:     http.MethodPost,
+			route:          "/pipelines",
+			body:           `{"name": "report", "continue_on_error": true, "steps": [{"name": "list", "command": {"command": "ls /tmp"}}, {"name": "count", "input": "stdin", "command": {"argv": ["wc", "-l"]}}, {"name": "print", "input": "env", "input_env": "COUNT", "command": {"command": "echo files: $COUNT"}}]}`,
+			headers:        [][2]string{{"Content-Type", "application/json"}},
+			expectedStatus: http.StatusCreated,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Log(testCase.caseName)
+
+		req, err := buildRequest(testCase.httpMethod, testCase.route, testCase.body, testCase.headers, ts.URL)
+		require.NoError(t, err)
+
+		sendReq(t, &client, req, testCase.expectedStatus, testCase.parsedBody, testCase.requireParsing)
+	}
+}
+
+func Test_bashrunHandlers_Pipelines(t *testing.T) {
+	ts := httptest.NewServer(testRouter(t))
+	defer ts.Close()
+
+	client := http.Client{}
+
+	tests := []testTableElem{
+		{
+			caseName:       "list server error",
+			httpMethod:     http.MethodGet,
+			route:          "/pipelines",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusInternalServerError,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "list ok",
+			httpMethod:     http.MethodGet,
+			route:          "/pipelines",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusOK,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "non-numeric id",
+			httpMethod:     http.MethodGet,
+			route:          "/pipelines/a",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "zero id",
+			httpMethod:     http.MethodGet,
+			route:          "/pipelines/0",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "read not found",
+			httpMethod:     http.MethodGet,
+			route:          "/pipelines/1",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusNotFound,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "read ok",
+			httpMethod:     http.MethodGet,
+			route:          "/pipelines/1",
+			body:           ``,
+			headers:        [][2]string{},
+			expectedStatus: http.StatusOK,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Log(testCase.caseName)
+
+		req, err := buildRequest(testCase.httpMethod, testCase.route, testCase.body, testCase.headers, ts.URL)
+		require.NoError(t, err)
+
+		sendReq(t, &client, req, testCase.expectedStatus, testCase.parsedBody, testCase.requireParsing)
+	}
+}
+
+func Test_bashrunHandlers_StreamCommand(t *testing.T) {
+	ts := httptest.NewServer(testRouter(t))
+	defer ts.Close()
+
+	client := http.Client{}
+
+	tests := []testTableElem{
+		{
+			caseName:       "non-numeric id",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/a/stream",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{
+			caseName:       "wrong Last-Event-ID",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/1/stream",
+			body:           "",
+			headers:        [][2]string{{"Last-Event-ID", "a"}},
+			expectedStatus: http.StatusBadRequest,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //17
+			caseName:       "server error",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/1/stream",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusInternalServerError,
+			requireParsing: false,
+			parsedBody:     nil,
+		},
+		{ //18
+			caseName:       "rows not found",
+			httpMethod:     http.MethodGet,
+			route:          "/commands/1/stream",
+			body:           "",
+			headers:        [][2]string{},
+			expectedStatus: http.StatusNotFound,
 			requireParsing: false,
 			parsedBody:     nil,
 		},
@@ -666,3 +2829,106 @@ func Test_bashrunHandlers_ReadOutput(t *testing.T) {
 		sendReq(t, &client, req, testCase.expectedStatus, testCase.parsedBody, testCase.requireParsing)
 	}
 }
+
+func Test_bashrunHandlers_StreamStoredCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var wg sync.WaitGroup
+
+	ar := mocks.NewMockBashrunRepository(ctrl)
+	ah := New(service.New(context.Background(), ar, &wg, &config.Config{}))
+
+	// the IDs of output events are stdout offsets, the stream resumes from the
+	// offset in Last-Event-ID
+	var exitStatus int
+	ar.EXPECT().ReadCommand(gomock.Any(), 1).Return(domain.CommandFromDB{ID: 1, Status: domain.StatusDone, ExitStatus: &exitStatus}, nil).Times(1)
+	ar.EXPECT().ReadOutput(gomock.Any(), 1, domain.StreamStdout, domain.OutputRange{Offset: 2}).Return(domain.OutputSlice{Data: "b\r\nc", Start: 2, Total: 6}, nil).Times(1)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /commands/{command_id}/{subresource}", http.HandlerFunc(ah.CommandSubresource))
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	client := http.Client{}
+
+	req, err := buildRequest(http.MethodGet, "/commands/1/stream", "", [][2]string{{"Last-Event-ID", "2"}}, ts.URL)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	require.Equal(t, "id: 5\nevent: output\ndata: b\n\nid: 6\nevent: output\ndata: c\n\nevent: exit\ndata: 0\n\nevent: status\ndata: done\n\n", string(body))
+}
+
+func Test_outputContentType(t *testing.T) {
+	tests := []struct {
+		route    string
+		accept   string
+		expected string
+	}{
+		{route: "/commands/output/1", accept: "", expected: "text/plain"},
+		{route: "/commands/output/1", accept: "text/plain", expected: "text/plain"},
+		{route: "/commands/output/1", accept: "text/plain;q=0.5, application/octet-stream", expected: "application/octet-stream"},
+		{route: "/commands/output/1?format=raw", accept: "", expected: "application/octet-stream"},
+		{route: "/commands/output/1?format=text", accept: "", expected: "text/plain"},
+	}
+
+	for _, testCase := range tests {
+		req := httptest.NewRequest(http.MethodGet, testCase.route, nil)
+		if testCase.accept != "" {
+			req.Header.Set("Accept", testCase.accept)
+		}
+
+		require.Equal(t, testCase.expected, outputContentType(req))
+	}
+}
+
+func Test_bashrunHandlers_ReadOutputHeaders(t *testing.T) {
+	ts := httptest.NewServer(testRouter(t))
+	defer ts.Close()
+
+	client := http.Client{}
+
+	req, err := buildRequest(http.MethodGet, "/commands/output/1?combined=true&download=true", "", [][2]string{{"Range", "bytes=1-"}}, ts.URL)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	require.Equal(t, "bytes", resp.Header.Get("Accept-Ranges"))
+	require.Equal(t, "bytes 0-2/3", resp.Header.Get("Content-Range"))
+	require.Equal(t, "attachment; filename=command-1-combined.log", resp.Header.Get("Content-Disposition"))
+}
+
+func Test_parseRangeHeader(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected domain.OutputRange
+		ok       bool
+	}{
+		{header: "", ok: false},
+		{header: "lines=0-1", ok: false},
+		{header: "bytes=0-1,5-6", ok: false},
+		{header: "bytes=5-1", ok: false},
+		{header: "bytes=-0", ok: false},
+		{header: "bytes=0-99", expected: domain.OutputRange{Offset: 0, Limit: 100}, ok: true},
+		{header: "bytes=100-", expected: domain.OutputRange{Offset: 100}, ok: true},
+		{header: "bytes=-500", expected: domain.OutputRange{Suffix: 500}, ok: true},
+	}
+
+	for _, testCase := range tests {
+		rng, ok := parseRangeHeader(testCase.header)
+		require.Equal(t, testCase.ok, ok, testCase.header)
+		require.Equal(t, testCase.expected, rng, testCase.header)
+	}
+}
@@ -1,11 +1,17 @@
 package repository
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 
 	appErrors "github.com/PoorMercymain/bashrun/errors"
 	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
@@ -15,6 +21,36 @@ var (
 	_ domain.BashrunRepository = (*bashrunRepository)(nil)
 )
 
+const (
+	// outputOwner resolves a command with retries to its latest attempt, the
+	// parent has no output of its own.
+	outputOwner    = "COALESCE((SELECT MAX(a.command_id) FROM cmd a WHERE a.parent_id = cmd.command_id), cmd.command_id)"
+	stdoutColumn   = "COALESCE((SELECT cmd_output_text(string_agg(o.data, ''::BYTEA ORDER BY o.seq)) FROM cmd_output o WHERE o.command_id = " + outputOwner + " AND o.stream = 'stdout'), '')"
+	stderrColumn   = "COALESCE((SELECT cmd_output_text(string_agg(o.data, ''::BYTEA ORDER BY o.seq)) FROM cmd_output o WHERE o.command_id = " + outputOwner + " AND o.stream = 'stderr'), '')"
+	commandColumns = "command_id, command, script, script_args, interpreter, argv, pid, " + stdoutColumn + ", " + stderrColumn + ", " + statusColumns
+	// listColumns leave out the output when the list is requested without it,
+	// then it is not aggregated from all chunks of every listed command.
+	listColumns   = "command_id, command, script, script_args, interpreter, argv, pid, NULL::TEXT, NULL::TEXT, " + statusColumns
+	statusColumns = "processing_status, error, exit_status, timeout_seconds, kill_grace_seconds, created_at, run_at, started_at, finished_at, queued_ms, duration_ms, user_cpu_ms, system_cpu_ms, env, workdir, clear_env, labels, max_output_bytes, output_policy, truncated, output_bytes, callback_url, priority, schedule_id, retry_policy, parent_id, attempt, workflow_id, pipeline_id"
+)
+
+const (
+	// searchDocument must match the expression of idx_cmd_output_search_document,
+	// otherwise the search does not use the index.
+	searchDocument = "(document || to_tsvector('simple', cmd_output_text(tail)))"
+	// maxSearchTail bounds the stdout kept out of the search document while
+	// waiting for the end of its last word.
+	maxSearchTail = 4096
+)
+
+const invalidRegularExpressionCode = "2201B"
+
+var sortColumns = map[string]string{
+	domain.SortByID:        "command_id",
+	domain.SortByCreatedAt: "created_at",
+	domain.SortByDuration:  "duration_ms",
+}
+
 type bashrunRepository struct {
 	db *postgres
 }
@@ -23,6 +59,14 @@ func New(db *postgres) *bashrunRepository {
 	return &bashrunRepository{db: db}
 }
 
+func scanCommand(row pgx.Row, command *domain.CommandFromDB) error {
+	return row.Scan(&command.ID, &command.Command, &command.Script, &command.ScriptArgs, &command.Interpreter, &command.Argv, &command.PID, &command.Output, &command.Stderr, &command.Status, &command.Error, &command.ExitStatus,
+		&command.TimeoutSeconds, &command.KillGraceSeconds, &command.CreatedAt, &command.RunAt, &command.StartedAt, &command.FinishedAt,
+		&command.QueuedMS, &command.DurationMS, &command.UserCPUMS, &command.SystemCPUMS, &command.Env, &command.Workdir, &command.ClearEnv, &command.Labels,
+		&command.MaxOutputBytes, &command.OutputPolicy, &command.Truncated, &command.OutputBytes, &command.CallbackURL, &command.Priority, &command.ScheduleID,
+		&command.Retry, &command.ParentID, &command.Attempt, &command.WorkflowID, &command.PipelineID)
+}
+
 func (r *bashrunRepository) Ping(ctx context.Context) error {
 	const logPrefix = "repository.Ping"
 
@@ -34,31 +78,158 @@ func (r *bashrunRepository) Ping(ctx context.Context) error {
 	return nil
 }
 
-func (r *bashrunRepository) CreateCommand(ctx context.Context, command string) (int, error) {
+// commandOwner links a command to the schedule, workflow or pipeline that
+// created it.
+type commandOwner struct {
+	ScheduleID *int
+	WorkflowID *int
+	PipelineID *int
+}
+
+func insertCommand(ctx context.Context, tx pgx.Tx, command domain.CommandFromUser, owner commandOwner) (int, error) {
+	var stdin []byte
+	if command.Stdin != "" {
+		stdin = []byte(command.Stdin)
+	}
+
+	labels := command.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+
+	var id int
+	err := tx.QueryRow(ctx, "INSERT INTO cmd(command, script, script_args, interpreter, argv, timeout_seconds, kill_grace_seconds, env, workdir, clear_env, stdin, created_at, labels, max_output_bytes, output_policy, callback_url, callback_secret, priority, schedule_id, run_at, processing_status, retry_policy, workflow_id, pipeline_id) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24) RETURNING command_id",
+		command.Command, command.Script, command.ScriptArgs, command.Interpreter, command.Argv, command.TimeoutSeconds, command.KillGraceSeconds, command.Env, command.Workdir, command.ClearEnv, stdin, command.CreatedAt, labels,
+		command.MaxOutputBytes, command.OutputPolicy, command.CallbackURL, command.CallbackSecret, command.Priority, owner.ScheduleID, command.RunAt, command.InitialStatus(), command.Retry, owner.WorkflowID, owner.PipelineID).Scan(&id)
+	if err != nil || command.Retry == nil {
+		return id, err
+	}
+
+	_, err = insertAttempt(ctx, tx, domain.NewAttempt{ParentID: id, Number: 1, CreatedAt: command.CreatedAt, RunAt: command.RunAt})
+
+	return id, err
+}
+
+func (r *bashrunRepository) CreateCommand(ctx context.Context, command domain.CommandFromUser) (int, error) {
 	const logPrefix = "repository.CreateCommand"
 
 	var id int
 	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
-		err := tx.QueryRow(ctx, "INSERT INTO cmd(command) VALUES($1) RETURNING command_id", command).Scan(&id)
+		var err error
+		id, err = insertCommand(ctx, tx, command, commandOwner{})
+		return err
+	})
+
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return id, nil
+}
+
+func (r *bashrunRepository) AppendOutput(ctx context.Context, id int, chunks []domain.OutputChunk) error {
+	const logPrefix = "repository.AppendOutput"
+
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		_, err := tx.CopyFrom(ctx, pgx.Identifier{"cmd_output"}, []string{"command_id", "seq", "stream", "stream_offset", "data"},
+			pgx.CopyFromSlice(len(chunks), func(i int) ([]interface{}, error) {
+				return []interface{}{id, chunks[i].Seq, chunks[i].Stream, chunks[i].StreamOffset, []byte(chunks[i].Data)}, nil
+			}))
 		if err != nil {
 			return err
 		}
 
-		return nil
+		return appendSearch(ctx, tx, id, chunks)
 	})
 
 	if err != nil {
-		return 0, fmt.Errorf("%s: %w", logPrefix, err)
+		return fmt.Errorf("%s: %w", logPrefix, err)
 	}
 
-	return id, nil
+	return nil
 }
 
-func (r *bashrunRepository) UpdateOutput(ctx context.Context, id int, newOutputPart string) error {
-	const logPrefix = "repository.UpdateOutput"
+func (r *bashrunRepository) TrimOutput(ctx context.Context, id int, trim domain.OutputTrim) error {
+	const logPrefix = "repository.TrimOutput"
 
 	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
-		tag, err := tx.Exec(ctx, "UPDATE cmd SET output_text = output_text || $1 WHERE command_id = $2", newOutputPart, id)
+		_, err := tx.Exec(ctx, "DELETE FROM cmd_output WHERE command_id = $1 AND seq < $2", id, trim.Seq)
+		if err != nil {
+			return err
+		}
+
+		if trim.Bytes != 0 {
+			_, err = tx.Exec(ctx, "UPDATE cmd_output SET data = substring(data FROM $1), stream_offset = stream_offset + $2 WHERE command_id = $3 AND seq = $4",
+				trim.Bytes+1, trim.Bytes, id, trim.Seq)
+			if err != nil {
+				return err
+			}
+		}
+
+		// the trimmed words can not be taken out of the document, so it is
+		// built again from the stdout that is left, the tail stays as it is
+		_, err = tx.Exec(ctx, "UPDATE cmd_output_search s SET document = to_tsvector('simple', cmd_output_text(substring(o.data FROM 1 FOR GREATEST(octet_length(o.data) - octet_length(s.tail), 0))))"+
+			" FROM (SELECT COALESCE(string_agg(data, ''::BYTEA ORDER BY seq), ''::BYTEA) AS data FROM cmd_output WHERE command_id = $1 AND stream = 'stdout') o WHERE s.command_id = $1", id)
+
+		return err
+	})
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return nil
+}
+
+// appendSearch adds the stdout of chunks to the search document of the
+// command. The last word may continue in the next chunks, so everything after
+// the last whitespace is kept in the tail until the word ends.
+func appendSearch(ctx context.Context, tx pgx.Tx, id int, chunks []domain.OutputChunk) error {
+	var stdout []byte
+	for _, chunk := range chunks {
+		if chunk.Stream == domain.StreamStdout {
+			stdout = append(stdout, chunk.Data...)
+		}
+	}
+
+	if len(stdout) == 0 {
+		return nil
+	}
+
+	var tail []byte
+	err := tx.QueryRow(ctx, "SELECT tail FROM cmd_output_search WHERE command_id = $1 FOR UPDATE", id).Scan(&tail)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	words, tail := splitSearchTail(append(tail, stdout...))
+
+	_, err = tx.Exec(ctx, "INSERT INTO cmd_output_search (command_id, document, tail) VALUES ($1, to_tsvector('simple', cmd_output_text($2)), $3)"+
+		" ON CONFLICT (command_id) DO UPDATE SET document = cmd_output_search.document || EXCLUDED.document, tail = EXCLUDED.tail", id, words, tail)
+
+	return err
+}
+
+// splitSearchTail splits data after its last whitespace. A tail longer than
+// maxSearchTail is cut at a rune boundary, such a word is indexed in parts.
+func splitSearchTail(data []byte) ([]byte, []byte) {
+	cut := bytes.LastIndexAny(data, " \t\r\n\v\f") + 1
+
+	if len(data)-cut > maxSearchTail {
+		cut = len(data) - maxSearchTail
+		for cut < len(data) && !utf8.RuneStart(data[cut]) {
+			cut++
+		}
+	}
+
+	return data[:cut], data[cut:]
+}
+
+func (r *bashrunRepository) UpdateOutputStats(ctx context.Context, id int, stats domain.OutputStats) error {
+	const logPrefix = "repository.UpdateOutputStats"
+
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, "UPDATE cmd SET output_bytes = $1, truncated = $2 WHERE command_id = $3", stats.Bytes, stats.Truncated, id)
 		if err != nil {
 			return err
 		}
@@ -77,20 +248,35 @@ func (r *bashrunRepository) UpdateOutput(ctx context.Context, id int, newOutputP
 	return nil
 }
 
-func (r *bashrunRepository) UpdateStatus(ctx context.Context, id int, newStatus string) error {
+func (r *bashrunRepository) UpdateStatus(ctx context.Context, id int, transition domain.StatusTransition) error {
 	const logPrefix = "repository.UpdateStatus"
 
+	if !transition.From.CanTransitionTo(transition.To) {
+		return fmt.Errorf("%s: %w", logPrefix, appErrors.ErrWrongTransition)
+	}
+
 	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
-		tag, err := tx.Exec(ctx, "UPDATE cmd SET processing_status = $1 WHERE command_id = $2", newStatus, id)
+		tag, err := tx.Exec(ctx, "UPDATE cmd SET processing_status = $1, error = $2 WHERE command_id = $3 AND processing_status = $4",
+			transition.To, transition.Error, id, transition.From)
 		if err != nil {
 			return err
 		}
 
-		if tag.RowsAffected() == 0 {
+		if tag.RowsAffected() > 0 {
+			return nil
+		}
+
+		var exists bool
+		err = tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM cmd WHERE command_id = $1)", id).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
 			return appErrors.ErrRowsNotAffected
 		}
 
-		return nil
+		return appErrors.ErrStatusConflict
 	})
 
 	if err != nil {
@@ -146,12 +332,237 @@ func (r *bashrunRepository) UpdateExitStatus(ctx context.Context, id int, exitSt
 	return nil
 }
 
-func (r *bashrunRepository) ListCommands(ctx context.Context, limit int, offset int) ([]domain.CommandFromDB, error) {
+func (r *bashrunRepository) UpdateStartTime(ctx context.Context, id int, startedAt time.Time, queued time.Duration) error {
+	const logPrefix = "repository.UpdateStartTime"
+
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, "UPDATE cmd SET started_at = $1, queued_ms = $2 WHERE command_id = $3", startedAt, queued.Milliseconds(), id)
+		if err != nil {
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return appErrors.ErrRowsNotAffected
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return nil
+}
+
+func (r *bashrunRepository) UpdateExecutionStats(ctx context.Context, id int, stats domain.ExecutionStats) error {
+	const logPrefix = "repository.UpdateExecutionStats"
+
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, "UPDATE cmd SET finished_at = $1, duration_ms = $2, user_cpu_ms = $3, system_cpu_ms = $4 WHERE command_id = $5",
+			stats.FinishedAt, stats.Duration.Milliseconds(), stats.UserCPU.Milliseconds(), stats.SystemCPU.Milliseconds(), id)
+		if err != nil {
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return appErrors.ErrRowsNotAffected
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return nil
+}
+
+type queryBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+func (b *queryBuilder) addArg(arg interface{}) string {
+	b.args = append(b.args, arg)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+func (b *queryBuilder) addCondition(condition string, arg interface{}) {
+	b.conditions = append(b.conditions, fmt.Sprintf(condition, b.addArg(arg)))
+}
+
+func (b *queryBuilder) where() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+func filterConditions(filter domain.CommandFilter) *queryBuilder {
+	b := &queryBuilder{}
+
+	if filter.Status != "" {
+		b.addCondition("processing_status = %s", string(filter.Status))
+	}
+
+	if filter.ExitStatus != nil {
+		b.addCondition("exit_status = %s", *filter.ExitStatus)
+	}
+
+	if filter.CreatedFrom != nil {
+		b.addCondition("created_at >= %s", *filter.CreatedFrom)
+	}
+
+	if filter.CreatedTo != nil {
+		b.addCondition("created_at <= %s", *filter.CreatedTo)
+	}
+
+	if filter.Command != "" {
+		b.addCondition("strpos(command, %s) > 0", filter.Command)
+	}
+
+	if filter.CommandRegex != "" {
+		b.addCondition("command ~ %s", filter.CommandRegex)
+	}
+
+	if filter.Label != "" {
+		b.addCondition("labels @> ARRAY[%s]::TEXT[]", filter.Label)
+	}
+
+	if filter.ScheduleID != nil {
+		b.addCondition("schedule_id = %s", *filter.ScheduleID)
+	}
+
+	if filter.WorkflowID != nil {
+		b.addCondition("workflow_id = %s", *filter.WorkflowID)
+	}
+
+	if filter.PipelineID != nil {
+		b.addCondition("pipeline_id = %s", *filter.PipelineID)
+	}
+
+	if filter.Search != "" {
+		// the whole stdout is searched, a word split between chunks is found too
+		b.addCondition(outputOwner+" IN (SELECT command_id FROM cmd_output_search WHERE "+searchDocument+" @@ plainto_tsquery('simple', %s))", filter.Search)
+	}
+
+	return b
+}
+
+func (b *queryBuilder) addCursorCondition(column string, cursor domain.Cursor, ascending bool, nullsLast bool) {
+	cmp := "<"
+	if ascending {
+		cmp = ">"
+	}
+
+	id := b.addArg(cursor.ID)
+	if column == sortColumns[domain.SortByID] {
+		b.conditions = append(b.conditions, fmt.Sprintf("command_id %s %s", cmp, id))
+		return
+	}
+
+	var value interface{}
+	switch cursor.SortBy {
+	case domain.SortByCreatedAt:
+		if cursor.CreatedAt != nil {
+			value = *cursor.CreatedAt
+		}
+	case domain.SortByDuration:
+		if cursor.DurationMS != nil {
+			value = *cursor.DurationMS
+		}
+	}
+
+	if value == nil {
+		if nullsLast {
+			b.conditions = append(b.conditions, fmt.Sprintf("(%s IS NULL AND command_id %s %s)", column, cmp, id))
+		} else {
+			b.conditions = append(b.conditions, fmt.Sprintf("(%s IS NOT NULL OR command_id %s %s)", column, cmp, id))
+		}
+
+		return
+	}
+
+	v := b.addArg(value)
+	condition := fmt.Sprintf("%s %s %s OR (%s = %s AND command_id %s %s)", column, cmp, v, column, v, cmp, id)
+	if nullsLast {
+		condition += fmt.Sprintf(" OR %s IS NULL", column)
+	}
+
+	b.conditions = append(b.conditions, "("+condition+")")
+}
+
+func listCommandsQuery(filter domain.CommandFilter) (string, []interface{}) {
+	b := filterConditions(filter)
+
+	sortColumn, ok := sortColumns[filter.SortBy]
+	if !ok {
+		sortColumn = sortColumns[domain.SortByID]
+	}
+
+	ascending := !filter.Descending
+	nullsLast := true
+	if filter.Backward {
+		ascending = !ascending
+		nullsLast = false
+	}
+
+	if filter.Cursor != nil {
+		b.addCursorCondition(sortColumn, *filter.Cursor, ascending, nullsLast)
+	}
+
+	direction := "DESC"
+	if ascending {
+		direction = "ASC"
+	}
+
+	nulls := "NULLS FIRST"
+	if nullsLast {
+		nulls = "NULLS LAST"
+	}
+
+	columns := commandColumns
+	if filter.OmitOutput {
+		columns = listColumns
+	}
+
+	query := "SELECT " + columns + " FROM cmd" + b.where() + " ORDER BY " + sortColumn + " " + direction
+	if sortColumn != sortColumns[domain.SortByID] {
+		query += " " + nulls + ", command_id " + direction
+	}
+
+	limit := b.addArg(filter.Limit)
+	offset := b.addArg(filter.Offset)
+	query += " LIMIT " + limit + " OFFSET " + offset
+
+	return query, b.args
+}
+
+func (r *bashrunRepository) CountCommands(ctx context.Context, filter domain.CommandFilter) (int, error) {
+	const logPrefix = "repository.CountCommands"
+
+	b := filterConditions(filter)
+
+	var count int
+	err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM cmd"+b.where(), b.args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", logPrefix, wrapRegexError(err))
+	}
+
+	return count, nil
+}
+
+func (r *bashrunRepository) ListCommands(ctx context.Context, filter domain.CommandFilter) ([]domain.CommandFromDB, error) {
 	const logPrefix = "repository.ListCommands"
 
-	rows, err := r.db.Query(ctx, "SELECT command_id, command, pid, output_text, processing_status, exit_status FROM cmd ORDER BY command_id ASC LIMIT $1 OFFSET $2", limit, offset)
+	query, args := listCommandsQuery(filter)
+
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+		return nil, fmt.Errorf("%s: %w", logPrefix, wrapRegexError(err))
 	}
 	defer rows.Close()
 
@@ -159,7 +570,7 @@ func (r *bashrunRepository) ListCommands(ctx context.Context, limit int, offset
 	for rows.Next() {
 		var command domain.CommandFromDB
 
-		err = rows.Scan(&command.ID, &command.Command, &command.PID, &command.Output, &command.Status, &command.ExitStatus)
+		err = scanCommand(rows, &command)
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", logPrefix, err)
 		}
@@ -167,6 +578,10 @@ func (r *bashrunRepository) ListCommands(ctx context.Context, limit int, offset
 		commands = append(commands, command)
 	}
 
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, wrapRegexError(err))
+	}
+
 	if len(commands) == 0 {
 		return nil, appErrors.ErrNoRows
 	}
@@ -174,10 +589,19 @@ func (r *bashrunRepository) ListCommands(ctx context.Context, limit int, offset
 	return commands, nil
 }
 
-func (r *bashrunRepository) ReadStatus(ctx context.Context, id int) (string, error) {
+func wrapRegexError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == invalidRegularExpressionCode {
+		return fmt.Errorf("%w: %w", appErrors.ErrWrongCommandRegex, err)
+	}
+
+	return err
+}
+
+func (r *bashrunRepository) ReadStatus(ctx context.Context, id int) (domain.CommandStatus, error) {
 	const logPrefix = "repository.ReadStatus"
 
-	var status string
+	var status domain.CommandStatus
 	err := r.db.QueryRow(ctx, "SELECT processing_status FROM cmd WHERE command_id = $1", id).Scan(&status)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -190,50 +614,246 @@ func (r *bashrunRepository) ReadStatus(ctx context.Context, id int) (string, err
 	return status, nil
 }
 
-func (r *bashrunRepository) ReadPID(ctx context.Context, id int) (int, error) {
-	const logPrefix = "repository.ReadPID"
+func (r *bashrunRepository) ReadCommand(ctx context.Context, id int) (domain.CommandFromDB, error) {
+	const logPrefix = "repository.ReadCommand"
 
-	var pid int
-	err := r.db.QueryRow(ctx, "SELECT pid FROM cmd WHERE command_id = $1", id).Scan(&pid)
+	var command domain.CommandFromDB
+	err := scanCommand(r.db.QueryRow(ctx, "SELECT "+commandColumns+" FROM cmd WHERE command_id = $1", id), &command)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return 0, appErrors.ErrNoRows
+			return domain.CommandFromDB{}, appErrors.ErrNoRows
 		}
 
-		return 0, fmt.Errorf("%s: %w", logPrefix, err)
+		return domain.CommandFromDB{}, fmt.Errorf("%s: %w", logPrefix, err)
 	}
 
-	return pid, nil
+	return command, nil
 }
 
-func (r *bashrunRepository) ReadCommand(ctx context.Context, id int) (domain.CommandFromDB, error) {
-	const logPrefix = "repository.ReadCommand"
+func (r *bashrunRepository) ReadOutput(ctx context.Context, id int, stream string, rng domain.OutputRange) (domain.OutputSlice, error) {
+	const logPrefix = "repository.ReadOutput"
 
-	var command domain.CommandFromDB
-	err := r.db.QueryRow(ctx, "SELECT command_id, command, pid, output_text, processing_status, exit_status FROM cmd WHERE command_id = $1", id).Scan(&command.ID, &command.Command, &command.PID, &command.Output, &command.Status, &command.ExitStatus)
+	err := r.db.QueryRow(ctx, "SELECT "+outputOwner+" FROM cmd WHERE command_id = $1", id).Scan(&id)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return domain.CommandFromDB{}, appErrors.ErrNoRows
+			return domain.OutputSlice{}, appErrors.ErrNoRows
 		}
 
-		return domain.CommandFromDB{}, fmt.Errorf("%s: %w", logPrefix, err)
+		return domain.OutputSlice{}, fmt.Errorf("%s: %w", logPrefix, err)
 	}
 
-	return command, nil
+	var slice domain.OutputSlice
+	query, args := outputSizeQuery(id, stream)
+	err = r.db.QueryRow(ctx, query, args...).Scan(&slice.Total)
+	if err != nil {
+		return domain.OutputSlice{}, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	if rng.Suffix > 0 {
+		rng.Offset = max(slice.Total-rng.Suffix, 0)
+	}
+
+	query, args = readOutputQuery(id, stream, rng)
+
+	switch {
+	case rng.Tail > 0:
+		err = r.readTail(ctx, query, args, rng, &slice)
+	case rng.Lines:
+		err = r.readLines(ctx, query, args, rng, &slice)
+	default:
+		err = r.readBytes(ctx, query, args, rng, &slice)
+	}
+
+	if err != nil {
+		return domain.OutputSlice{}, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return slice, nil
 }
 
-func (r *bashrunRepository) ReadOutput(ctx context.Context, id int) (string, error) {
-	const logPrefix = "repository.ReadOutput"
+func (r *bashrunRepository) readBytes(ctx context.Context, query string, args []interface{}, rng domain.OutputRange, slice *domain.OutputSlice) error {
+	var output strings.Builder
+	slice.Start = -1
 
-	var output string
-	err := r.db.QueryRow(ctx, "SELECT output_text FROM cmd WHERE command_id = $1", id).Scan(&output)
+	err := r.readChunks(ctx, query, args, func(data []byte, position int64) bool {
+		if slice.Start < 0 {
+			slice.Start = max(rng.Offset, position)
+		}
+
+		output.WriteString(sliceChunk(string(data), position, rng))
+		return true
+	})
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return "", appErrors.ErrNoRows
+		return err
+	}
+
+	if slice.Start < 0 {
+		slice.Start = rng.Offset
+	}
+
+	slice.Data = output.String()
+
+	return nil
+}
+
+func (r *bashrunRepository) readLines(ctx context.Context, query string, args []interface{}, rng domain.OutputRange, slice *domain.OutputSlice) error {
+	var output strings.Builder
+	var lines int64
+	start := int64(-1)
+
+	err := r.readChunks(ctx, query, args, func(data []byte, position int64) bool {
+		if start < 0 {
+			start = position
 		}
 
-		return "", fmt.Errorf("%s: %w", logPrefix, err)
+		output.Write(data)
+		lines += int64(bytes.Count(data, []byte("\n")))
+
+		return rng.Limit == 0 || lines < rng.Offset+rng.Limit
+	})
+	if err != nil {
+		return err
+	}
+
+	var skipped int64
+	slice.Data, skipped = selectLines(output.String(), rng.Offset, rng.Limit)
+	slice.Start = max(start, 0) + skipped
+
+	return nil
+}
+
+func (r *bashrunRepository) readTail(ctx context.Context, query string, args []interface{}, rng domain.OutputRange, slice *domain.OutputSlice) error {
+	var chunks [][]byte
+	var lines, start int64
+
+	err := r.readChunks(ctx, query, args, func(data []byte, position int64) bool {
+		chunks = append(chunks, data)
+		start = position
+		lines += int64(bytes.Count(data, []byte("\n")))
+
+		return lines <= rng.Tail
+	})
+	if err != nil {
+		return err
+	}
+
+	slices.Reverse(chunks)
+
+	var skipped int64
+	slice.Data, skipped = tailLines(string(bytes.Join(chunks, nil)), rng.Tail)
+	slice.Start = start + skipped
+
+	return nil
+}
+
+func (r *bashrunRepository) readChunks(ctx context.Context, query string, args []interface{}, read func(data []byte, position int64) bool) error {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data []byte
+		var position int64
+
+		err = rows.Scan(&data, &position)
+		if err != nil {
+			return err
+		}
+
+		if !read(data, position) {
+			return nil
+		}
+	}
+
+	return rows.Err()
+}
+
+func outputSizeQuery(id int, stream string) (string, []interface{}) {
+	if stream == domain.StreamCombined {
+		return "SELECT COALESCE(SUM(octet_length(data)), 0) FROM cmd_output WHERE command_id = $1", []interface{}{id}
+	}
+
+	return "SELECT COALESCE(MAX(stream_offset + octet_length(data)), 0) FROM cmd_output WHERE command_id = $1 AND stream = $2", []interface{}{id, stream}
+}
+
+func readOutputQuery(id int, stream string, rng domain.OutputRange) (string, []interface{}) {
+	b := &queryBuilder{}
+	b.addCondition("command_id = %s", id)
+
+	position := "SUM(octet_length(data)) OVER (ORDER BY seq) - octet_length(data)"
+	if stream != domain.StreamCombined {
+		b.addCondition("stream = %s", stream)
+		position = "stream_offset"
+	}
+
+	query := "SELECT data, position FROM (SELECT data, seq, " + position + " AS position FROM cmd_output" + b.where() + ") chunks"
+	if rng.Tail > 0 {
+		return query + " ORDER BY seq DESC", b.args
+	}
+
+	if rng.Lines {
+		return query + " ORDER BY seq", b.args
+	}
+
+	query += " WHERE position + octet_length(data) > " + b.addArg(rng.Offset)
+	if rng.Limit > 0 {
+		query += " AND position < " + b.addArg(rng.Offset+rng.Limit)
+	}
+
+	return query + " ORDER BY seq", b.args
+}
+
+func sliceChunk(data string, position int64, rng domain.OutputRange) string {
+	start := rng.Offset - position
+	if start < 0 {
+		start = 0
+	}
+
+	end := int64(len(data))
+	if rng.Limit > 0 && rng.Offset+rng.Limit-position < end {
+		end = rng.Offset + rng.Limit - position
+	}
+
+	return data[start:end]
+}
+
+func selectLines(data string, offset, limit int64) (string, int64) {
+	start := lineIndex(data, offset)
+
+	end := len(data)
+	if limit > 0 {
+		end = start + lineIndex(data[start:], limit)
+	}
+
+	return data[start:end], int64(start)
+}
+
+func lineIndex(data string, lines int64) int {
+	i := 0
+	for ; lines > 0; lines-- {
+		j := strings.IndexByte(data[i:], '\n')
+		if j < 0 {
+			return len(data)
+		}
+
+		i += j + 1
+	}
+
+	return i
+}
+
+func tailLines(data string, lines int64) (string, int64) {
+	i := len(strings.TrimSuffix(data, "\n"))
+	for ; lines > 0; lines-- {
+		j := strings.LastIndexByte(data[:i], '\n')
+		if j < 0 {
+			return data, 0
+		}
+
+		i = j
 	}
 
-	return output, nil
+	return data[i+1:], int64(i + 1)
 }
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/PoorMercymain/bashrun/errors"
+	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
+)
+
+func TestListCommandsQuery(t *testing.T) {
+	query, args := listCommandsQuery(domain.CommandFilter{Limit: 15})
+	require.Equal(t, "SELECT "+commandColumns+" FROM cmd ORDER BY command_id ASC LIMIT $1 OFFSET $2", query)
+	require.Equal(t, []interface{}{15, 0}, args)
+
+	exitStatus := 1
+	createdFrom := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	query, args = listCommandsQuery(domain.CommandFilter{
+		Limit:       10,
+		Offset:      20,
+		Status:      "done",
+		ExitStatus:  &exitStatus,
+		CreatedFrom: &createdFrom,
+		Label:       "nightly",
+		Search:      "error",
+		SortBy:      domain.SortByDuration,
+		Descending:  true,
+	})
+	require.Equal(t, "SELECT "+commandColumns+" FROM cmd WHERE processing_status = $1 AND exit_status = $2 AND created_at >= $3 AND labels @> ARRAY[$4]::TEXT[]"+
+		" AND "+outputOwner+" IN (SELECT command_id FROM cmd_output_search WHERE "+searchDocument+" @@ plainto_tsquery('simple', $5))"+
+		" ORDER BY duration_ms DESC NULLS LAST, command_id DESC LIMIT $6 OFFSET $7", query)
+	require.Equal(t, []interface{}{"done", 1, createdFrom, "nightly", "error", 10, 20}, args)
+
+	query, args = listCommandsQuery(domain.CommandFilter{Limit: 5, Command: "ls", CommandRegex: "^ls", SortBy: "unknown", OmitOutput: true})
+	require.Equal(t, "SELECT "+listColumns+" FROM cmd WHERE strpos(command, $1) > 0 AND command ~ $2 ORDER BY command_id ASC LIMIT $3 OFFSET $4", query)
+	require.Equal(t, []interface{}{"ls", "^ls", 5, 0}, args)
+
+	query, args = listCommandsQuery(domain.CommandFilter{Limit: 16, Cursor: &domain.Cursor{SortBy: domain.SortByID, ID: 7}})
+	require.Equal(t, "SELECT "+commandColumns+" FROM cmd WHERE command_id > $1 ORDER BY command_id ASC LIMIT $2 OFFSET $3", query)
+	require.Equal(t, []interface{}{7, 16, 0}, args)
+
+	durationMS := int64(300)
+	query, args = listCommandsQuery(domain.CommandFilter{Limit: 16, Status: "done", SortBy: domain.SortByDuration, Descending: true,
+		Cursor: &domain.Cursor{SortBy: domain.SortByDuration, Descending: true, ID: 7, DurationMS: &durationMS}})
+	require.Equal(t, "SELECT "+commandColumns+" FROM cmd WHERE processing_status = $1 AND (duration_ms < $3 OR (duration_ms = $3 AND command_id < $2) OR duration_ms IS NULL)"+
+		" ORDER BY duration_ms DESC NULLS LAST, command_id DESC LIMIT $4 OFFSET $5", query)
+	require.Equal(t, []interface{}{"done", 7, durationMS, 16, 0}, args)
+
+	query, args = listCommandsQuery(domain.CommandFilter{Limit: 16, SortBy: domain.SortByDuration, Backward: true,
+		Cursor: &domain.Cursor{SortBy: domain.SortByDuration, ID: 7}})
+	require.Equal(t, "SELECT "+commandColumns+" FROM cmd WHERE (duration_ms IS NOT NULL OR command_id < $1)"+
+		" ORDER BY duration_ms DESC NULLS FIRST, command_id DESC LIMIT $2 OFFSET $3", query)
+	require.Equal(t, []interface{}{7, 16, 0}, args)
+}
+
+func TestWrapRegexError(t *testing.T) {
+	err := wrapRegexError(fmt.Errorf("query: %w", &pgconn.PgError{Code: invalidRegularExpressionCode, Message: "invalid regular expression: parentheses () not balanced"}))
+	require.ErrorIs(t, err, appErrors.ErrWrongCommandRegex)
+
+	err = wrapRegexError(&pgconn.PgError{Code: "42P01"})
+	require.False(t, errors.Is(err, appErrors.ErrWrongCommandRegex))
+}
+
+func TestSplitSearchTail(t *testing.T) {
+	words, tail := splitSearchTail([]byte("first second\nthi"))
+	require.Equal(t, "first second\n", string(words))
+	require.Equal(t, "thi", string(tail))
+
+	words, tail = splitSearchTail([]byte("first "))
+	require.Equal(t, "first ", string(words))
+	require.Empty(t, tail)
+
+	long := []byte("ё" + strings.Repeat("a", maxSearchTail-1))
+	words, tail = splitSearchTail(long)
+	require.Equal(t, "ё", string(words))
+	require.Equal(t, strings.Repeat("a", maxSearchTail-1), string(tail))
+}
+
+func TestCommandSpec(t *testing.T) {
+	command := domain.CommandFromUser{Script: "#!/bin/sh\necho \"$1\"\n", ScriptArgs: []string{"a"}, Interpreter: "sh", Labels: []string{"nightly"}}
+
+	spec, err := marshalSpec(command)
+	require.NoError(t, err)
+
+	var restored domain.CommandFromUser
+	require.NoError(t, unmarshalSpec(spec, &restored))
+	require.Equal(t, command, restored)
+}
+
+func TestReadOutputQuery(t *testing.T) {
+	query, args := readOutputQuery(1, domain.StreamStdout, domain.OutputRange{})
+	require.Equal(t, "SELECT data, position FROM (SELECT data, seq, stream_offset AS position FROM cmd_output WHERE command_id = $1 AND stream = $2) chunks"+
+		" WHERE position + octet_length(data) > $3 ORDER BY seq", query)
+	require.Equal(t, []interface{}{1, domain.StreamStdout, int64(0)}, args)
+
+	query, args = readOutputQuery(1, domain.StreamCombined, domain.OutputRange{Offset: 10, Limit: 5})
+	require.Equal(t, "SELECT data, position FROM (SELECT data, seq, SUM(octet_length(data)) OVER (ORDER BY seq) - octet_length(data) AS position FROM cmd_output WHERE command_id = $1) chunks"+
+		" WHERE position + octet_length(data) > $2 AND position < $3 ORDER BY seq", query)
+	require.Equal(t, []interface{}{1, int64(10), int64(15)}, args)
+
+	query, args = readOutputQuery(1, domain.StreamStderr, domain.OutputRange{Tail: 10})
+	require.Equal(t, "SELECT data, position FROM (SELECT data, seq, stream_offset AS position FROM cmd_output WHERE command_id = $1 AND stream = $2) chunks ORDER BY seq DESC", query)
+	require.Equal(t, []interface{}{1, domain.StreamStderr}, args)
+
+	query, args = readOutputQuery(1, domain.StreamStdout, domain.OutputRange{Offset: 2, Limit: 3, Lines: true})
+	require.Equal(t, "SELECT data, position FROM (SELECT data, seq, stream_offset AS position FROM cmd_output WHERE command_id = $1 AND stream = $2) chunks ORDER BY seq", query)
+	require.Equal(t, []interface{}{1, domain.StreamStdout}, args)
+}
+
+func TestQueueQueries(t *testing.T) {
+	require.Equal(t, "UPDATE cmd SET claimed_at = now(), owner = $1, lease_expires_at = now() + make_interval(secs => $2) WHERE command_id = (SELECT command_id FROM cmd WHERE cmd.processing_status = 'created' AND cmd.claimed_at IS NULL AND cmd.retry_policy IS NULL"+
+		" AND (cmd.schedule_id IS NULL OR NOT EXISTS (SELECT 1 FROM cmd p WHERE p.schedule_id = cmd.schedule_id AND p.command_id < cmd.command_id AND p.command_id <> COALESCE(cmd.parent_id, 0) AND p.processing_status IN ('created', 'started')))"+
+		" ORDER BY priority DESC, command_id LIMIT 1 FOR UPDATE SKIP LOCKED) RETURNING "+commandSpecColumns, claimCommandQuery())
+
+	require.Equal(t, "SELECT (SELECT COUNT(*) FROM cmd q WHERE q.processing_status = 'created' AND q.claimed_at IS NULL AND q.retry_policy IS NULL"+
+		" AND (q.priority > c.priority OR (q.priority = c.priority AND q.command_id < c.command_id))) + 1"+
+		" FROM cmd c WHERE c.command_id = $1 AND c.processing_status = 'created' AND c.claimed_at IS NULL AND c.retry_policy IS NULL", queuePositionQuery())
+
+	require.Equal(t, "(owner = $2 OR lease_expires_at IS NULL OR lease_expires_at < now())", recoverableCondition("$2"))
+}
+
+func TestSliceChunk(t *testing.T) {
+	require.Equal(t, "abcdef", sliceChunk("abcdef", 0, domain.OutputRange{}))
+	require.Equal(t, "cdef", sliceChunk("abcdef", 0, domain.OutputRange{Offset: 2}))
+	require.Equal(t, "cd", sliceChunk("abcdef", 0, domain.OutputRange{Offset: 2, Limit: 2}))
+	require.Equal(t, "ab", sliceChunk("abcdef", 10, domain.OutputRange{Offset: 5, Limit: 7}))
+	require.Equal(t, "bcdef", sliceChunk("abcdef", 10, domain.OutputRange{Offset: 11}))
+}
+
+func TestSelectLines(t *testing.T) {
+	data, start := selectLines("a\nbb\nccc\nd", 0, 0)
+	require.Equal(t, "a\nbb\nccc\nd", data)
+	require.Equal(t, int64(0), start)
+
+	data, start = selectLines("a\nbb\nccc\nd", 1, 2)
+	require.Equal(t, "bb\nccc\n", data)
+	require.Equal(t, int64(2), start)
+
+	data, start = selectLines("a\nbb\nccc\nd", 3, 5)
+	require.Equal(t, "d", data)
+	require.Equal(t, int64(9), start)
+
+	data, start = selectLines("a\nbb\n", 5, 1)
+	require.Equal(t, "", data)
+	require.Equal(t, int64(5), start)
+}
+
+func TestTailLines(t *testing.T) {
+	data, start := tailLines("a\nbb\nccc\n", 2)
+	require.Equal(t, "bb\nccc\n", data)
+	require.Equal(t, int64(2), start)
+
+	data, start = tailLines("a\nbb\nccc", 1)
+	require.Equal(t, "ccc", data)
+	require.Equal(t, int64(5), start)
+
+	data, start = tailLines("a\nbb\n", 10)
+	require.Equal(t, "a\nbb\n", data)
+	require.Equal(t, int64(0), start)
+}
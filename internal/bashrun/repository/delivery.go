@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	appErrors "github.com/PoorMercymain/bashrun/errors"
+	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
+)
+
+const deliveryColumns = "delivery_id, command_id, url, payload, status, attempts, response_status, last_error, created_at, last_attempt_at, next_attempt_at, delivered_at"
+
+// missingDeliveriesQuery finds finished commands with a callback_url whose
+// delivery was not saved, for example because the service stopped right after
+// saving the final status.
+const missingDeliveriesQuery = "SELECT c.command_id, c.callback_url FROM cmd c WHERE c.callback_url <> ''" +
+	" AND c.processing_status IN ('done', 'stopped', 'timed_out', 'output_limit_exceeded', 'failed', 'lost')" +
+	" AND NOT EXISTS (SELECT 1 FROM cmd_delivery d WHERE d.command_id = c.command_id) ORDER BY c.command_id LIMIT $1"
+
+// CreateDelivery saves at most one delivery per command: the command row is
+// locked, so the delivery saved after a finish and the one saved by recovery
+// do not both get inserted.
+func (r *bashrunRepository) CreateDelivery(ctx context.Context, id int, url string, payload []byte) error {
+	const logPrefix = "repository.CreateDelivery"
+
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		var exists bool
+		err := tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM cmd_delivery WHERE command_id = $1) FROM cmd WHERE command_id = $1 FOR UPDATE", id).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			return nil
+		}
+
+		_, err = tx.Exec(ctx, "INSERT INTO cmd_delivery(command_id, url, payload) VALUES($1, $2, $3)", id, url, payload)
+		return err
+	})
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return nil
+}
+
+func (r *bashrunRepository) ListMissingDeliveries(ctx context.Context, limit int) ([]domain.Delivery, error) {
+	const logPrefix = "repository.ListMissingDeliveries"
+
+	rows, err := r.db.Query(ctx, missingDeliveriesQuery, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]domain.Delivery, 0)
+	for rows.Next() {
+		var delivery domain.Delivery
+		err = rows.Scan(&delivery.CommandID, &delivery.URL)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", logPrefix, err)
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *bashrunRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.Delivery, error) {
+	const logPrefix = "repository.ClaimDeliveries"
+
+	deliveries := make([]domain.Delivery, 0)
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, "UPDATE cmd_delivery d SET next_attempt_at = now() + make_interval(secs => $1) FROM cmd c"+
+			" WHERE c.command_id = d.command_id AND d.delivery_id IN (SELECT delivery_id FROM cmd_delivery WHERE status = 'pending' AND next_attempt_at <= now()"+
+			" ORDER BY next_attempt_at LIMIT $2 FOR UPDATE SKIP LOCKED)"+
+			" RETURNING d.delivery_id, d.command_id, d.url, d.payload, d.status, d.attempts, c.callback_secret", lease.Seconds(), limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var delivery domain.Delivery
+			err = rows.Scan(&delivery.ID, &delivery.CommandID, &delivery.URL, &delivery.Payload, &delivery.Status, &delivery.Attempts, &delivery.Secret)
+			if err != nil {
+				return err
+			}
+
+			deliveries = append(deliveries, delivery)
+		}
+
+		return rows.Err()
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *bashrunRepository) UpdateDelivery(ctx context.Context, deliveryID int64, attempt domain.DeliveryAttempt) error {
+	const logPrefix = "repository.UpdateDelivery"
+
+	var deliveredAt *time.Time
+	if attempt.Status == domain.DeliveryStatusDelivered {
+		deliveredAt = &attempt.AttemptedAt
+	}
+
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, "UPDATE cmd_delivery SET status = $1, attempts = attempts + 1, response_status = $2, last_error = $3, last_attempt_at = $4, next_attempt_at = $5, delivered_at = $6 WHERE delivery_id = $7",
+			attempt.Status, attempt.ResponseStatus, attempt.Error, attempt.AttemptedAt, attempt.NextAttemptAt, deliveredAt, deliveryID)
+		if err != nil {
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return appErrors.ErrRowsNotAffected
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return nil
+}
+
+func (r *bashrunRepository) ListDeliveries(ctx context.Context, id int) ([]domain.Delivery, error) {
+	const logPrefix = "repository.ListDeliveries"
+
+	var exists bool
+	err := r.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM cmd WHERE command_id = $1)", id).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	if !exists {
+		return nil, appErrors.ErrNoRows
+	}
+
+	rows, err := r.db.Query(ctx, "SELECT "+deliveryColumns+" FROM cmd_delivery WHERE command_id = $1 ORDER BY delivery_id", id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]domain.Delivery, 0)
+	for rows.Next() {
+		var delivery domain.Delivery
+		err = rows.Scan(&delivery.ID, &delivery.CommandID, &delivery.URL, &delivery.Payload, &delivery.Status, &delivery.Attempts, &delivery.ResponseStatus,
+			&delivery.LastError, &delivery.CreatedAt, &delivery.LastAttemptAt, &delivery.NextAttemptAt, &delivery.DeliveredAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", logPrefix, err)
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return deliveries, nil
+}
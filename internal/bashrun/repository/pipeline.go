@@ -0,0 +1,314 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	appErrors "github.com/PoorMercymain/bashrun/errors"
+	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
+)
+
+const pipelineColumns = "pipeline_id, name, continue_on_error, status, created_at, finished_at"
+
+func (r *bashrunRepository) CreatePipeline(ctx context.Context, pipeline domain.PipelineFromUser, createdAt time.Time) (int, domain.PipelineAdvance, error) {
+	const logPrefix = "repository.CreatePipeline"
+
+	var id int
+	var advance domain.PipelineAdvance
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, "INSERT INTO cmd_pipeline(name, continue_on_error, created_at) VALUES($1, $2, $3) RETURNING pipeline_id",
+			pipeline.Name, pipeline.ContinueOnError, createdAt).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		for i, step := range pipeline.Steps {
+			var stdin []byte
+			if step.Command.Stdin != "" {
+				stdin = []byte(step.Command.Stdin)
+			}
+
+			spec := step.Command
+			spec.Stdin = ""
+
+			specJSON, err := marshalSpec(spec)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec(ctx, "INSERT INTO cmd_pipeline_step(pipeline_id, position, name, input, input_env, spec, stdin) VALUES($1, $2, $3, $4, $5, $6, $7)",
+				id, i, step.Name, step.Input, step.InputEnv, specJSON, stdin)
+			if err != nil {
+				return err
+			}
+		}
+
+		advance, err = advancePipeline(ctx, tx, id, createdAt)
+		return err
+	})
+
+	if err != nil {
+		return 0, domain.PipelineAdvance{}, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return id, advance, nil
+}
+
+// AdvancePipeline starts the step after a finished one, passing it the stdout
+// of the previous step if requested. The pipeline row is locked, so a step is
+// not started twice when the service is notified about a finish more than once.
+func (r *bashrunRepository) AdvancePipeline(ctx context.Context, id int, now time.Time) (domain.PipelineAdvance, error) {
+	const logPrefix = "repository.AdvancePipeline"
+
+	var advance domain.PipelineAdvance
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		var err error
+		advance, err = advancePipeline(ctx, tx, id, now)
+		return err
+	})
+
+	if err != nil {
+		return domain.PipelineAdvance{}, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return advance, nil
+}
+
+func advancePipeline(ctx context.Context, tx pgx.Tx, id int, now time.Time) (domain.PipelineAdvance, error) {
+	pipeline, err := readPipeline(ctx, tx, id, "SELECT "+pipelineColumns+" FROM cmd_pipeline WHERE pipeline_id = $1 FOR UPDATE")
+	if err != nil {
+		return domain.PipelineAdvance{}, err
+	}
+
+	advance := domain.PipelineAdvance{Status: pipeline.Status}
+	if pipeline.Status != domain.WorkflowStatusRunning {
+		return advance, nil
+	}
+
+	for advance.Started == nil {
+		run, skip := pipeline.Advance()
+
+		for _, i := range skip {
+			_, err = tx.Exec(ctx, "UPDATE cmd_pipeline_step SET skipped = true WHERE pipeline_id = $1 AND position = $2", id, i)
+			if err != nil {
+				return domain.PipelineAdvance{}, err
+			}
+		}
+
+		if run < 0 {
+			break
+		}
+
+		advance.Started, err = startPipelineStep(ctx, tx, &pipeline, run, now)
+		if err != nil {
+			return domain.PipelineAdvance{}, err
+		}
+	}
+
+	advance.Status = pipeline.AggregateStatus()
+	if advance.Status != domain.WorkflowStatusRunning {
+		_, err = tx.Exec(ctx, "UPDATE cmd_pipeline SET status = $1, finished_at = $2 WHERE pipeline_id = $3", advance.Status, now, id)
+		if err != nil {
+			return domain.PipelineAdvance{}, err
+		}
+	}
+
+	return advance, nil
+}
+
+// startPipelineStep creates the command of a step. A step that can not get
+// the stdout of the previous step in its environment is failed right away
+// and nil is returned, so the pipeline goes on as after any failed step.
+func startPipelineStep(ctx context.Context, tx pgx.Tx, pipeline *domain.Pipeline, run int, now time.Time) (*domain.StartedCommand, error) {
+	step := pipeline.Steps[run]
+	command := step.Command
+	command.CreatedAt = now
+
+	var inputError string
+	if step.Input != "" {
+		stdout, err := readStdout(ctx, tx, *pipeline.Steps[run-1].CommandID)
+		if err != nil {
+			return nil, err
+		}
+
+		switch step.Input {
+		case domain.PipelineInputStdin:
+			command.Stdin = string(stdout)
+		case domain.PipelineInputEnv:
+			inputError = domain.EnvInputError(step.InputEnv, stdout)
+			if inputError != "" {
+				break
+			}
+
+			env := make(map[string]string, len(command.Env)+1)
+			for key, value := range command.Env {
+				env[key] = value
+			}
+
+			env[step.InputEnv] = string(stdout)
+			command.Env = env
+		}
+	}
+
+	commandID, err := insertCommand(ctx, tx, command, commandOwner{PipelineID: &pipeline.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE cmd_pipeline_step SET command_id = $1 WHERE pipeline_id = $2 AND position = $3", commandID, pipeline.ID, run)
+	if err != nil {
+		return nil, err
+	}
+
+	if inputError != "" {
+		_, err = tx.Exec(ctx, "UPDATE cmd SET processing_status = $1, error = $2, finished_at = $3 WHERE command_id = $4", domain.StatusFailed, inputError, now, commandID)
+		if err != nil {
+			return nil, err
+		}
+
+		pipeline.Steps[run].Status, pipeline.Steps[run].CommandID = string(domain.StatusFailed), &commandID
+		return nil, nil
+	}
+
+	return &domain.StartedCommand{ID: commandID, Status: command.InitialStatus()}, nil
+}
+
+// readStdout reads the saved stdout of a command as is, for a command with
+// retries it is the stdout of the latest attempt.
+func readStdout(ctx context.Context, tx pgx.Tx, id int) ([]byte, error) {
+	var stdout []byte
+	err := tx.QueryRow(ctx, "SELECT string_agg(o.data, ''::BYTEA ORDER BY o.seq) FROM cmd_output o"+
+		" WHERE o.command_id = (SELECT "+outputOwner+" FROM cmd WHERE cmd.command_id = $1) AND o.stream = 'stdout'", id).Scan(&stdout)
+
+	return stdout, err
+}
+
+func scanPipeline(row pgx.Row, pipeline *domain.Pipeline) error {
+	return row.Scan(&pipeline.ID, &pipeline.Name, &pipeline.ContinueOnError, &pipeline.Status, &pipeline.CreatedAt, &pipeline.FinishedAt)
+}
+
+// readPipeline reads a pipeline with its steps, the status of a started step
+// is the status of its command.
+func readPipeline(ctx context.Context, q querier, id int, query string) (domain.Pipeline, error) {
+	var pipeline domain.Pipeline
+	err := scanPipeline(q.QueryRow(ctx, query, id), &pipeline)
+	if err != nil {
+		return domain.Pipeline{}, err
+	}
+
+	rows, err := q.Query(ctx, "SELECT s.name, s.input, s.input_env, s.spec, s.stdin, s.skipped, s.command_id, c.processing_status, c.exit_status"+
+		" FROM cmd_pipeline_step s LEFT JOIN cmd c ON c.command_id = s.command_id WHERE s.pipeline_id = $1 ORDER BY s.position", id)
+	if err != nil {
+		return domain.Pipeline{}, err
+	}
+	defer rows.Close()
+
+	pipeline.Steps = make([]domain.PipelineStep, 0)
+	for rows.Next() {
+		var step domain.PipelineStep
+		var spec, stdin []byte
+		var skipped bool
+		var status *string
+		err = rows.Scan(&step.Name, &step.Input, &step.InputEnv, &spec, &stdin, &skipped, &step.CommandID, &status, &step.ExitStatus)
+		if err != nil {
+			return domain.Pipeline{}, err
+		}
+
+		err = unmarshalSpec(spec, &step.Command)
+		if err != nil {
+			return domain.Pipeline{}, err
+		}
+
+		step.Command.Stdin = string(stdin)
+
+		switch {
+		case skipped:
+			step.Status = domain.NodeStatusSkipped
+		case status != nil:
+			step.Status = *status
+		default:
+			step.Status = domain.NodeStatusPending
+		}
+
+		pipeline.Steps = append(pipeline.Steps, step)
+	}
+
+	if err = rows.Err(); err != nil {
+		return domain.Pipeline{}, err
+	}
+
+	return pipeline, nil
+}
+
+func (r *bashrunRepository) ReadPipeline(ctx context.Context, id int) (domain.Pipeline, error) {
+	const logPrefix = "repository.ReadPipeline"
+
+	pipeline, err := readPipeline(ctx, r.db, id, "SELECT "+pipelineColumns+" FROM cmd_pipeline WHERE pipeline_id = $1")
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Pipeline{}, appErrors.ErrNoRows
+		}
+
+		return domain.Pipeline{}, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return pipeline, nil
+}
+
+func (r *bashrunRepository) ListPipelines(ctx context.Context) ([]domain.Pipeline, error) {
+	const logPrefix = "repository.ListPipelines"
+
+	rows, err := r.db.Query(ctx, "SELECT "+pipelineColumns+" FROM cmd_pipeline ORDER BY pipeline_id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+	defer rows.Close()
+
+	pipelines := make([]domain.Pipeline, 0)
+	for rows.Next() {
+		var pipeline domain.Pipeline
+		err = scanPipeline(rows, &pipeline)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", logPrefix, err)
+		}
+
+		pipelines = append(pipelines, pipeline)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return pipelines, nil
+}
+
+func (r *bashrunRepository) ListRunningPipelines(ctx context.Context) ([]int, error) {
+	const logPrefix = "repository.ListRunningPipelines"
+
+	rows, err := r.db.Query(ctx, "SELECT pipeline_id FROM cmd_pipeline WHERE status = $1 ORDER BY pipeline_id", domain.WorkflowStatusRunning)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", logPrefix, err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	appErrors "github.com/PoorMercymain/bashrun/errors"
+	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
+)
+
+const commandSpecColumns = "command_id, command, script, script_args, interpreter, argv, timeout_seconds, kill_grace_seconds, env, workdir, clear_env, stdin, created_at, labels, max_output_bytes, output_policy, callback_url, callback_secret, priority, run_at, parent_id, workflow_id, pipeline_id"
+
+func queuedCondition(table string) string {
+	return table + ".processing_status = 'created' AND " + table + ".claimed_at IS NULL AND " + table + ".retry_policy IS NULL"
+}
+
+// scheduleFreeCondition keeps runs of one schedule from overlapping: a run
+// is claimable only when no earlier run of the schedule is pending or running.
+// The parent of a retried run is skipped, it stays active between attempts.
+func scheduleFreeCondition(table string) string {
+	return "(" + table + ".schedule_id IS NULL OR NOT EXISTS (SELECT 1 FROM cmd p WHERE p.schedule_id = " + table + ".schedule_id AND p.command_id < " + table + ".command_id" +
+		" AND p.command_id <> COALESCE(" + table + ".parent_id, 0) AND p.processing_status IN ('created', 'started')))"
+}
+
+// claimCommandQuery takes the next queued command and leases it to the
+// instance, the lease is renewed while the instance runs the command.
+func claimCommandQuery() string {
+	return "UPDATE cmd SET claimed_at = now(), owner = $1, lease_expires_at = now() + make_interval(secs => $2) WHERE command_id = (SELECT command_id FROM cmd WHERE " + queuedCondition("cmd") + " AND " + scheduleFreeCondition("cmd") +
+		" ORDER BY priority DESC, command_id LIMIT 1 FOR UPDATE SKIP LOCKED) RETURNING " + commandSpecColumns
+}
+
+func queuePositionQuery() string {
+	return "SELECT (SELECT COUNT(*) FROM cmd q WHERE " + queuedCondition("q") + " AND (q.priority > c.priority OR (q.priority = c.priority AND q.command_id < c.command_id))) + 1" +
+		" FROM cmd c WHERE c.command_id = $1 AND " + queuedCondition("c")
+}
+
+func scanQueuedCommand(row pgx.Row, queued *domain.QueuedCommand) error {
+	command := &queued.Command
+
+	var stdin []byte
+	err := row.Scan(&queued.ID, &command.Command, &command.Script, &command.ScriptArgs, &command.Interpreter, &command.Argv, &command.TimeoutSeconds, &command.KillGraceSeconds,
+		&command.Env, &command.Workdir, &command.ClearEnv, &stdin, &command.CreatedAt, &command.Labels, &command.MaxOutputBytes, &command.OutputPolicy,
+		&command.CallbackURL, &command.CallbackSecret, &command.Priority, &command.RunAt, &queued.ParentID, &queued.WorkflowID, &queued.PipelineID)
+	if err != nil {
+		return err
+	}
+
+	command.Stdin = string(stdin)
+
+	return nil
+}
+
+func (r *bashrunRepository) ClaimCommand(ctx context.Context, owner string, lease time.Duration) (domain.QueuedCommand, error) {
+	const logPrefix = "repository.ClaimCommand"
+
+	var queued domain.QueuedCommand
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		return scanQueuedCommand(tx.QueryRow(ctx, claimCommandQuery(), owner, lease.Seconds()), &queued)
+	})
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.QueuedCommand{}, appErrors.ErrNoRows
+		}
+
+		return domain.QueuedCommand{}, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return queued, nil
+}
+
+// RequeueCommand returns a claimed command to the queue if it is still leased
+// to the owner or its lease has expired, so a command claimed again by
+// another instance in the meantime is left as is.
+func (r *bashrunRepository) RequeueCommand(ctx context.Context, id int, owner string) error {
+	const logPrefix = "repository.RequeueCommand"
+
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, "UPDATE cmd SET claimed_at = NULL, owner = NULL, lease_expires_at = NULL WHERE command_id = $1 AND processing_status = 'created' AND "+
+			recoverableCondition("$2"), id, owner)
+		if err != nil {
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return appErrors.ErrRowsNotAffected
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return nil
+}
+
+// RenewLeases extends the leases of the commands claimed by the owner and not
+// finished yet.
+func (r *bashrunRepository) RenewLeases(ctx context.Context, owner string, lease time.Duration) error {
+	const logPrefix = "repository.RenewLeases"
+
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "UPDATE cmd SET lease_expires_at = now() + make_interval(secs => $1) WHERE owner = $2 AND processing_status IN ($3, $4) AND claimed_at IS NOT NULL",
+			lease.Seconds(), owner, domain.StatusCreated, domain.StatusStarted)
+		return err
+	})
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return nil
+}
+
+// RequestStop leaves a stop request for the instance running the command,
+// only that instance can signal the process.
+func (r *bashrunRepository) RequestStop(ctx context.Context, id int, signal int) error {
+	const logPrefix = "repository.RequestStop"
+
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, "UPDATE cmd SET stop_signal = $1 WHERE command_id = $2 AND processing_status = $3", signal, id, domain.StatusStarted)
+		if err != nil {
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return appErrors.ErrRowsNotAffected
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return nil
+}
+
+// ClaimStopRequests takes the stop requests left for the commands of the
+// owner, every request is returned once.
+func (r *bashrunRepository) ClaimStopRequests(ctx context.Context, owner string) ([]domain.StopRequest, error) {
+	const logPrefix = "repository.ClaimStopRequests"
+
+	requests := make([]domain.StopRequest, 0)
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, "UPDATE cmd c SET stop_signal = NULL FROM (SELECT command_id, stop_signal FROM cmd WHERE owner = $1 AND stop_signal IS NOT NULL FOR UPDATE) r"+
+			" WHERE c.command_id = r.command_id RETURNING c.command_id, r.stop_signal", owner)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var request domain.StopRequest
+			err = rows.Scan(&request.ID, &request.Signal)
+			if err != nil {
+				return err
+			}
+
+			requests = append(requests, request)
+		}
+
+		return rows.Err()
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return requests, nil
+}
+
+func (r *bashrunRepository) ReadQueuePosition(ctx context.Context, id int) (*int, error) {
+	const logPrefix = "repository.ReadQueuePosition"
+
+	var position int
+	err := r.db.QueryRow(ctx, queuePositionQuery(), id).Scan(&position)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return &position, nil
+}
+
+// PromoteScheduledCommands moves due delayed commands to the queue. The update
+// is atomic, so with several instances every command is promoted once.
+func (r *bashrunRepository) PromoteScheduledCommands(ctx context.Context, now time.Time) ([]int, error) {
+	const logPrefix = "repository.PromoteScheduledCommands"
+
+	var ids []int
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, "UPDATE cmd SET processing_status = $1 WHERE processing_status = $2 AND run_at <= $3 RETURNING command_id",
+			domain.StatusCreated, domain.StatusScheduled, now)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id int
+			err = rows.Scan(&id)
+			if err != nil {
+				return err
+			}
+
+			ids = append(ids, id)
+		}
+
+		return rows.Err()
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
+)
+
+const activeCommandColumns = "command_id, processing_status, COALESCE(pid, 0), GREATEST(created_at, run_at), started_at, callback_url, parent_id, workflow_id, pipeline_id, COALESCE(owner, '')"
+
+// recoverableCondition matches a claimed command that the given owner may
+// recover: its own one or one whose lease was not renewed in time. Commands
+// claimed before leases were added have no lease and are recoverable too.
+func recoverableCondition(owner string) string {
+	return "(owner = " + owner + " OR lease_expires_at IS NULL OR lease_expires_at < now())"
+}
+
+// ListActiveCommands returns the claimed commands that are not finished yet
+// and are owned by the given instance or have an expired lease.
+func (r *bashrunRepository) ListActiveCommands(ctx context.Context, owner string) ([]domain.ActiveCommand, error) {
+	const logPrefix = "repository.ListActiveCommands"
+
+	commands, err := r.listActiveCommands(ctx, recoverableCondition("$3"), owner)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return commands, nil
+}
+
+// ListExpiredCommands returns the claimed commands that are not finished yet
+// and whose lease has expired, except the ones owned by the given instance.
+func (r *bashrunRepository) ListExpiredCommands(ctx context.Context, owner string) ([]domain.ActiveCommand, error) {
+	const logPrefix = "repository.ListExpiredCommands"
+
+	commands, err := r.listActiveCommands(ctx, "owner IS DISTINCT FROM $3 AND (lease_expires_at IS NULL OR lease_expires_at < now())", owner)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return commands, nil
+}
+
+func (r *bashrunRepository) listActiveCommands(ctx context.Context, condition string, owner string) ([]domain.ActiveCommand, error) {
+	rows, err := r.db.Query(ctx, "SELECT "+activeCommandColumns+" FROM cmd WHERE processing_status IN ($1, $2) AND retry_policy IS NULL AND claimed_at IS NOT NULL AND "+condition+" ORDER BY command_id",
+		domain.StatusCreated, domain.StatusStarted, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	commands := make([]domain.ActiveCommand, 0)
+	for rows.Next() {
+		var command domain.ActiveCommand
+		err = rows.Scan(&command.ID, &command.Status, &command.PID, &command.CreatedAt, &command.StartedAt, &command.CallbackURL, &command.ParentID, &command.WorkflowID, &command.PipelineID, &command.Owner)
+		if err != nil {
+			return nil, err
+		}
+
+		commands = append(commands, command)
+	}
+
+	return commands, rows.Err()
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	appErrors "github.com/PoorMercymain/bashrun/errors"
+	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
+)
+
+const attemptSpecColumns = "command, script, script_args, interpreter, argv, timeout_seconds, kill_grace_seconds, env, workdir, clear_env, stdin, labels, max_output_bytes, output_policy, priority, schedule_id"
+
+// insertAttempt copies the spec of the parent into a new attempt. Callbacks
+// belong to the parent, so the attempt gets none. Nothing is inserted when
+// the parent is already final or the attempt exists.
+func insertAttempt(ctx context.Context, tx pgx.Tx, attempt domain.NewAttempt) (int, error) {
+	var id int
+	err := tx.QueryRow(ctx, "INSERT INTO cmd("+attemptSpecColumns+", created_at, run_at, processing_status, parent_id, attempt)"+
+		" SELECT "+attemptSpecColumns+", $1, $2, $3, command_id, $4 FROM cmd WHERE command_id = $5 AND processing_status IN ($6, $7, $8)"+
+		" ON CONFLICT (parent_id, attempt) WHERE parent_id IS NOT NULL DO NOTHING RETURNING command_id",
+		attempt.CreatedAt, attempt.RunAt, attempt.InitialStatus(), attempt.Number, attempt.ParentID, domain.StatusScheduled, domain.StatusCreated, domain.StatusStarted).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, appErrors.ErrRowsNotAffected
+	}
+
+	return id, err
+}
+
+func (r *bashrunRepository) CreateAttempt(ctx context.Context, attempt domain.NewAttempt) (int, error) {
+	const logPrefix = "repository.CreateAttempt"
+
+	var id int
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		var err error
+		id, err = insertAttempt(ctx, tx, attempt)
+		return err
+	})
+
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return id, nil
+}
+
+func (r *bashrunRepository) ReadAttempt(ctx context.Context, id int) (domain.Attempt, error) {
+	const logPrefix = "repository.ReadAttempt"
+
+	var attempt domain.Attempt
+	err := r.db.QueryRow(ctx, "SELECT a.command_id, a.parent_id, a.attempt, a.processing_status, a.exit_status, p.retry_policy, p.processing_status, p.callback_url, p.workflow_id, p.pipeline_id"+
+		" FROM cmd a JOIN cmd p ON p.command_id = a.parent_id WHERE a.command_id = $1", id).Scan(&attempt.ID, &attempt.ParentID, &attempt.Number, &attempt.Status,
+		&attempt.ExitStatus, &attempt.Retry, &attempt.ParentStatus, &attempt.ParentCallbackURL, &attempt.ParentWorkflowID, &attempt.ParentPipelineID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Attempt{}, appErrors.ErrNoRows
+		}
+
+		return domain.Attempt{}, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return attempt, nil
+}
+
+// FinishParent copies the outcome of the last attempt to its parent. CPU time
+// is summed over all attempts, the duration spans from the first start.
+func (r *bashrunRepository) FinishParent(ctx context.Context, attemptID int, transition domain.StatusTransition) error {
+	const logPrefix = "repository.FinishParent"
+
+	if !transition.From.CanTransitionTo(transition.To) {
+		return fmt.Errorf("%s: %w", logPrefix, appErrors.ErrWrongTransition)
+	}
+
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, "UPDATE cmd p SET processing_status = $1, error = a.error, exit_status = a.exit_status, finished_at = a.finished_at,"+
+			" duration_ms = (EXTRACT(EPOCH FROM a.finished_at - p.started_at) * 1000)::BIGINT,"+
+			" user_cpu_ms = (SELECT SUM(user_cpu_ms) FROM cmd WHERE parent_id = p.command_id),"+
+			" system_cpu_ms = (SELECT SUM(system_cpu_ms) FROM cmd WHERE parent_id = p.command_id),"+
+			" truncated = a.truncated, output_bytes = a.output_bytes"+
+			" FROM cmd a WHERE a.command_id = $2 AND p.command_id = a.parent_id AND p.processing_status = $3",
+			transition.To, attemptID, transition.From)
+		if err != nil {
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return appErrors.ErrStatusConflict
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return nil
+}
+
+func (r *bashrunRepository) ReadCommandLinks(ctx context.Context, id int) (domain.CommandLinks, error) {
+	const logPrefix = "repository.ReadCommandLinks"
+
+	var links domain.CommandLinks
+	err := r.db.QueryRow(ctx, "SELECT retry_policy IS NOT NULL, parent_id IS NOT NULL, workflow_id, pipeline_id, callback_url, (SELECT MAX(a.command_id) FROM cmd a WHERE a.parent_id = cmd.command_id AND a.processing_status IN ($1, $2, $3))"+
+		" FROM cmd WHERE command_id = $4", domain.StatusScheduled, domain.StatusCreated, domain.StatusStarted, id).Scan(&links.Parent, &links.Attempt, &links.WorkflowID, &links.PipelineID, &links.CallbackURL, &links.ActiveAttempt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.CommandLinks{}, appErrors.ErrNoRows
+		}
+
+		return domain.CommandLinks{}, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return links, nil
+}
+
+func (r *bashrunRepository) ListAttempts(ctx context.Context, id int) ([]domain.CommandFromDB, error) {
+	const logPrefix = "repository.ListAttempts"
+
+	var exists bool
+	err := r.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM cmd WHERE command_id = $1)", id).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	if !exists {
+		return nil, appErrors.ErrNoRows
+	}
+
+	rows, err := r.db.Query(ctx, "SELECT "+commandColumns+" FROM cmd WHERE parent_id = $1 ORDER BY attempt", id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+	defer rows.Close()
+
+	attempts := make([]domain.CommandFromDB, 0)
+	for rows.Next() {
+		var attempt domain.CommandFromDB
+		err = scanCommand(rows, &attempt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", logPrefix, err)
+		}
+
+		attempts = append(attempts, attempt)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return attempts, nil
+}
+
+// ListStalledAttempts returns the last attempts of parents that are still
+// active while none of their attempts is, e.g. when the service went down
+// between an attempt and its retry.
+func (r *bashrunRepository) ListStalledAttempts(ctx context.Context) ([]int, error) {
+	const logPrefix = "repository.ListStalledAttempts"
+
+	rows, err := r.db.Query(ctx, "SELECT (SELECT MAX(a.command_id) FROM cmd a WHERE a.parent_id = p.command_id) FROM cmd p WHERE p.retry_policy IS NOT NULL"+
+		" AND p.processing_status IN ($1, $2, $3) AND NOT EXISTS (SELECT 1 FROM cmd a WHERE a.parent_id = p.command_id AND a.processing_status IN ($1, $2, $3)) ORDER BY p.command_id",
+		domain.StatusScheduled, domain.StatusCreated, domain.StatusStarted)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id *int
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", logPrefix, err)
+		}
+
+		if id != nil {
+			ids = append(ids, *id)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	appErrors "github.com/PoorMercymain/bashrun/errors"
+	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
+)
+
+const scheduleColumns = "schedule_id, cron, timezone, overlap, spec, stdin, paused, next_run_at, last_run_at, last_command_id, skipped_runs, created_at"
+
+func scanSchedule(row pgx.Row, schedule *domain.Schedule) error {
+	var spec, stdin []byte
+	err := row.Scan(&schedule.ID, &schedule.Cron, &schedule.Timezone, &schedule.Overlap, &spec, &stdin, &schedule.Paused, &schedule.NextRunAt, &schedule.LastRunAt,
+		&schedule.LastCommandID, &schedule.SkippedRuns, &schedule.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	err = unmarshalSpec(spec, &schedule.Command)
+	if err != nil {
+		return err
+	}
+
+	schedule.Command.Stdin = string(stdin)
+
+	return nil
+}
+
+func (r *bashrunRepository) CreateSchedule(ctx context.Context, schedule domain.Schedule) (int, error) {
+	const logPrefix = "repository.CreateSchedule"
+
+	var stdin []byte
+	if schedule.Command.Stdin != "" {
+		stdin = []byte(schedule.Command.Stdin)
+	}
+
+	spec := schedule.Command
+	spec.Stdin = ""
+
+	specJSON, err := marshalSpec(spec)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	var id int
+	err = r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx, "INSERT INTO cmd_schedule(cron, timezone, overlap, spec, stdin, next_run_at) VALUES($1, $2, $3, $4, $5, $6) RETURNING schedule_id",
+			schedule.Cron, schedule.Timezone, schedule.Overlap, specJSON, stdin, schedule.NextRunAt).Scan(&id)
+	})
+
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return id, nil
+}
+
+func (r *bashrunRepository) querySchedules(ctx context.Context, query string, args ...interface{}) ([]domain.Schedule, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := make([]domain.Schedule, 0)
+	for rows.Next() {
+		var schedule domain.Schedule
+		err = scanSchedule(rows, &schedule)
+		if err != nil {
+			return nil, err
+		}
+
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, rows.Err()
+}
+
+func (r *bashrunRepository) ListSchedules(ctx context.Context) ([]domain.Schedule, error) {
+	const logPrefix = "repository.ListSchedules"
+
+	schedules, err := r.querySchedules(ctx, "SELECT "+scheduleColumns+" FROM cmd_schedule ORDER BY schedule_id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return schedules, nil
+}
+
+func (r *bashrunRepository) ListDueSchedules(ctx context.Context, now time.Time, limit int) ([]domain.Schedule, error) {
+	const logPrefix = "repository.ListDueSchedules"
+
+	schedules, err := r.querySchedules(ctx, "SELECT "+scheduleColumns+" FROM cmd_schedule WHERE NOT paused AND next_run_at <= $1 ORDER BY next_run_at LIMIT $2", now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return schedules, nil
+}
+
+func (r *bashrunRepository) ReadSchedule(ctx context.Context, id int) (domain.Schedule, error) {
+	const logPrefix = "repository.ReadSchedule"
+
+	var schedule domain.Schedule
+	err := scanSchedule(r.db.QueryRow(ctx, "SELECT "+scheduleColumns+" FROM cmd_schedule WHERE schedule_id = $1", id), &schedule)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Schedule{}, appErrors.ErrNoRows
+		}
+
+		return domain.Schedule{}, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return schedule, nil
+}
+
+func (r *bashrunRepository) PauseSchedule(ctx context.Context, id int) error {
+	const logPrefix = "repository.PauseSchedule"
+
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, "UPDATE cmd_schedule SET paused = true WHERE schedule_id = $1", id)
+		if err != nil {
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return appErrors.ErrNoRows
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return nil
+}
+
+func (r *bashrunRepository) ResumeSchedule(ctx context.Context, id int, nextRunAt *time.Time) error {
+	const logPrefix = "repository.ResumeSchedule"
+
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "UPDATE cmd_schedule SET paused = false, next_run_at = $1 WHERE schedule_id = $2 AND paused", nextRunAt, id)
+		return err
+	})
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return nil
+}
+
+func (r *bashrunRepository) DeleteSchedule(ctx context.Context, id int) error {
+	const logPrefix = "repository.DeleteSchedule"
+
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, "DELETE FROM cmd_schedule WHERE schedule_id = $1", id)
+		if err != nil {
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return appErrors.ErrNoRows
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return nil
+}
+
+func activeScheduleRuns(ctx context.Context, tx pgx.Tx, scheduleID int) ([]int, error) {
+	rows, err := tx.Query(ctx, "SELECT command_id FROM cmd WHERE schedule_id = $1 AND parent_id IS NULL AND processing_status IN ('created', 'started') ORDER BY command_id", scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var active []int
+	for rows.Next() {
+		var id int
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		active = append(active, id)
+	}
+
+	return active, rows.Err()
+}
+
+// RunSchedule moves next_run_at forward with a compare-and-set, so when several
+// instances see the same due schedule only one of them creates the run.
+func (r *bashrunRepository) RunSchedule(ctx context.Context, run domain.ScheduleRun) (domain.ScheduleRunResult, error) {
+	const logPrefix = "repository.RunSchedule"
+
+	var result domain.ScheduleRunResult
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, "UPDATE cmd_schedule SET next_run_at = $1 WHERE schedule_id = $2 AND next_run_at = $3 AND NOT paused", run.NextRunAt, run.ScheduleID, run.RunAt)
+		if err != nil {
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return appErrors.ErrRowsNotAffected
+		}
+
+		active, err := activeScheduleRuns(ctx, tx, run.ScheduleID)
+		if err != nil {
+			return err
+		}
+
+		if len(active) > 0 && run.Overlap == domain.OverlapSkip {
+			result.Skipped = true
+			_, err = tx.Exec(ctx, "UPDATE cmd_schedule SET skipped_runs = skipped_runs + 1 WHERE schedule_id = $1", run.ScheduleID)
+			return err
+		}
+
+		if run.Overlap == domain.OverlapReplace {
+			result.Replaced = active
+		}
+
+		result.CommandID, err = insertCommand(ctx, tx, run.Command, commandOwner{ScheduleID: &run.ScheduleID})
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, "UPDATE cmd_schedule SET last_run_at = $1, last_command_id = $2 WHERE schedule_id = $3", run.Command.CreatedAt, result.CommandID, run.ScheduleID)
+		return err
+	})
+
+	if err != nil {
+		return domain.ScheduleRunResult{}, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"encoding/json"
+
+	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
+)
+
+// commandSpec is how a command that is created later is saved. The script
+// fields are left out of the API JSON, but the command can not run without
+// them.
+type commandSpec struct {
+	domain.CommandFromUser
+	Script     string   `json:"script,omitempty"`
+	ScriptArgs []string `json:"script_args,omitempty"`
+}
+
+func marshalSpec(command domain.CommandFromUser) ([]byte, error) {
+	return json.Marshal(commandSpec{CommandFromUser: command, Script: command.Script, ScriptArgs: command.ScriptArgs})
+}
+
+func unmarshalSpec(data []byte, command *domain.CommandFromUser) error {
+	var spec commandSpec
+	err := json.Unmarshal(data, &spec)
+	if err != nil {
+		return err
+	}
+
+	*command = spec.CommandFromUser
+	command.Script, command.ScriptArgs = spec.Script, spec.ScriptArgs
+
+	return nil
+}
@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	appErrors "github.com/PoorMercymain/bashrun/errors"
+	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
+)
+
+const workflowColumns = "workflow_id, name, status, created_at, finished_at"
+
+func (r *bashrunRepository) CreateWorkflow(ctx context.Context, workflow domain.WorkflowFromUser, createdAt time.Time) (int, domain.WorkflowAdvance, error) {
+	const logPrefix = "repository.CreateWorkflow"
+
+	var id int
+	var advance domain.WorkflowAdvance
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, "INSERT INTO cmd_workflow(name, created_at) VALUES($1, $2) RETURNING workflow_id", workflow.Name, createdAt).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		for i, node := range workflow.Nodes {
+			var stdin []byte
+			if node.Command.Stdin != "" {
+				stdin = []byte(node.Command.Stdin)
+			}
+
+			spec := node.Command
+			spec.Stdin = ""
+
+			specJSON, err := marshalSpec(spec)
+			if err != nil {
+				return err
+			}
+
+			dependsOn := node.DependsOn
+			if dependsOn == nil {
+				dependsOn = []string{}
+			}
+
+			_, err = tx.Exec(ctx, "INSERT INTO cmd_workflow_node(workflow_id, name, position, depends_on, condition, spec, stdin) VALUES($1, $2, $3, $4, $5, $6, $7)",
+				id, node.Name, i, dependsOn, node.Condition, specJSON, stdin)
+			if err != nil {
+				return err
+			}
+		}
+
+		advance, err = advanceWorkflow(ctx, tx, id, createdAt)
+		return err
+	})
+
+	if err != nil {
+		return 0, domain.WorkflowAdvance{}, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return id, advance, nil
+}
+
+// AdvanceWorkflow starts or skips the nodes whose dependencies are resolved.
+// The workflow row is locked, so concurrent calls for commands finishing at
+// the same time do not start a node twice.
+func (r *bashrunRepository) AdvanceWorkflow(ctx context.Context, id int, now time.Time) (domain.WorkflowAdvance, error) {
+	const logPrefix = "repository.AdvanceWorkflow"
+
+	var advance domain.WorkflowAdvance
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		var err error
+		advance, err = advanceWorkflow(ctx, tx, id, now)
+		return err
+	})
+
+	if err != nil {
+		return domain.WorkflowAdvance{}, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return advance, nil
+}
+
+func advanceWorkflow(ctx context.Context, tx pgx.Tx, id int, now time.Time) (domain.WorkflowAdvance, error) {
+	workflow, err := readWorkflow(ctx, tx, id, "SELECT "+workflowColumns+" FROM cmd_workflow WHERE workflow_id = $1 FOR UPDATE")
+	if err != nil {
+		return domain.WorkflowAdvance{}, err
+	}
+
+	advance := domain.WorkflowAdvance{Status: workflow.Status}
+	if workflow.Status != domain.WorkflowStatusRunning {
+		return advance, nil
+	}
+
+	run, skip := workflow.Advance()
+
+	for _, i := range skip {
+		_, err = tx.Exec(ctx, "UPDATE cmd_workflow_node SET skipped = true WHERE workflow_id = $1 AND name = $2", id, workflow.Nodes[i].Name)
+		if err != nil {
+			return domain.WorkflowAdvance{}, err
+		}
+	}
+
+	for _, i := range run {
+		command := workflow.Nodes[i].Command
+		command.CreatedAt = now
+
+		commandID, err := insertCommand(ctx, tx, command, commandOwner{WorkflowID: &id})
+		if err != nil {
+			return domain.WorkflowAdvance{}, err
+		}
+
+		_, err = tx.Exec(ctx, "UPDATE cmd_workflow_node SET command_id = $1 WHERE workflow_id = $2 AND name = $3", commandID, id, workflow.Nodes[i].Name)
+		if err != nil {
+			return domain.WorkflowAdvance{}, err
+		}
+
+		advance.Started = append(advance.Started, domain.StartedCommand{ID: commandID, Status: command.InitialStatus()})
+	}
+
+	advance.Status = workflow.AggregateStatus()
+	if advance.Status != domain.WorkflowStatusRunning {
+		_, err = tx.Exec(ctx, "UPDATE cmd_workflow SET status = $1, finished_at = $2 WHERE workflow_id = $3", advance.Status, now, id)
+		if err != nil {
+			return domain.WorkflowAdvance{}, err
+		}
+	}
+
+	return advance, nil
+}
+
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+func scanWorkflow(row pgx.Row, workflow *domain.Workflow) error {
+	return row.Scan(&workflow.ID, &workflow.Name, &workflow.Status, &workflow.CreatedAt, &workflow.FinishedAt)
+}
+
+// readWorkflow reads a workflow with its nodes, the status of a started node
+// is the status of its command.
+func readWorkflow(ctx context.Context, q querier, id int, query string) (domain.Workflow, error) {
+	var workflow domain.Workflow
+	err := scanWorkflow(q.QueryRow(ctx, query, id), &workflow)
+	if err != nil {
+		return domain.Workflow{}, err
+	}
+
+	rows, err := q.Query(ctx, "SELECT n.name, n.depends_on, n.condition, n.spec, n.stdin, n.skipped, n.command_id, c.processing_status, c.exit_status"+
+		" FROM cmd_workflow_node n LEFT JOIN cmd c ON c.command_id = n.command_id WHERE n.workflow_id = $1 ORDER BY n.position", id)
+	if err != nil {
+		return domain.Workflow{}, err
+	}
+	defer rows.Close()
+
+	workflow.Nodes = make([]domain.WorkflowNode, 0)
+	for rows.Next() {
+		var node domain.WorkflowNode
+		var spec, stdin []byte
+		var skipped bool
+		var status *string
+		err = rows.Scan(&node.Name, &node.DependsOn, &node.Condition, &spec, &stdin, &skipped, &node.CommandID, &status, &node.ExitStatus)
+		if err != nil {
+			return domain.Workflow{}, err
+		}
+
+		err = unmarshalSpec(spec, &node.Command)
+		if err != nil {
+			return domain.Workflow{}, err
+		}
+
+		node.Command.Stdin = string(stdin)
+
+		switch {
+		case skipped:
+			node.Status = domain.NodeStatusSkipped
+		case status != nil:
+			node.Status = *status
+		default:
+			node.Status = domain.NodeStatusPending
+		}
+
+		workflow.Nodes = append(workflow.Nodes, node)
+	}
+
+	if err = rows.Err(); err != nil {
+		return domain.Workflow{}, err
+	}
+
+	return workflow, nil
+}
+
+func (r *bashrunRepository) ReadWorkflow(ctx context.Context, id int) (domain.Workflow, error) {
+	const logPrefix = "repository.ReadWorkflow"
+
+	workflow, err := readWorkflow(ctx, r.db, id, "SELECT "+workflowColumns+" FROM cmd_workflow WHERE workflow_id = $1")
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Workflow{}, appErrors.ErrNoRows
+		}
+
+		return domain.Workflow{}, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return workflow, nil
+}
+
+func (r *bashrunRepository) ListWorkflows(ctx context.Context) ([]domain.Workflow, error) {
+	const logPrefix = "repository.ListWorkflows"
+
+	rows, err := r.db.Query(ctx, "SELECT "+workflowColumns+" FROM cmd_workflow ORDER BY workflow_id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+	defer rows.Close()
+
+	workflows := make([]domain.Workflow, 0)
+	for rows.Next() {
+		var workflow domain.Workflow
+		err = scanWorkflow(rows, &workflow)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", logPrefix, err)
+		}
+
+		workflows = append(workflows, workflow)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return workflows, nil
+}
+
+func (r *bashrunRepository) ListRunningWorkflows(ctx context.Context) ([]int, error) {
+	const logPrefix = "repository.ListRunningWorkflows"
+
+	rows, err := r.db.Query(ctx, "SELECT workflow_id FROM cmd_workflow WHERE status = $1 ORDER BY workflow_id", domain.WorkflowStatusRunning)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", logPrefix, err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return ids, nil
+}
@@ -1,20 +1,26 @@
 package service
 
 import (
-	"bufio"
 	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"os/exec"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
-	"golang.org/x/sync/semaphore"
 	"golang.org/x/sync/singleflight"
 
 	appErrors "github.com/PoorMercymain/bashrun/errors"
+	"github.com/PoorMercymain/bashrun/internal/bashrun/config"
 	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
 	"github.com/PoorMercymain/bashrun/pkg/logger"
 )
@@ -23,16 +29,42 @@ var (
 	_ domain.BashrunService = (*bashrunService)(nil)
 )
 
+const (
+	defaultWaitTimeout = 30 * time.Second
+	waitPollInterval   = 500 * time.Millisecond
+)
+
 type bashrunService struct {
 	repo           domain.BashrunRepository
-	sem            *semaphore.Weighted
 	wg             *sync.WaitGroup
 	commandContext context.Context
 	sf             *singleflight.Group
+	events         *eventHub
+	cfg            *config.Config
+	stopMu         *sync.Mutex
+	stopDeadlines  map[int]time.Time
+	processes      *sync.Map
+	client         *http.Client
+	wake           chan struct{}
+	instanceID     string
 }
 
-func New(commandContext context.Context, repo domain.BashrunRepository, sem *semaphore.Weighted, wg *sync.WaitGroup) *bashrunService {
-	return &bashrunService{repo: repo, sem: sem, wg: wg, commandContext: commandContext, sf: &singleflight.Group{}}
+func New(commandContext context.Context, repo domain.BashrunRepository, wg *sync.WaitGroup, cfg *config.Config) *bashrunService {
+	callbackTimeout := time.Duration(cfg.CallbackTimeout) * time.Second
+	if callbackTimeout <= 0 {
+		callbackTimeout = defaultCallbackTimeout
+	}
+
+	// host names repeat across hosts and containers, so without a configured
+	// ID every process gets its own, its commands are then recovered by lease
+	// expiry after a restart
+	instanceID := cfg.InstanceID
+	if instanceID == "" {
+		instanceID = randomInstanceID()
+	}
+
+	return &bashrunService{repo: repo, wg: wg, commandContext: commandContext, sf: &singleflight.Group{}, events: newEventHub(), cfg: cfg,
+		stopMu: &sync.Mutex{}, stopDeadlines: make(map[int]time.Time), processes: &sync.Map{}, client: &http.Client{Timeout: callbackTimeout}, wake: make(chan struct{}, 1), instanceID: instanceID}
 }
 
 func (s *bashrunService) Ping(ctx context.Context) error {
@@ -46,176 +78,547 @@ func (s *bashrunService) Ping(ctx context.Context) error {
 	return nil
 }
 
-func (s *bashrunService) CreateCommand(ctx context.Context, command string) (int, error) {
+func (s *bashrunService) CreateCommand(ctx context.Context, command domain.CommandFromUser) (int, error) {
 	const logPrefix = "service.CreateCommand"
 
+	err := s.prepareCommand(&command)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	command.CreatedAt = time.Now()
+	if command.DelaySeconds != nil {
+		runAt := command.CreatedAt.Add(time.Duration(*command.DelaySeconds) * time.Second)
+		command.RunAt = &runAt
+	}
+
 	id, err := s.repo.CreateCommand(ctx, command)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", logPrefix, err)
 	}
 
-	s.wg.Add(1)
-	go func() {
-		defer s.wg.Done()
+	s.commandCreated(id, command.InitialStatus())
 
-		err := s.sem.Acquire(s.commandContext, 1)
-		if err != nil {
-			logger.Logger().Warnln("couldn't run command: semaphore didn't have enough resources")
-			return
+	return id, nil
+}
+
+func (s *bashrunService) commandCreated(id int, status domain.CommandStatus) {
+	s.events.open(id)
+	s.events.publishStatus(id, status)
+
+	if status == domain.StatusCreated {
+		s.notifyWorkers()
+	}
+}
+
+func (s *bashrunService) prepareCommand(command *domain.CommandFromUser) error {
+	err := s.applyTimeoutLimits(command)
+	if err != nil {
+		return err
+	}
+
+	err = s.applyOutputLimits(command)
+	if err != nil {
+		return err
+	}
+
+	err = prepareEnvironment(command)
+	if err != nil {
+		return err
+	}
+
+	err = s.prepareInterpreter(command)
+	if err != nil {
+		return err
+	}
+
+	err = validateLabels(command.Labels)
+	if err != nil {
+		return err
+	}
+
+	err = validateCallback(*command)
+	if err != nil {
+		return err
+	}
+
+	if command.Priority < minPriority || command.Priority > maxPriority {
+		return appErrors.ErrWrongPriority
+	}
+
+	if command.DelaySeconds != nil && (*command.DelaySeconds <= 0 || command.RunAt != nil) {
+		return appErrors.ErrWrongRunAt
+	}
+
+	return prepareRetry(command.Retry)
+}
+
+func validateLabels(labels []string) error {
+	for _, label := range labels {
+		if label == "" || strings.TrimSpace(label) != label {
+			return appErrors.ErrWrongLabels
 		}
-		defer s.sem.Release(1)
+	}
 
-		status, err := func() (string, error) {
-			cmd := exec.CommandContext(s.commandContext, "sh", "-c", command)
-			commandStdout, err := cmd.StdoutPipe()
-			if err != nil {
-				return "failed to create pipe", err
-			}
+	return nil
+}
 
-			status, err := s.repo.ReadStatus(s.commandContext, id)
-			if err != nil {
-				return "failed to check status", err
-			}
+func (s *bashrunService) applyTimeoutLimits(command *domain.CommandFromUser) error {
+	if command.TimeoutSeconds == nil {
+		if s.cfg.DefaultCommandTimeout > 0 {
+			timeout := s.cfg.DefaultCommandTimeout
+			command.TimeoutSeconds = &timeout
+		} else if s.cfg.MaxCommandTimeout > 0 {
+			timeout := s.cfg.MaxCommandTimeout
+			command.TimeoutSeconds = &timeout
+		}
+	} else if *command.TimeoutSeconds <= 0 || (s.cfg.MaxCommandTimeout > 0 && *command.TimeoutSeconds > s.cfg.MaxCommandTimeout) {
+		return appErrors.ErrWrongTimeout
+	}
 
-			if status == "stopped" {
-				return "stopped", appErrors.ErrCommandStopped
-			}
+	if command.KillGraceSeconds == nil {
+		killGrace := s.cfg.DefaultKillGrace
+		command.KillGraceSeconds = &killGrace
+	} else if *command.KillGraceSeconds < 0 {
+		return appErrors.ErrWrongKillGrace
+	}
 
-			err = cmd.Start()
-			if err != nil {
-				return "failed to start command", err
-			}
+	return nil
+}
 
-			err = s.repo.UpdatePID(s.commandContext, id, cmd.Process.Pid)
-			if err != nil {
-				return "failed to set PID in DB", err
-			}
+func (s *bashrunService) applyOutputLimits(command *domain.CommandFromUser) error {
+	if command.MaxOutputBytes == nil {
+		if s.cfg.MaxOutputBytes > 0 {
+			maxOutput := s.cfg.MaxOutputBytes
+			command.MaxOutputBytes = &maxOutput
+		}
+	} else if *command.MaxOutputBytes <= 0 || (s.cfg.MaxOutputBytes > 0 && *command.MaxOutputBytes > s.cfg.MaxOutputBytes) {
+		return appErrors.ErrWrongMaxOutput
+	}
 
-			err = s.repo.UpdateStatus(s.commandContext, id, "started")
-			if err != nil {
-				return "failed to update status in DB", err
-			}
+	if command.OutputPolicy == "" {
+		command.OutputPolicy = s.cfg.DefaultOutputPolicy
+		if command.OutputPolicy == "" {
+			command.OutputPolicy = domain.OutputPolicyHead
+		}
+	}
 
-			scanner := bufio.NewScanner(commandStdout)
+	switch command.OutputPolicy {
+	case domain.OutputPolicyHead, domain.OutputPolicyTail, domain.OutputPolicyKill:
+		return nil
+	default:
+		return appErrors.ErrWrongOutputPolicy
+	}
+}
 
-			var outputPart string
-			for scanner.Scan() {
-				outputPart = scanner.Text()
-				err = s.repo.UpdateOutput(s.commandContext, id, outputPart+"\n")
-				if err != nil {
-					return "failed to update output in DB", err
-				}
-			}
+func (s *bashrunService) run(queued domain.QueuedCommand) {
+	const logPrefix = "service.run"
 
-			var exitStatus int
-			err = cmd.Wait()
-			if err != nil {
-				var exitErr *exec.ExitError
-				if errors.As(err, &exitErr) {
-					exitStatus = exitErr.ExitCode()
-				} else {
-					return "failed to wait for a process to finish", err
-				}
-			}
+	id, command := queued.ID, queued.Command
 
-			err = s.repo.UpdateExitStatus(s.commandContext, id, exitStatus)
-			if err != nil {
-				return "failed to update exit status in DB", err
-			}
+	defer s.wg.Done()
+	defer s.events.close(id)
 
-			status, err = s.repo.ReadStatus(s.commandContext, id)
-			if err != nil {
-				return "failed to check status", err
-			}
+	s.events.open(id)
 
-			if status == "stopped" {
-				return "stopped", appErrors.ErrCommandStopped
-			}
+	transition, err := s.execute(queued)
+	if err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+	}
 
-			return "done", errors.New("")
-		}()
+	// the command was stopped before it started, StopCommand has already
+	// finished it
+	if transition.To == "" {
+		return
+	}
 
-		if err != nil {
-			logger.Logger().Error(logPrefix, ": ", err.Error())
-			c, cancel := context.WithTimeout(context.Background(), time.Second)
-			defer cancel()
+	c, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
 
-			err = s.repo.UpdateStatus(c, id, status)
-			if err != nil {
-				logger.Logger().Error(logPrefix, ": ", err.Error())
-			}
+	err = s.repo.UpdateStatus(c, id, transition)
+	if err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+		return
+	}
+
+	s.events.publishStatus(id, transition.To)
+
+	if command.CallbackURL != "" {
+		s.enqueueCallback(id, command.CallbackURL)
+	}
+
+	if queued.ParentID != nil {
+		s.finishAttempt(id)
+	}
+
+	s.advanceFlows(queued.WorkflowID, queued.PipelineID)
+}
+
+func randomInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = strconv.Itoa(os.Getpid())
+	}
+
+	suffix := make([]byte, 8)
+	_, _ = cryptorand.Read(suffix)
+
+	return hostname + "-" + hex.EncodeToString(suffix)
+}
+
+func failed(from domain.CommandStatus, reason string, err error) (domain.StatusTransition, error) {
+	return domain.StatusTransition{From: from, To: domain.StatusFailed, Error: reason}, fmt.Errorf("%s: %w", reason, err)
+}
+
+func (s *bashrunService) execute(queued domain.QueuedCommand) (domain.StatusTransition, error) {
+	id, command := queued.ID, queued.Command
+
+	cmd, cleanup, err := s.newCmd(s.commandContext, command)
+	if err != nil {
+		return failed(domain.StatusCreated, "failed to prepare command", err)
+	}
+	defer cleanup()
+
+	startInProcessGroup(cmd)
+	applyEnvironment(cmd, command)
+
+	commandStdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return failed(domain.StatusCreated, "failed to create pipe", err)
+	}
+
+	commandStderr, err := cmd.StderrPipe()
+	if err != nil {
+		return failed(domain.StatusCreated, "failed to create pipe", err)
+	}
+
+	status, err := s.repo.ReadStatus(s.commandContext, id)
+	if err != nil {
+		return failed(domain.StatusCreated, "failed to check status", err)
+	}
+
+	if status != domain.StatusCreated {
+		return domain.StatusTransition{}, appErrors.ErrCommandStopped
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return failed(domain.StatusCreated, "failed to start command", err)
+	}
+
+	s.processes.Store(id, cmd.Process.Pid)
+	defer s.processes.Delete(id)
+
+	startedAt := time.Now()
+	waitDone := make(chan struct{})
+	defer close(waitDone)
+
+	killGrace := time.Duration(*command.KillGraceSeconds) * time.Second
+
+	var timedOut atomic.Bool
+	if command.TimeoutSeconds != nil {
+		timer := time.AfterFunc(time.Duration(*command.TimeoutSeconds)*time.Second, func() {
+			timedOut.Store(true)
+			terminateProcessGroup(cmd.Process.Pid, killGrace, waitDone)
+		})
+		defer timer.Stop()
+	}
+
+	// the command fails, but its process is already running, so it is stopped
+	// and waited for before the failure is reported
+	abort := func(reason string, err error) (domain.StatusTransition, error) {
+		go terminateProcessGroup(cmd.Process.Pid, killGrace, waitDone)
+		_ = cmd.Wait()
+
+		return failed(domain.StatusCreated, reason, err)
+	}
+
+	err = s.repo.UpdatePID(s.commandContext, id, cmd.Process.Pid)
+	if err != nil {
+		return abort("failed to set PID in DB", err)
+	}
+
+	queuedAt := command.CreatedAt
+	if command.RunAt != nil && command.RunAt.After(queuedAt) {
+		queuedAt = *command.RunAt
+	}
+
+	queuedFor := startedAt.Sub(queuedAt)
+	err = s.repo.UpdateStartTime(s.commandContext, id, startedAt, queuedFor)
+	if err != nil {
+		return abort("failed to update start time in DB", err)
+	}
+
+	var stoppedBeforeStart bool
+	err = s.repo.UpdateStatus(s.commandContext, id, domain.StatusTransition{From: domain.StatusCreated, To: domain.StatusStarted})
+	if errors.Is(err, appErrors.ErrStatusConflict) {
+		stoppedBeforeStart = true
+		go terminateProcessGroup(cmd.Process.Pid, killGrace, waitDone)
+	} else if err != nil {
+		return abort("failed to update status in DB", err)
+	} else {
+		s.events.publishStatus(id, domain.StatusStarted)
+	}
+
+	if queued.ParentID != nil && !stoppedBeforeStart {
+		err = s.startParent(s.commandContext, *queued.ParentID, startedAt, queuedFor)
+		if err != nil {
+			logger.Logger().Error("service.execute: ", err.Error())
 		}
+	}
+
+	var outputLimitExceeded atomic.Bool
+	output := newOutputWriter(s.commandContext, s.repo, id, s.cfg, command, func() {
+		outputLimitExceeded.Store(true)
+		go terminateProcessGroup(cmd.Process.Pid, killGrace, waitDone)
+	})
+
+	stderrDone := make(chan struct{})
+	go func() {
+		s.captureOutput(id, domain.StreamStderr, commandStderr, output)
+		close(stderrDone)
 	}()
 
-	return id, nil
+	s.captureOutput(id, domain.StreamStdout, commandStdout, output)
+	<-stderrDone
+	exitedAt := time.Now()
+
+	outputErr := output.close()
+
+	var exitStatus int
+	err = cmd.Wait()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return failed(domain.StatusStarted, "failed to wait for a process to finish", err)
+		}
+
+		exitStatus = exitErr.ExitCode()
+	}
+
+	if outputErr != nil {
+		return failed(domain.StatusStarted, "failed to update output in DB", outputErr)
+	}
+
+	finishedAt := time.Now()
+	err = s.repo.UpdateExecutionStats(s.commandContext, id, domain.ExecutionStats{
+		FinishedAt: finishedAt,
+		Duration:   finishedAt.Sub(startedAt),
+		UserCPU:    cmd.ProcessState.UserTime(),
+		SystemCPU:  cmd.ProcessState.SystemTime(),
+	})
+	if err != nil {
+		return failed(domain.StatusStarted, "failed to update execution stats in DB", err)
+	}
+
+	err = s.repo.UpdateExitStatus(s.commandContext, id, exitStatus)
+	if err != nil {
+		return failed(domain.StatusStarted, "failed to update exit status in DB", err)
+	}
+
+	s.events.publishExit(id, exitStatus)
+
+	switch {
+	case stoppedBeforeStart:
+		return domain.StatusTransition{}, appErrors.ErrCommandStopped
+	case s.stopVerified(id, cmd.Process.Pid, exitedAt):
+		return domain.StatusTransition{From: domain.StatusStarted, To: domain.StatusStopped}, appErrors.ErrCommandStopped
+	case timedOut.Load():
+		return domain.StatusTransition{From: domain.StatusStarted, To: domain.StatusTimedOut}, appErrors.ErrCommandTimedOut
+	case outputLimitExceeded.Load():
+		return domain.StatusTransition{From: domain.StatusStarted, To: domain.StatusOutputLimitExceeded}, appErrors.ErrOutputLimitExceeded
+	}
+
+	return domain.StatusTransition{From: domain.StatusStarted, To: domain.StatusDone}, nil
 }
 
-func (s *bashrunService) ListCommands(ctx context.Context, limit int, offset int) ([]domain.CommandFromDB, error) {
+func (s *bashrunService) ListCommands(ctx context.Context, filter domain.CommandFilter) (domain.CommandPage, error) {
 	const logPrefix = "service.ListCommands"
 
-	commands, err := s.repo.ListCommands(ctx, limit, offset)
+	err := s.applyCursor(&filter)
+	if err != nil {
+		return domain.CommandPage{}, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	limit := filter.Limit
+	filter.Limit++
+
+	commands, err := s.repo.ListCommands(ctx, filter)
+	if err != nil {
+		return domain.CommandPage{}, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	hasMore := len(commands) > limit
+	if hasMore {
+		commands = commands[:limit]
+	}
+
+	if filter.Backward {
+		slices.Reverse(commands)
+	}
+
+	for _, command := range commands {
+		redactEnv(command.Env, s.cfg.RedactedEnvKeys)
+	}
+
+	hasNext, hasPrev := hasMore, filter.Cursor != nil || filter.Offset > 0
+	if filter.Backward {
+		hasNext, hasPrev = true, hasMore
+	}
+
+	page := domain.CommandPage{Commands: commands}
+
+	if hasNext {
+		page.NextCursor = encodeCursor(filter, commands[len(commands)-1])
+	}
+
+	if hasPrev {
+		page.PrevCursor = encodeCursor(filter, commands[0])
+	}
+
+	page.Total, err = s.repo.CountCommands(ctx, filter)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+		return domain.CommandPage{}, fmt.Errorf("%s: %w", logPrefix, err)
 	}
 
-	return commands, nil
+	return page, nil
 }
 
-func (s *bashrunService) StopCommand(ctx context.Context, id int) error {
+func (s *bashrunService) StopCommand(ctx context.Context, id int, signalName string) error {
 	const logPrefix = "service.StopCommand"
 
+	sig, err := parseStopSignal(signalName)
+	if err != nil {
+		return fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
 	status, err := s.repo.ReadStatus(ctx, id)
 	if err != nil {
 		return fmt.Errorf("%s: %w", logPrefix, err)
 	}
 
-	if status == "created" {
-		err = s.repo.UpdateStatus(ctx, id, "stopped")
-		if err != nil {
+	links, err := s.repo.ReadCommandLinks(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	// a command with retries is stopped through its active attempt, between
+	// attempts it has no process and is stopped right away
+	if links.Parent && links.ActiveAttempt != nil {
+		return s.StopCommand(ctx, *links.ActiveAttempt, signalName)
+	}
+
+	for status == domain.StatusScheduled || status == domain.StatusCreated || (links.Parent && status == domain.StatusStarted) {
+		err = s.repo.UpdateStatus(ctx, id, domain.StatusTransition{From: status, To: domain.StatusStopped})
+		if err == nil {
+			s.events.publishStatus(id, domain.StatusStopped)
+			s.stopped(id, links)
+			return nil
+		}
+
+		if !errors.Is(err, appErrors.ErrStatusConflict) {
 			return fmt.Errorf("%s: %w", logPrefix, err)
 		}
 
-		return nil
+		status, err = s.repo.ReadStatus(ctx, id)
+		if err != nil {
+			return fmt.Errorf("%s: %w", logPrefix, err)
+		}
 	}
 
-	if status != "started" {
+	if status != domain.StatusStarted {
 		return appErrors.ErrCommandNotRunning
 	}
 
-	pid, err := s.repo.ReadPID(ctx, id)
-	if err != nil {
-		return fmt.Errorf("%s: %w", logPrefix, err)
+	// only the instance that started the process signals it, a command run
+	// by another instance gets a stop request in DB
+	pid, ok := s.processes.Load(id)
+	if !ok {
+		err = s.repo.RequestStop(ctx, id, int(sig))
+		if errors.Is(err, appErrors.ErrRowsNotAffected) {
+			return appErrors.ErrCommandNotRunning
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", logPrefix, err)
+		}
+
+		return nil
 	}
 
+	s.signalCommand(id, pid.(int), sig)
+
+	return nil
+}
+
+// signalCommand signals the process group of a command started by this
+// instance. Requests with different signals are not merged, so KILL is sent
+// even while TERM is being verified. The command is marked stopped only once
+// the signal is delivered, execute then checks that the group has exited in
+// time.
+func (s *bashrunService) signalCommand(id int, pid int, sig syscall.Signal) {
+	const logPrefix = "service.signalCommand"
+
+	verifyTimeout := time.Duration(s.cfg.StopVerifyTimeout) * time.Second
+
 	s.wg.Add(1)
 	go func() {
 		defer s.wg.Done()
 
-		_, err, _ = s.sf.Do(strconv.Itoa(id), func() (interface{}, error) {
-			proc, err := os.FindProcess(pid)
-			if err != nil {
-				return nil, err
-			}
-
-			err = proc.Kill()
-			if err != nil {
-				return nil, err
+		_, err, _ := s.sf.Do(fmt.Sprintf("%d:%d", id, sig), func() (interface{}, error) {
+			// the lock keeps execute from checking the stop between the signal
+			// and saving its deadline
+			s.stopMu.Lock()
+			delivered, err := signalProcessGroup(pid, sig)
+			if delivered {
+				s.stopDeadlines[id] = time.Now().Add(verifyTimeout)
 			}
+			s.stopMu.Unlock()
 
-			err = s.repo.UpdateStatus(s.commandContext, id, "stopped")
-			if err != nil {
+			if err != nil || !delivered {
 				return nil, err
 			}
 
-			return nil, nil
+			return nil, waitProcessGroupExit(pid, verifyTimeout)
 		})
 
 		if err != nil {
 			logger.Logger().Error(logPrefix, ": ", err.Error())
 		}
 	}()
+}
 
-	return nil
+// stopVerified reports whether a command was stopped: its process group got
+// a stop signal and exited within the verify timeout after it. A process that
+// ignores the signal and exits later on its own is not stopped.
+func (s *bashrunService) stopVerified(id int, pgid int, exitedAt time.Time) bool {
+	s.stopMu.Lock()
+	deadline, ok := s.stopDeadlines[id]
+	delete(s.stopDeadlines, id)
+	s.stopMu.Unlock()
+
+	if !ok || exitedAt.After(deadline) {
+		return false
+	}
+
+	return waitProcessGroupExit(pgid, time.Until(deadline)) == nil
+}
+
+// stopped finishes a command stopped without a running process: its events
+// are closed, the callback is sent and the parent of an attempt and the
+// workflow or pipeline are updated. Attempts have no callback of their own.
+func (s *bashrunService) stopped(id int, links domain.CommandLinks) {
+	if links.Attempt {
+		s.finishAttempt(id)
+	}
+
+	s.events.close(id)
+
+	if links.CallbackURL != "" {
+		s.enqueueCallback(id, links.CallbackURL)
+	}
+
+	s.advanceFlows(links.WorkflowID, links.PipelineID)
 }
 
 func (s *bashrunService) ReadCommand(ctx context.Context, id int) (domain.CommandFromDB, error) {
@@ -226,16 +629,165 @@ func (s *bashrunService) ReadCommand(ctx context.Context, id int) (domain.Comman
 		return domain.CommandFromDB{}, fmt.Errorf("%s: %w", logPrefix, err)
 	}
 
+	if command.Status == domain.StatusCreated {
+		command.QueuePosition, err = s.repo.ReadQueuePosition(ctx, id)
+		if err != nil {
+			return domain.CommandFromDB{}, fmt.Errorf("%s: %w", logPrefix, err)
+		}
+	}
+
+	redactEnv(command.Env, s.cfg.RedactedEnvKeys)
+
 	return command, nil
 }
 
-func (s *bashrunService) ReadOutput(ctx context.Context, id int) (string, error) {
+func (s *bashrunService) ReadOutput(ctx context.Context, id int, rng domain.OutputRange) (domain.OutputSlice, error) {
 	const logPrefix = "service.ReadOutput"
 
-	output, err := s.repo.ReadOutput(ctx, id)
+	output, err := s.repo.ReadOutput(ctx, id, domain.StreamStdout, rng)
+	if err != nil {
+		return domain.OutputSlice{}, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return output, nil
+}
+
+func (s *bashrunService) ReadStderr(ctx context.Context, id int, rng domain.OutputRange) (domain.OutputSlice, error) {
+	const logPrefix = "service.ReadStderr"
+
+	output, err := s.repo.ReadOutput(ctx, id, domain.StreamStderr, rng)
+	if err != nil {
+		return domain.OutputSlice{}, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return output, nil
+}
+
+func (s *bashrunService) ReadCombinedOutput(ctx context.Context, id int, rng domain.OutputRange) (domain.OutputSlice, error) {
+	const logPrefix = "service.ReadCombinedOutput"
+
+	output, err := s.repo.ReadOutput(ctx, id, domain.StreamCombined, rng)
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", logPrefix, err)
+		return domain.OutputSlice{}, fmt.Errorf("%s: %w", logPrefix, err)
 	}
 
 	return output, nil
 }
+
+func (s *bashrunService) WaitCommand(ctx context.Context, id int, timeout time.Duration) (domain.CommandFromDB, bool, error) {
+	const logPrefix = "service.WaitCommand"
+
+	if timeout == 0 {
+		timeout = defaultWaitTimeout
+	}
+
+	if timeout < 0 || (s.cfg.MaxWaitSeconds > 0 && timeout > time.Duration(s.cfg.MaxWaitSeconds)*time.Second) {
+		return domain.CommandFromDB{}, false, fmt.Errorf("%s: %w", logPrefix, appErrors.ErrWrongWaitTimeout)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	command, err := s.repo.ReadCommand(ctx, id)
+	if err != nil {
+		return domain.CommandFromDB{}, false, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	for !command.Status.Final() {
+		// a command run by this instance notifies about its finish, a command
+		// run by another instance or recovered after a restart is polled in DB
+		var poll <-chan time.Time
+		done, ok := s.events.finished(id)
+		if !ok {
+			poll = time.After(waitPollInterval)
+		}
+
+		select {
+		case <-done:
+		case <-poll:
+		case <-timer.C:
+			redactEnv(command.Env, s.cfg.RedactedEnvKeys)
+			return command, false, nil
+		case <-ctx.Done():
+			return domain.CommandFromDB{}, false, fmt.Errorf("%s: %w", logPrefix, ctx.Err())
+		}
+
+		command, err = s.repo.ReadCommand(ctx, id)
+		if err != nil {
+			return domain.CommandFromDB{}, false, fmt.Errorf("%s: %w", logPrefix, err)
+		}
+	}
+
+	redactEnv(command.Env, s.cfg.RedactedEnvKeys)
+
+	return command, true, nil
+}
+
+func (s *bashrunService) StreamCommand(ctx context.Context, id int, lastEventID int) (<-chan domain.CommandEvent, error) {
+	const logPrefix = "service.StreamCommand"
+
+	out := make(chan domain.CommandEvent)
+
+	replay, droppedTo, subscriber, ok := s.events.subscribe(id, lastEventID)
+	if !ok {
+		command, err := s.repo.ReadCommand(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", logPrefix, err)
+		}
+
+		stdout, err := s.repo.ReadOutput(ctx, id, domain.StreamStdout, domain.OutputRange{Offset: int64(lastEventID)})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", logPrefix, err)
+		}
+
+		go func() {
+			defer close(out)
+			sendEvents(ctx, out, eventsFromCommand(command, stdout))
+		}()
+
+		return out, nil
+	}
+
+	// the output dropped from the history is read from DB, it is saved with a
+	// delay, so the part that is not saved yet is missing
+	var dropped []domain.CommandEvent
+	if droppedTo > lastEventID {
+		stdout, err := s.repo.ReadOutput(ctx, id, domain.StreamStdout, domain.OutputRange{Offset: int64(lastEventID), Limit: int64(droppedTo - lastEventID)})
+		if err != nil {
+			s.events.unsubscribe(id, subscriber)
+			return nil, fmt.Errorf("%s: %w", logPrefix, err)
+		}
+
+		dropped = outputEvents(stdout)
+	}
+
+	go func() {
+		defer close(out)
+		defer s.events.unsubscribe(id, subscriber)
+
+		if !sendEvents(ctx, out, dropped) || !sendEvents(ctx, out, replay) {
+			return
+		}
+
+		for {
+			select {
+			case stored, ok := <-subscriber:
+				if !ok {
+					return
+				}
+
+				if !stored.isAfter(lastEventID) {
+					continue
+				}
+
+				if !sendEvents(ctx, out, []domain.CommandEvent{stored.event}) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
+)
+
+const (
+	outputReadSize   = 32 * 1024
+	maxEventLineSize = 64 * 1024
+)
+
+func (s *bashrunService) captureOutput(id int, stream string, reader io.Reader, output *outputWriter) {
+	buf := make([]byte, outputReadSize)
+	var lines lineSplitter
+
+	publish := func(line string, end int64) {
+		s.events.publishOutput(id, line, end)
+	}
+
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			if !output.write(stream, string(buf[:n])) {
+				break
+			}
+
+			if stream == domain.StreamStdout {
+				lines.write(buf[:n], publish)
+			}
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	lines.flush(publish)
+
+	_, _ = io.Copy(io.Discard, reader)
+}
+
+// lineSplitter splits stdout into output events in the same way for a running
+// command and for stdout read from DB: a line ends with "\n" or is cut after
+// maxEventLineSize bytes. Each line is passed with the stdout offset right
+// after it, which is the ID of its event, so a client resuming with
+// Last-Event-ID gets the same events from memory and from DB.
+type lineSplitter struct {
+	offset  int64
+	pending []byte
+}
+
+func (l *lineSplitter) write(data []byte, emit func(line string, end int64)) {
+	l.pending = append(l.pending, data...)
+
+	for {
+		i := bytes.IndexByte(l.pending, '\n')
+
+		switch {
+		case i >= 0 && i < maxEventLineSize:
+			l.next(i+1, bytes.TrimSuffix(l.pending[:i], []byte("\r")), emit)
+		case len(l.pending) >= maxEventLineSize:
+			l.next(maxEventLineSize, l.pending[:maxEventLineSize], emit)
+		default:
+			l.pending = append([]byte(nil), l.pending...)
+			return
+		}
+	}
+}
+
+func (l *lineSplitter) flush(emit func(line string, end int64)) {
+	if len(l.pending) > 0 {
+		l.next(len(l.pending), bytes.TrimSuffix(l.pending, []byte("\r")), emit)
+	}
+}
+
+func (l *lineSplitter) next(size int, line []byte, emit func(line string, end int64)) {
+	l.offset += int64(size)
+	emit(string(line), l.offset)
+	l.pending = l.pending[size:]
+}
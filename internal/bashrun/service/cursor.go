@@ -0,0 +1,75 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	appErrors "github.com/PoorMercymain/bashrun/errors"
+	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
+)
+
+func encodeCursor(filter domain.CommandFilter, command domain.CommandFromDB) string {
+	cursor := domain.Cursor{SortBy: filter.SortBy, Descending: filter.Descending, ID: command.ID}
+
+	switch filter.SortBy {
+	case domain.SortByCreatedAt:
+		createdAt := command.CreatedAt
+		cursor.CreatedAt = &createdAt
+	case domain.SortByDuration:
+		cursor.DurationMS = command.DurationMS
+	}
+
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(filter domain.CommandFilter, token string) (*domain.Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, appErrors.ErrWrongCursor
+	}
+
+	var cursor domain.Cursor
+	if err = json.Unmarshal(data, &cursor); err != nil {
+		return nil, appErrors.ErrWrongCursor
+	}
+
+	if cursor.SortBy != filter.SortBy || cursor.Descending != filter.Descending || cursor.ID < 1 {
+		return nil, appErrors.ErrWrongCursor
+	}
+
+	if cursor.SortBy == domain.SortByCreatedAt && cursor.CreatedAt == nil {
+		return nil, appErrors.ErrWrongCursor
+	}
+
+	return &cursor, nil
+}
+
+func (s *bashrunService) applyCursor(filter *domain.CommandFilter) error {
+	if filter.After == "" && filter.Before == "" {
+		return nil
+	}
+
+	if (filter.After != "" && filter.Before != "") || filter.Offset > 0 {
+		return appErrors.ErrWrongCursor
+	}
+
+	token := filter.After
+	if filter.Before != "" {
+		token = filter.Before
+		filter.Backward = true
+	}
+
+	cursor, err := decodeCursor(*filter, token)
+	if err != nil {
+		return err
+	}
+
+	filter.Cursor = cursor
+
+	return nil
+}
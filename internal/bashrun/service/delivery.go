@@ -0,0 +1,253 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	appErrors "github.com/PoorMercymain/bashrun/errors"
+	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
+	"github.com/PoorMercymain/bashrun/pkg/logger"
+)
+
+const (
+	callbackExcerptBytes    = 4 * 1024
+	callbackBatchSize       = 16
+	missingDeliveriesLimit  = 100
+	callbackBaseBackoff     = time.Second
+	callbackMaxBackoff      = time.Hour
+	callbackLeaseMargin     = 30 * time.Second
+	defaultCallbackTimeout  = 10 * time.Second
+	defaultCallbackInterval = time.Second
+	defaultCallbackAttempts = 10
+
+	signatureHeader = "X-Bashrun-Signature"
+	deliveryHeader  = "X-Bashrun-Delivery"
+)
+
+func validateCallback(command domain.CommandFromUser) error {
+	if command.CallbackURL == "" {
+		if command.CallbackSecret != "" {
+			return appErrors.ErrWrongCallback
+		}
+
+		return nil
+	}
+
+	callbackURL, err := url.Parse(command.CallbackURL)
+	if err != nil || (callbackURL.Scheme != "http" && callbackURL.Scheme != "https") || callbackURL.Host == "" {
+		return appErrors.ErrWrongCallback
+	}
+
+	return nil
+}
+
+func (s *bashrunService) enqueueCallback(id int, callbackURL string) {
+	const logPrefix = "service.enqueueCallback"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	command, err := s.repo.ReadCommand(ctx, id)
+	if err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+		return
+	}
+
+	payload := domain.CallbackPayload{
+		CommandID:   command.ID,
+		Status:      command.Status,
+		Error:       command.Error,
+		ExitStatus:  command.ExitStatus,
+		CreatedAt:   command.CreatedAt,
+		StartedAt:   command.StartedAt,
+		FinishedAt:  command.FinishedAt,
+		QueuedMS:    command.QueuedMS,
+		DurationMS:  command.DurationMS,
+		UserCPUMS:   command.UserCPUMS,
+		SystemCPUMS: command.SystemCPUMS,
+		OutputBytes: command.OutputBytes,
+		Truncated:   command.Truncated,
+	}
+
+	output, err := s.repo.ReadOutput(ctx, id, domain.StreamStdout, domain.OutputRange{Suffix: callbackExcerptBytes})
+	if err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+		return
+	}
+
+	stderr, err := s.repo.ReadOutput(ctx, id, domain.StreamStderr, domain.OutputRange{Suffix: callbackExcerptBytes})
+	if err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+		return
+	}
+
+	payload.Output, payload.Stderr = output.Data, stderr.Data
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+		return
+	}
+
+	err = s.repo.CreateDelivery(ctx, id, callbackURL, body)
+	if err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+	}
+}
+
+// enqueueMissingCallbacks saves the callbacks of finished commands that were
+// not saved after the finish, so a restart between the final status and the
+// delivery does not lose the callback.
+func (s *bashrunService) enqueueMissingCallbacks(ctx context.Context) {
+	const logPrefix = "service.enqueueMissingCallbacks"
+
+	deliveries, err := s.repo.ListMissingDeliveries(ctx, missingDeliveriesLimit)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Logger().Error(logPrefix, ": ", err.Error())
+		}
+
+		return
+	}
+
+	for _, delivery := range deliveries {
+		s.enqueueCallback(delivery.CommandID, delivery.URL)
+	}
+}
+
+func (s *bashrunService) DeliverCallbacks(ctx context.Context) {
+	interval := time.Duration(s.cfg.CallbackPollInterval) * time.Millisecond
+	if interval <= 0 {
+		interval = defaultCallbackInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.deliverPending(ctx)
+		}
+	}
+}
+
+func (s *bashrunService) deliverPending(ctx context.Context) {
+	const logPrefix = "service.deliverPending"
+
+	deliveries, err := s.repo.ClaimDeliveries(ctx, callbackBatchSize, s.client.Timeout+callbackLeaseMargin)
+	if err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery domain.Delivery) {
+			defer wg.Done()
+
+			err := s.repo.UpdateDelivery(ctx, delivery.ID, s.deliver(ctx, delivery))
+			if err != nil {
+				logger.Logger().Error(logPrefix, ": ", err.Error())
+			}
+		}(delivery)
+	}
+
+	wg.Wait()
+}
+
+func (s *bashrunService) deliver(ctx context.Context, delivery domain.Delivery) domain.DeliveryAttempt {
+	attempt := domain.DeliveryAttempt{Status: domain.DeliveryStatusDelivered}
+
+	responseStatus, err := s.post(ctx, delivery)
+	attempt.AttemptedAt = time.Now()
+	if responseStatus != 0 {
+		attempt.ResponseStatus = &responseStatus
+	}
+
+	if err == nil {
+		return attempt
+	}
+
+	attempt.Error = err.Error()
+
+	maxAttempts := s.cfg.CallbackMaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultCallbackAttempts
+	}
+
+	if delivery.Attempts+1 >= maxAttempts {
+		attempt.Status = domain.DeliveryStatusFailed
+		return attempt
+	}
+
+	nextAttemptAt := attempt.AttemptedAt.Add(callbackBackoff(delivery.Attempts + 1))
+	attempt.Status, attempt.NextAttemptAt = domain.DeliveryStatusPending, &nextAttemptAt
+
+	return attempt
+}
+
+func (s *bashrunService) post(ctx context.Context, delivery domain.Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(deliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	if delivery.Secret != "" {
+		req.Header.Set(signatureHeader, "sha256="+signPayload(delivery.Secret, delivery.Payload))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+func signPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func callbackBackoff(attempts int) time.Duration {
+	backoff := callbackBaseBackoff
+	for i := 1; i < attempts && backoff < callbackMaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, callbackMaxBackoff)
+}
+
+func (s *bashrunService) ListDeliveries(ctx context.Context, id int) ([]domain.Delivery, error) {
+	const logPrefix = "service.ListDeliveries"
+
+	deliveries, err := s.repo.ListDeliveries(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return deliveries, nil
+}
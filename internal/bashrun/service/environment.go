@@ -0,0 +1,81 @@
+package service
+
+import (
+	"encoding/base64"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	appErrors "github.com/PoorMercymain/bashrun/errors"
+	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
+)
+
+const redactedValue = "***"
+
+func prepareEnvironment(command *domain.CommandFromUser) error {
+	for key, value := range command.Env {
+		if key == "" || strings.ContainsAny(key, "=\x00") || strings.ContainsRune(value, 0) {
+			return appErrors.ErrWrongEnv
+		}
+	}
+
+	if command.Workdir != "" {
+		info, err := os.Stat(command.Workdir)
+		if err != nil || !info.IsDir() {
+			return appErrors.ErrWrongWorkdir
+		}
+	}
+
+	if command.StdinBase64 != "" {
+		if command.Stdin != "" {
+			return appErrors.ErrWrongStdin
+		}
+
+		stdin, err := base64.StdEncoding.DecodeString(command.StdinBase64)
+		if err != nil {
+			return appErrors.ErrWrongStdin
+		}
+
+		command.Stdin = string(stdin)
+		command.StdinBase64 = ""
+	}
+
+	return nil
+}
+
+func applyEnvironment(cmd *exec.Cmd, command domain.CommandFromUser) {
+	cmd.Dir = command.Workdir
+
+	if command.ClearEnv {
+		cmd.Env = make([]string, 0, len(command.Env))
+	} else {
+		cmd.Env = os.Environ()
+	}
+
+	keys := make([]string, 0, len(command.Env))
+	for key := range command.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		cmd.Env = append(cmd.Env, key+"="+command.Env[key])
+	}
+
+	if command.Stdin != "" {
+		cmd.Stdin = strings.NewReader(command.Stdin)
+	}
+}
+
+func redactEnv(env map[string]string, redactedKeys []string) {
+	for key := range env {
+		upperKey := strings.ToUpper(key)
+		for _, redactedKey := range redactedKeys {
+			if redactedKey != "" && strings.Contains(upperKey, strings.ToUpper(redactedKey)) {
+				env[key] = redactedValue
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
+)
+
+const (
+	eventTypeOutput = "output"
+	eventTypeStatus = "status"
+	eventTypeExit   = "exit"
+
+	subscriberBufferSize = 256
+	eventHistorySize     = 1024
+)
+
+type storedEvent struct {
+	event    domain.CommandEvent
+	position int
+}
+
+func (e storedEvent) isAfter(lastEventID int) bool {
+	return e.event.ID > lastEventID || (e.event.ID == 0 && e.position >= lastEventID)
+}
+
+// commandEvents keeps the latest events of a command in a ring buffer, the
+// ID of an output event is the stdout offset after it, so output dropped from
+// the history can be read from DB.
+type commandEvents struct {
+	history     []storedEvent
+	first       int
+	offset      int
+	droppedTo   int
+	subscribers map[chan storedEvent]struct{}
+	done        chan struct{}
+}
+
+func (e *commandEvents) remember(stored storedEvent) {
+	if len(e.history) < eventHistorySize {
+		e.history = append(e.history, stored)
+		return
+	}
+
+	if dropped := e.history[e.first]; dropped.event.Type == eventTypeOutput {
+		e.droppedTo = dropped.event.ID
+	}
+
+	e.history[e.first] = stored
+	e.first = (e.first + 1) % len(e.history)
+}
+
+type eventHub struct {
+	mu       sync.Mutex
+	commands map[int]*commandEvents
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{commands: make(map[int]*commandEvents)}
+}
+
+func (h *eventHub) open(id int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.commands[id]; ok {
+		return
+	}
+
+	h.commands[id] = &commandEvents{subscribers: make(map[chan storedEvent]struct{}), done: make(chan struct{})}
+}
+
+func (h *eventHub) publishOutput(id int, line string, end int64) {
+	h.publish(id, domain.CommandEvent{ID: int(end), Type: eventTypeOutput, Data: strings.ToValidUTF8(line, "\uFFFD")})
+}
+
+func (h *eventHub) publishStatus(id int, status domain.CommandStatus) {
+	h.publish(id, domain.CommandEvent{Type: eventTypeStatus, Data: string(status)})
+}
+
+func (h *eventHub) publishExit(id int, exitStatus int) {
+	h.publish(id, domain.CommandEvent{Type: eventTypeExit, Data: strconv.Itoa(exitStatus)})
+}
+
+func (h *eventHub) publish(id int, event domain.CommandEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	events, ok := h.commands[id]
+	if !ok {
+		return
+	}
+
+	if event.Type == eventTypeOutput {
+		events.offset = event.ID
+	}
+
+	stored := storedEvent{event: event, position: events.offset}
+	events.remember(stored)
+
+	for subscriber := range events.subscribers {
+		select {
+		case subscriber <- stored:
+		default:
+			// slow subscriber is disconnected, it can resume using Last-Event-ID
+			delete(events.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+}
+
+func (h *eventHub) close(id int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	events, ok := h.commands[id]
+	if !ok {
+		return
+	}
+
+	for subscriber := range events.subscribers {
+		close(subscriber)
+	}
+
+	close(events.done)
+	delete(h.commands, id)
+}
+
+func (h *eventHub) finished(id int) (<-chan struct{}, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	events, ok := h.commands[id]
+	if !ok {
+		return nil, false
+	}
+
+	return events.done, true
+}
+
+// subscribe returns the events after lastEventID kept in the history and the
+// offset up to which the output after lastEventID was dropped from it and has
+// to be read from DB, it is not greater than lastEventID if nothing was dropped.
+func (h *eventHub) subscribe(id int, lastEventID int) ([]domain.CommandEvent, int, chan storedEvent, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	events, ok := h.commands[id]
+	if !ok {
+		return nil, 0, nil, false
+	}
+
+	replay := make([]domain.CommandEvent, 0, len(events.history))
+	for i := range events.history {
+		stored := events.history[(events.first+i)%len(events.history)]
+		if stored.isAfter(lastEventID) {
+			replay = append(replay, stored.event)
+		}
+	}
+
+	subscriber := make(chan storedEvent, subscriberBufferSize)
+	events.subscribers[subscriber] = struct{}{}
+
+	return replay, events.droppedTo, subscriber, true
+}
+
+func (h *eventHub) unsubscribe(id int, subscriber chan storedEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	events, ok := h.commands[id]
+	if !ok {
+		return
+	}
+
+	if _, ok = events.subscribers[subscriber]; ok {
+		delete(events.subscribers, subscriber)
+		close(subscriber)
+	}
+}
+
+// outputEvents splits stdout read from DB into output events with the same IDs
+// the events of a running command have.
+func outputEvents(stdout domain.OutputSlice) []domain.CommandEvent {
+	events := make([]domain.CommandEvent, 0)
+
+	lines := lineSplitter{offset: stdout.Start}
+	emit := func(line string, end int64) {
+		events = append(events, domain.CommandEvent{ID: int(end), Type: eventTypeOutput, Data: strings.ToValidUTF8(line, "\uFFFD")})
+	}
+
+	lines.write([]byte(stdout.Data), emit)
+	lines.flush(emit)
+
+	return events
+}
+
+func eventsFromCommand(command domain.CommandFromDB, stdout domain.OutputSlice) []domain.CommandEvent {
+	events := outputEvents(stdout)
+
+	if command.ExitStatus != nil {
+		events = append(events, domain.CommandEvent{Type: eventTypeExit, Data: strconv.Itoa(*command.ExitStatus)})
+	}
+
+	events = append(events, domain.CommandEvent{Type: eventTypeStatus, Data: string(command.Status)})
+
+	return events
+}
+
+func sendEvents(ctx context.Context, out chan<- domain.CommandEvent, events []domain.CommandEvent) bool {
+	for _, event := range events {
+		select {
+		case out <- event:
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	return true
+}
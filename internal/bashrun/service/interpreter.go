@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+
+	appErrors "github.com/PoorMercymain/bashrun/errors"
+	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
+)
+
+const (
+	defaultInterpreter     = "sh"
+	defaultInterpreterFlag = "-c"
+)
+
+var builtinInterpreters = map[string]string{
+	"sh":      "-c",
+	"bash":    "-c",
+	"zsh":     "-c",
+	"python3": "-c",
+}
+
+func (s *bashrunService) interpreterFlag(interpreter string) (string, bool) {
+	if flag, ok := builtinInterpreters[interpreter]; ok {
+		return flag, true
+	}
+
+	for _, allowed := range s.cfg.AllowedInterpreters {
+		path, flag, found := strings.Cut(allowed, ":")
+		if !found {
+			flag = defaultInterpreterFlag
+		}
+
+		if path != "" && path == interpreter {
+			return flag, true
+		}
+	}
+
+	return "", false
+}
+
+func (s *bashrunService) prepareInterpreter(command *domain.CommandFromUser) error {
+	if len(command.Argv) > 0 {
+		if command.Command != "" || command.Script != "" || command.Interpreter != "" || command.Argv[0] == "" {
+			return appErrors.ErrWrongArgv
+		}
+
+		return nil
+	}
+
+	if command.Script != "" && command.Interpreter == "" && hasShebang(command.Script) {
+		return nil
+	}
+
+	if command.Interpreter == "" {
+		command.Interpreter = defaultInterpreter
+	}
+
+	if _, ok := s.interpreterFlag(command.Interpreter); !ok {
+		return appErrors.ErrWrongInterpreter
+	}
+
+	if _, err := exec.LookPath(command.Interpreter); err != nil {
+		return appErrors.ErrWrongInterpreter
+	}
+
+	return nil
+}
+
+func (s *bashrunService) newCmd(ctx context.Context, command domain.CommandFromUser) (*exec.Cmd, func(), error) {
+	if len(command.Argv) > 0 {
+		return exec.CommandContext(ctx, command.Argv[0], command.Argv[1:]...), func() {}, nil
+	}
+
+	if command.Script != "" {
+		return s.newScriptCmd(ctx, command.Script, command.Interpreter, command.ScriptArgs)
+	}
+
+	interpreter := command.Interpreter
+	if interpreter == "" {
+		interpreter = defaultInterpreter
+	}
+
+	flag, ok := s.interpreterFlag(interpreter)
+	if !ok {
+		return nil, nil, appErrors.ErrWrongInterpreter
+	}
+
+	return exec.CommandContext(ctx, interpreter, flag, command.Command), func() {}, nil
+}
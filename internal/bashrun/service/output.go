@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PoorMercymain/bashrun/internal/bashrun/config"
+	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
+)
+
+const (
+	defaultOutputFlushBytes    = 64 * 1024
+	defaultOutputFlushInterval = 200 * time.Millisecond
+	defaultOutputBufferBytes   = 8 * 1024 * 1024
+	outputBufferChunks         = 1024
+)
+
+type outputWriter struct {
+	ctx           context.Context
+	repo          domain.BashrunRepository
+	id            int
+	chunks        chan domain.OutputChunk
+	done          chan struct{}
+	bufferMu      sync.Mutex
+	bufferFreed   *sync.Cond
+	bufferBytes   int64
+	buffered      int64
+	flushBytes    int
+	flushInterval time.Duration
+	seq           int64
+	offsets       map[string]int64
+	err           error
+	failed        atomic.Bool
+	maxBytes      int64
+	policy        string
+	onOverflow    func()
+	overflowed    bool
+	totalBytes    int64
+	storedBytes   int64
+	stored        []storedChunk
+	flushedSeq    int64
+	trim          domain.OutputTrim
+	trimPending   bool
+	statsPending  bool
+}
+
+type storedChunk struct {
+	seq  int64
+	size int64
+}
+
+func newOutputWriter(ctx context.Context, repo domain.BashrunRepository, id int, cfg *config.Config, command domain.CommandFromUser, onOverflow func()) *outputWriter {
+	flushBytes := cfg.OutputFlushBytes
+	if flushBytes <= 0 {
+		flushBytes = defaultOutputFlushBytes
+	}
+
+	flushInterval := time.Duration(cfg.OutputFlushIntervalMS) * time.Millisecond
+	if flushInterval <= 0 {
+		flushInterval = defaultOutputFlushInterval
+	}
+
+	bufferBytes := cfg.OutputBufferBytes
+	if bufferBytes <= 0 {
+		bufferBytes = defaultOutputBufferBytes
+	}
+
+	w := &outputWriter{
+		ctx:           ctx,
+		repo:          repo,
+		id:            id,
+		chunks:        make(chan domain.OutputChunk, outputBufferChunks),
+		done:          make(chan struct{}),
+		bufferBytes:   bufferBytes,
+		flushBytes:    flushBytes,
+		flushInterval: flushInterval,
+		offsets:       make(map[string]int64),
+		policy:        command.OutputPolicy,
+		onOverflow:    onOverflow,
+	}
+
+	if command.MaxOutputBytes != nil {
+		w.maxBytes = *command.MaxOutputBytes
+	}
+
+	w.bufferFreed = sync.NewCond(&w.bufferMu)
+
+	go w.run()
+
+	return w
+}
+
+func (w *outputWriter) write(stream string, data string) bool {
+	if w.failed.Load() {
+		return false
+	}
+
+	w.reserve(int64(len(data)))
+	w.chunks <- domain.OutputChunk{Stream: stream, Data: data}
+
+	return true
+}
+
+// reserve waits until the chunk fits into the buffer, so the output that is
+// not saved to DB yet takes at most bufferBytes. A chunk larger than the whole
+// buffer is let in when the buffer is empty.
+func (w *outputWriter) reserve(size int64) {
+	w.bufferMu.Lock()
+	defer w.bufferMu.Unlock()
+
+	for w.buffered > 0 && w.buffered+size > w.bufferBytes {
+		w.bufferFreed.Wait()
+	}
+
+	w.buffered += size
+}
+
+func (w *outputWriter) release(size int64) {
+	w.bufferMu.Lock()
+	w.buffered -= size
+	w.bufferMu.Unlock()
+
+	w.bufferFreed.Broadcast()
+}
+
+func (w *outputWriter) close() error {
+	close(w.chunks)
+	<-w.done
+
+	if w.err != nil {
+		return w.err
+	}
+
+	return w.repo.UpdateOutputStats(w.ctx, w.id, domain.OutputStats{Bytes: w.totalBytes, Truncated: w.overflowed})
+}
+
+func (w *outputWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	var batch []domain.OutputChunk
+	var batchBytes int
+	var reserved int64
+
+	for {
+		select {
+		case chunk, ok := <-w.chunks:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+
+			size := int64(len(chunk.Data))
+
+			chunk, ok = w.limit(chunk)
+			if !ok {
+				w.release(size)
+				continue
+			}
+
+			reserved += size
+
+			chunk = w.sequence(chunk)
+			batch = append(batch, chunk)
+			batchBytes += len(chunk.Data)
+
+			if w.policy == domain.OutputPolicyTail && w.maxBytes > 0 {
+				batch = w.trimTail(batch)
+			}
+
+			if batchBytes >= w.flushBytes {
+				w.flush(batch)
+				w.release(reserved)
+				batch, batchBytes, reserved = nil, 0, 0
+			}
+		case <-ticker.C:
+			if len(batch) > 0 || reserved > 0 || w.trimPending || w.statsPending {
+				w.flush(batch)
+				w.release(reserved)
+				batch, batchBytes, reserved = nil, 0, 0
+			}
+		}
+	}
+}
+
+func (w *outputWriter) flush(batch []domain.OutputChunk) {
+	if w.failed.Load() {
+		return
+	}
+
+	if len(batch) > 0 {
+		if err := w.repo.AppendOutput(w.ctx, w.id, batch); err != nil {
+			w.fail(err)
+			return
+		}
+
+		w.flushedSeq = batch[len(batch)-1].Seq
+	}
+
+	if w.trimPending {
+		if err := w.repo.TrimOutput(w.ctx, w.id, w.trim); err != nil {
+			w.fail(err)
+			return
+		}
+
+		w.trim.Bytes, w.trimPending = 0, false
+	}
+
+	if w.statsPending {
+		if err := w.repo.UpdateOutputStats(w.ctx, w.id, domain.OutputStats{Bytes: w.totalBytes, Truncated: w.overflowed}); err != nil {
+			w.fail(err)
+			return
+		}
+
+		w.statsPending = false
+	}
+}
+
+func (w *outputWriter) fail(err error) {
+	w.err = err
+	w.failed.Store(true)
+}
+
+func (w *outputWriter) limit(chunk domain.OutputChunk) (domain.OutputChunk, bool) {
+	w.totalBytes += int64(len(chunk.Data))
+
+	if w.maxBytes > 0 && w.policy != domain.OutputPolicyTail && w.storedBytes+int64(len(chunk.Data)) > w.maxBytes {
+		chunk.Data = chunk.Data[:w.maxBytes-w.storedBytes]
+
+		if !w.overflowed {
+			w.overflowed, w.statsPending = true, true
+
+			if w.policy == domain.OutputPolicyKill {
+				w.onOverflow()
+			}
+		}
+	}
+
+	if len(chunk.Data) == 0 {
+		return chunk, false
+	}
+
+	w.storedBytes += int64(len(chunk.Data))
+
+	return chunk, true
+}
+
+func (w *outputWriter) trimTail(batch []domain.OutputChunk) []domain.OutputChunk {
+	last := batch[len(batch)-1]
+	w.stored = append(w.stored, storedChunk{seq: last.Seq, size: int64(len(last.Data))})
+
+	for w.storedBytes > w.maxBytes {
+		if !w.overflowed {
+			w.overflowed, w.statsPending = true, true
+		}
+
+		front := &w.stored[0]
+		cut := min(w.storedBytes-w.maxBytes, front.size)
+		front.size -= cut
+		w.storedBytes -= cut
+
+		if front.seq > w.flushedSeq {
+			i := slices.IndexFunc(batch, func(chunk domain.OutputChunk) bool { return chunk.Seq == front.seq })
+			batch[i].Data = batch[i].Data[cut:]
+			batch[i].StreamOffset += cut
+
+			if front.size == 0 {
+				batch = slices.Delete(batch, i, i+1)
+			}
+		} else {
+			if w.trim.Seq != front.seq {
+				w.trim = domain.OutputTrim{Seq: front.seq}
+			}
+
+			w.trim.Bytes += cut
+			w.trimPending = true
+
+			if front.size == 0 {
+				w.trim = domain.OutputTrim{Seq: front.seq + 1}
+			}
+		}
+
+		if front.size == 0 {
+			w.stored = w.stored[1:]
+		}
+	}
+
+	return batch
+}
+
+func (w *outputWriter) sequence(chunk domain.OutputChunk) domain.OutputChunk {
+	w.seq++
+	chunk.Seq = w.seq
+	chunk.StreamOffset = w.offsets[chunk.Stream]
+	w.offsets[chunk.Stream] += int64(len(chunk.Data))
+
+	return chunk
+}
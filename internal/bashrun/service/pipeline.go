@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appErrors "github.com/PoorMercymain/bashrun/errors"
+	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
+	"github.com/PoorMercymain/bashrun/pkg/logger"
+)
+
+const (
+	maxPipelineSteps        = 100
+	defaultPipelineInputEnv = "PREVIOUS_STDOUT"
+)
+
+func (s *bashrunService) preparePipeline(pipeline *domain.PipelineFromUser) error {
+	if len(pipeline.Steps) == 0 || len(pipeline.Steps) > maxPipelineSteps {
+		return appErrors.ErrWrongPipeline
+	}
+
+	for i := range pipeline.Steps {
+		step := &pipeline.Steps[i]
+
+		if step.Command.RunAt != nil || step.Command.DelaySeconds != nil {
+			return appErrors.ErrWrongPipeline
+		}
+
+		err := s.prepareCommand(&step.Command)
+		if err != nil {
+			return err
+		}
+
+		switch step.Input {
+		case "":
+			if step.InputEnv != "" {
+				return appErrors.ErrWrongPipeline
+			}
+		case domain.PipelineInputStdin:
+			if step.InputEnv != "" || step.Command.Stdin != "" {
+				return appErrors.ErrWrongPipeline
+			}
+		case domain.PipelineInputEnv:
+			if step.InputEnv == "" {
+				step.InputEnv = defaultPipelineInputEnv
+			}
+
+			if _, ok := step.Command.Env[step.InputEnv]; ok || strings.ContainsAny(step.InputEnv, "=\x00") {
+				return appErrors.ErrWrongPipeline
+			}
+		default:
+			return appErrors.ErrWrongPipeline
+		}
+
+		if i == 0 && step.Input != "" {
+			return appErrors.ErrWrongPipeline
+		}
+	}
+
+	return nil
+}
+
+func (s *bashrunService) CreatePipeline(ctx context.Context, pipeline domain.PipelineFromUser) (int, error) {
+	const logPrefix = "service.CreatePipeline"
+
+	err := s.preparePipeline(&pipeline)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	id, advance, err := s.repo.CreatePipeline(ctx, pipeline, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	if advance.Started != nil {
+		s.commandCreated(advance.Started.ID, advance.Started.Status)
+	}
+
+	return id, nil
+}
+
+func (s *bashrunService) ListPipelines(ctx context.Context) ([]domain.Pipeline, error) {
+	const logPrefix = "service.ListPipelines"
+
+	pipelines, err := s.repo.ListPipelines(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return pipelines, nil
+}
+
+func (s *bashrunService) ReadPipeline(ctx context.Context, id int) (domain.Pipeline, error) {
+	const logPrefix = "service.ReadPipeline"
+
+	pipeline, err := s.repo.ReadPipeline(ctx, id)
+	if err != nil {
+		return domain.Pipeline{}, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return pipeline, nil
+}
+
+// advanceFlows is called when a command reaches a final status and moves its
+// workflow or pipeline forward.
+func (s *bashrunService) advanceFlows(workflowID *int, pipelineID *int) {
+	if workflowID != nil {
+		s.advanceWorkflow(*workflowID)
+	}
+
+	if pipelineID != nil {
+		s.advancePipeline(*pipelineID)
+	}
+}
+
+func (s *bashrunService) advancePipeline(id int) {
+	const logPrefix = "service.advancePipeline"
+
+	c, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	advance, err := s.repo.AdvancePipeline(c, id, time.Now())
+	if err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+		return
+	}
+
+	if advance.Started != nil {
+		s.commandCreated(advance.Started.ID, advance.Started.Status)
+	}
+}
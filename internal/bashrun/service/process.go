@@ -0,0 +1,214 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	appErrors "github.com/PoorMercymain/bashrun/errors"
+)
+
+const (
+	processGroupPollInterval = 50 * time.Millisecond
+	clockTicksPerSecond      = 100
+	startTimeTolerance       = 2 * time.Second
+)
+
+var stopSignals = map[string]syscall.Signal{
+	"TERM": syscall.SIGTERM,
+	"INT":  syscall.SIGINT,
+	"HUP":  syscall.SIGHUP,
+	"KILL": syscall.SIGKILL,
+}
+
+func parseStopSignal(name string) (syscall.Signal, error) {
+	if name == "" {
+		return syscall.SIGKILL, nil
+	}
+
+	sig, ok := stopSignals[strings.TrimPrefix(strings.ToUpper(name), "SIG")]
+	if !ok {
+		return 0, appErrors.ErrWrongSignal
+	}
+
+	return sig, nil
+}
+
+func startInProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+func terminateProcessGroup(pgid int, killGrace time.Duration, done <-chan struct{}) {
+	_ = syscall.Kill(-pgid, syscall.SIGTERM)
+
+	select {
+	case <-done:
+	case <-time.After(killGrace):
+		_ = syscall.Kill(-pgid, syscall.SIGKILL)
+	}
+}
+
+// signalProcessGroup reports whether the signal was delivered, a group that
+// has already exited is not an error.
+func signalProcessGroup(pgid int, sig syscall.Signal) (bool, error) {
+	err := syscall.Kill(-pgid, sig)
+	if errors.Is(err, syscall.ESRCH) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func waitProcessGroupExit(pgid int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		if !processGroupAlive(pgid) {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return appErrors.ErrProcessGroupAlive
+		}
+
+		time.Sleep(processGroupPollInterval)
+	}
+}
+
+// processGroupAlive ignores zombies, an orphaned member of the group may stay
+// unreaped for a while when the init process of a container does not reap it.
+func processGroupAlive(pgid int) bool {
+	if errors.Is(syscall.Kill(-pgid, 0), syscall.ESRCH) {
+		return false
+	}
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return true
+	}
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		state, group, err := processState(pid)
+		if err == nil && group == pgid && state != "Z" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func processMatches(pid int, startedAt time.Time) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	startTime, pgid, err := processStartTime(pid)
+	if err != nil || pgid != pid {
+		return false
+	}
+
+	diff := startTime.Sub(startedAt)
+	return diff > -startTimeTolerance && diff < startTimeTolerance
+}
+
+// processStat returns the fields of /proc/<pid>/stat after the command name.
+func processStat(pid int) ([]string, error) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return nil, err
+	}
+
+	end := strings.LastIndexByte(string(stat), ')')
+	if end < 0 {
+		return nil, fmt.Errorf("unexpected format of /proc/%d/stat", pid)
+	}
+
+	fields := strings.Fields(string(stat[end+1:]))
+	if len(fields) < 20 {
+		return nil, fmt.Errorf("unexpected format of /proc/%d/stat", pid)
+	}
+
+	return fields, nil
+}
+
+func processState(pid int) (string, int, error) {
+	fields, err := processStat(pid)
+	if err != nil {
+		return "", 0, err
+	}
+
+	pgid, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return "", 0, err
+	}
+
+	return fields[0], pgid, nil
+}
+
+func processStartTime(pid int) (time.Time, int, error) {
+	fields, err := processStat(pid)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	if fields[0] == "Z" {
+		return time.Time{}, 0, fmt.Errorf("process %d is a zombie", pid)
+	}
+
+	pgid, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	ticks, err := strconv.ParseInt(fields[19], 10, 64)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	bootTime, err := systemBootTime()
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	return bootTime.Add(time.Duration(ticks) * time.Second / clockTicksPerSecond), pgid, nil
+}
+
+func systemBootTime() (time.Time, error) {
+	stat, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	for _, line := range strings.Split(string(stat), "\n") {
+		value, ok := strings.CutPrefix(line, "btime ")
+		if !ok {
+			continue
+		}
+
+		seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		return time.Unix(seconds, 0), nil
+	}
+
+	return time.Time{}, errors.New("btime is missing in /proc/stat")
+}
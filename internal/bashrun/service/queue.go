@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall"
+	"time"
+
+	appErrors "github.com/PoorMercymain/bashrun/errors"
+	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
+	"github.com/PoorMercymain/bashrun/pkg/logger"
+)
+
+const (
+	minPriority = -1000
+	maxPriority = 1000
+
+	defaultQueuePollInterval = time.Second
+)
+
+func (s *bashrunService) RunWorkers(ctx context.Context) {
+	workers := int(s.cfg.MaxConcurrentCommands)
+	if workers <= 0 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		s.promoteScheduled(ctx)
+	}()
+
+	go func() {
+		defer wg.Done()
+		s.renewLeases(ctx)
+	}()
+
+	go func() {
+		defer wg.Done()
+		s.watchStopRequests(ctx)
+	}()
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(ctx)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (s *bashrunService) work(ctx context.Context) {
+	const logPrefix = "service.work"
+
+	ticker := time.NewTicker(s.queuePollInterval())
+	defer ticker.Stop()
+
+	for ctx.Err() == nil {
+		queued, err := s.repo.ClaimCommand(ctx, s.instanceID, s.leaseDuration())
+		if err == nil {
+			s.notifyWorkers()
+			s.wg.Add(1)
+			s.run(queued)
+			continue
+		}
+
+		if !errors.Is(err, appErrors.ErrNoRows) && ctx.Err() == nil {
+			logger.Logger().Error(logPrefix, ": ", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *bashrunService) queuePollInterval() time.Duration {
+	interval := time.Duration(s.cfg.QueuePollInterval) * time.Millisecond
+	if interval <= 0 {
+		interval = defaultQueuePollInterval
+	}
+
+	return interval
+}
+
+// promoteScheduled queues delayed commands when their run_at comes. The due
+// time is stored in the cmd table, so commands delayed before a restart are
+// promoted by whichever instance polls first.
+func (s *bashrunService) promoteScheduled(ctx context.Context) {
+	const logPrefix = "service.promoteScheduled"
+
+	ticker := time.NewTicker(s.queuePollInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ids, err := s.repo.PromoteScheduledCommands(ctx, time.Now())
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Logger().Error(logPrefix, ": ", err.Error())
+			}
+
+			continue
+		}
+
+		for _, id := range ids {
+			s.events.publishStatus(id, domain.StatusCreated)
+		}
+
+		if len(ids) > 0 {
+			s.notifyWorkers()
+		}
+	}
+}
+
+// watchStopRequests signals the processes of this instance that were
+// stopped through other instances.
+func (s *bashrunService) watchStopRequests(ctx context.Context) {
+	const logPrefix = "service.watchStopRequests"
+
+	ticker := time.NewTicker(s.queuePollInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		requests, err := s.repo.ClaimStopRequests(ctx, s.instanceID)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Logger().Error(logPrefix, ": ", err.Error())
+			}
+
+			continue
+		}
+
+		for _, request := range requests {
+			if pid, ok := s.processes.Load(request.ID); ok {
+				s.signalCommand(request.ID, pid.(int), syscall.Signal(request.Signal))
+			}
+		}
+	}
+}
+
+func (s *bashrunService) notifyWorkers() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
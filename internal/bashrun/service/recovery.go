@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	appErrors "github.com/PoorMercymain/bashrun/errors"
+	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
+	"github.com/PoorMercymain/bashrun/pkg/logger"
+)
+
+const (
+	detachedPollInterval = time.Second
+	defaultLeaseDuration = 30 * time.Second
+
+	lostStartedReason  = "the service was restarted while the command was running"
+	lostCreatedReason  = "the service was restarted before the command was started"
+	lostDetachedReason = "the command finished while detached from the service, its exit status is unknown"
+	lostExpiredReason  = "the service instance that claimed the command stopped renewing its lease"
+)
+
+func (s *bashrunService) leaseDuration() time.Duration {
+	lease := time.Duration(s.cfg.LeaseSeconds) * time.Second
+	if lease <= 0 {
+		lease = defaultLeaseDuration
+	}
+
+	return lease
+}
+
+func (s *bashrunService) recoverPolicy() (string, error) {
+	policy := s.cfg.RecoverCreatedPolicy
+	if policy == "" {
+		policy = domain.RecoverPolicyRequeue
+	}
+
+	if policy != domain.RecoverPolicyRequeue && policy != domain.RecoverPolicyExpire {
+		return "", appErrors.ErrWrongRecoverPolicy
+	}
+
+	return policy, nil
+}
+
+// RecoverCommands recovers the commands claimed by this instance before a
+// restart and the ones left by other instances whose leases have expired,
+// then saves the callbacks that were lost between a finish and the delivery.
+func (s *bashrunService) RecoverCommands(ctx context.Context) (domain.RecoverySummary, error) {
+	const logPrefix = "service.RecoverCommands"
+
+	policy, err := s.recoverPolicy()
+	if err != nil {
+		return domain.RecoverySummary{}, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	commands, err := s.repo.ListActiveCommands(ctx, s.instanceID)
+	if err != nil {
+		return domain.RecoverySummary{}, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	summary, err := s.recover(ctx, commands, policy)
+	if err != nil {
+		return summary, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	stalled, err := s.repo.ListStalledAttempts(ctx)
+	if err != nil {
+		return summary, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	for _, id := range stalled {
+		s.finishAttempt(id)
+	}
+
+	workflows, err := s.repo.ListRunningWorkflows(ctx)
+	if err != nil {
+		return summary, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	for _, id := range workflows {
+		s.advanceWorkflow(id)
+	}
+
+	pipelines, err := s.repo.ListRunningPipelines(ctx)
+	if err != nil {
+		return summary, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	for _, id := range pipelines {
+		s.advancePipeline(id)
+	}
+
+	s.enqueueMissingCallbacks(ctx)
+
+	return summary, nil
+}
+
+// recover handles claimed commands whose instance is gone. Only a process
+// started by this instance can be found in /proc, a command of another
+// instance is lost.
+func (s *bashrunService) recover(ctx context.Context, commands []domain.ActiveCommand, policy string) (domain.RecoverySummary, error) {
+	maxAge := time.Duration(s.cfg.RecoverMaxAgeSeconds) * time.Second
+
+	var summary domain.RecoverySummary
+	for _, command := range commands {
+		own := command.Owner == s.instanceID
+
+		if command.Status == domain.StatusStarted {
+			if own && command.StartedAt != nil && processMatches(command.PID, *command.StartedAt) {
+				s.watchDetached(command, *command.StartedAt)
+				summary.Reattached++
+				continue
+			}
+
+			reason := lostStartedReason
+			if !own {
+				reason = lostExpiredReason
+			}
+
+			lost, err := s.markLost(ctx, command, domain.StatusStarted, reason)
+			if err != nil {
+				return summary, err
+			}
+
+			if lost {
+				summary.Lost++
+			}
+
+			continue
+		}
+
+		if policy == domain.RecoverPolicyExpire || (maxAge > 0 && time.Since(command.CreatedAt) > maxAge) {
+			reason := lostCreatedReason
+			if !own {
+				reason = lostExpiredReason
+			}
+
+			expired, err := s.markLost(ctx, command, domain.StatusCreated, reason)
+			if err != nil {
+				return summary, err
+			}
+
+			if expired {
+				summary.Expired++
+			}
+
+			continue
+		}
+
+		err := s.repo.RequeueCommand(ctx, command.ID, s.instanceID)
+		if errors.Is(err, appErrors.ErrRowsNotAffected) {
+			continue
+		}
+
+		if err != nil {
+			return summary, err
+		}
+
+		summary.Requeued++
+	}
+
+	if summary.Requeued > 0 {
+		s.notifyWorkers()
+	}
+
+	return summary, nil
+}
+
+// renewLeases keeps the commands of this instance leased while it runs them
+// and recovers the commands of instances that stopped renewing their leases.
+func (s *bashrunService) renewLeases(ctx context.Context) {
+	const logPrefix = "service.renewLeases"
+
+	lease := s.leaseDuration()
+
+	ticker := time.NewTicker(lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := s.repo.RenewLeases(ctx, s.instanceID, lease)
+		if err != nil && ctx.Err() == nil {
+			logger.Logger().Error(logPrefix, ": ", err.Error())
+		}
+
+		s.recoverExpired(ctx)
+		s.enqueueMissingCallbacks(ctx)
+	}
+}
+
+func (s *bashrunService) recoverExpired(ctx context.Context) {
+	const logPrefix = "service.recoverExpired"
+
+	policy, err := s.recoverPolicy()
+	if err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+		return
+	}
+
+	commands, err := s.repo.ListExpiredCommands(ctx, s.instanceID)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Logger().Error(logPrefix, ": ", err.Error())
+		}
+
+		return
+	}
+
+	summary, err := s.recover(ctx, commands, policy)
+	if err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+	}
+
+	if summary.Lost+summary.Requeued+summary.Expired > 0 {
+		logger.Logger().Infof("Commands with expired leases recovered: %d lost, %d requeued, %d expired", summary.Lost, summary.Requeued, summary.Expired)
+	}
+}
+
+func (s *bashrunService) markLost(ctx context.Context, command domain.ActiveCommand, from domain.CommandStatus, reason string) (bool, error) {
+	err := s.repo.UpdateStatus(ctx, command.ID, domain.StatusTransition{From: from, To: domain.StatusLost, Error: reason})
+	if errors.Is(err, appErrors.ErrStatusConflict) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	s.events.publishStatus(command.ID, domain.StatusLost)
+
+	if command.CallbackURL != "" {
+		s.enqueueCallback(command.ID, command.CallbackURL)
+	}
+
+	if command.ParentID != nil {
+		s.finishAttempt(command.ID)
+	}
+
+	s.advanceFlows(command.WorkflowID, command.PipelineID)
+
+	return true, nil
+}
+
+func (s *bashrunService) watchDetached(command domain.ActiveCommand, startedAt time.Time) {
+	const logPrefix = "service.watchDetached"
+
+	s.events.open(command.ID)
+	s.processes.Store(command.ID, command.PID)
+
+	go func() {
+		defer s.events.close(command.ID)
+		defer s.processes.Delete(command.ID)
+
+		ticker := time.NewTicker(detachedPollInterval)
+		defer ticker.Stop()
+
+		for processMatches(command.PID, startedAt) {
+			select {
+			case <-s.commandContext.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+
+		c, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		transition := domain.StatusTransition{From: domain.StatusStarted, To: domain.StatusLost, Error: lostDetachedReason}
+		if s.stopVerified(command.ID, command.PID, time.Now()) {
+			transition = domain.StatusTransition{From: domain.StatusStarted, To: domain.StatusStopped}
+		}
+
+		err := s.repo.UpdateStatus(c, command.ID, transition)
+		if err != nil {
+			logger.Logger().Error(logPrefix, ": ", err.Error())
+			return
+		}
+
+		s.events.publishStatus(command.ID, transition.To)
+
+		if command.CallbackURL != "" {
+			s.enqueueCallback(command.ID, command.CallbackURL)
+		}
+
+		if command.ParentID != nil {
+			s.finishAttempt(command.ID)
+		}
+
+		s.advanceFlows(command.WorkflowID, command.PipelineID)
+	}()
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	appErrors "github.com/PoorMercymain/bashrun/errors"
+	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
+	"github.com/PoorMercymain/bashrun/pkg/logger"
+)
+
+const (
+	maxRetryAttempts = 100
+	maxExitCode      = 255
+	maxRetryDelay    = 24 * time.Hour
+)
+
+func prepareRetry(policy *domain.RetryPolicy) error {
+	if policy == nil {
+		return nil
+	}
+
+	if policy.Backoff == "" {
+		policy.Backoff = domain.BackoffExponential
+	}
+
+	if policy.Backoff != domain.BackoffFixed && policy.Backoff != domain.BackoffExponential {
+		return appErrors.ErrWrongRetry
+	}
+
+	if policy.MaxAttempts < 1 || policy.MaxAttempts > maxRetryAttempts || policy.DelaySeconds < 0 || policy.MaxDelaySeconds < 0 {
+		return appErrors.ErrWrongRetry
+	}
+
+	for _, code := range policy.ExitCodes {
+		if code < 1 || code > maxExitCode {
+			return appErrors.ErrWrongRetry
+		}
+	}
+
+	return nil
+}
+
+// retryDelay returns the backoff before the attempt following the given one.
+// Exponential backoff doubles the delay after every attempt, jitter picks a
+// random delay between a half and the full one.
+func retryDelay(policy domain.RetryPolicy, attempt int) time.Duration {
+	limit := maxRetryDelay
+	if policy.MaxDelaySeconds > 0 {
+		limit = min(limit, time.Duration(policy.MaxDelaySeconds)*time.Second)
+	}
+
+	delay := min(time.Duration(policy.DelaySeconds)*time.Second, limit)
+	if policy.Backoff == domain.BackoffExponential {
+		for i := 1; i < attempt && delay < limit; i++ {
+			delay = min(delay*2, limit)
+		}
+	}
+
+	if policy.Jitter && delay > 0 {
+		delay = delay/2 + rand.N(delay/2+1)
+	}
+
+	return delay
+}
+
+// startParent marks a command with retries started when its first attempt
+// starts, later attempts leave it as is.
+func (s *bashrunService) startParent(ctx context.Context, parentID int, startedAt time.Time, queued time.Duration) error {
+	err := s.repo.UpdateStatus(ctx, parentID, domain.StatusTransition{From: domain.StatusCreated, To: domain.StatusStarted})
+	if errors.Is(err, appErrors.ErrStatusConflict) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	s.events.publishStatus(parentID, domain.StatusStarted)
+
+	return s.repo.UpdateStartTime(ctx, parentID, startedAt, queued)
+}
+
+// finishAttempt is called when an attempt reaches a final status. It either
+// creates the next attempt after the backoff delay or copies the outcome to
+// the parent. Both steps are guarded in the DB, so a repeated call is a no-op.
+func (s *bashrunService) finishAttempt(id int) {
+	const logPrefix = "service.finishAttempt"
+
+	c, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	attempt, err := s.repo.ReadAttempt(c, id)
+	if err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+		return
+	}
+
+	if !attempt.Status.Final() || attempt.ParentStatus.Final() {
+		return
+	}
+
+	if attempt.Number < attempt.Retry.MaxAttempts && attempt.Retry.Retryable(attempt.Status, attempt.ExitStatus) {
+		now := time.Now()
+		runAt := now.Add(retryDelay(attempt.Retry, attempt.Number))
+		next := domain.NewAttempt{ParentID: attempt.ParentID, Number: attempt.Number + 1, CreatedAt: now, RunAt: &runAt}
+
+		nextID, err := s.repo.CreateAttempt(c, next)
+		if errors.Is(err, appErrors.ErrRowsNotAffected) {
+			return
+		}
+
+		if err != nil {
+			logger.Logger().Error(logPrefix, ": ", err.Error())
+			return
+		}
+
+		s.commandCreated(nextID, next.InitialStatus())
+		return
+	}
+
+	err = s.repo.FinishParent(c, id, domain.StatusTransition{From: attempt.ParentStatus, To: attempt.Status})
+	if errors.Is(err, appErrors.ErrStatusConflict) {
+		return
+	}
+
+	if err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+		return
+	}
+
+	if attempt.ExitStatus != nil {
+		s.events.publishExit(attempt.ParentID, *attempt.ExitStatus)
+	}
+
+	s.events.publishStatus(attempt.ParentID, attempt.Status)
+	s.events.close(attempt.ParentID)
+
+	if attempt.ParentCallbackURL != "" {
+		s.enqueueCallback(attempt.ParentID, attempt.ParentCallbackURL)
+	}
+
+	s.advanceFlows(attempt.ParentWorkflowID, attempt.ParentPipelineID)
+}
+
+func (s *bashrunService) ListAttempts(ctx context.Context, id int) ([]domain.CommandFromDB, error) {
+	const logPrefix = "service.ListAttempts"
+
+	attempts, err := s.repo.ListAttempts(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	for i := range attempts {
+		redactEnv(attempts[i].Env, s.cfg.RedactedEnvKeys)
+	}
+
+	return attempts, nil
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	appErrors "github.com/PoorMercymain/bashrun/errors"
+	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
+	"github.com/PoorMercymain/bashrun/pkg/cron"
+	"github.com/PoorMercymain/bashrun/pkg/logger"
+)
+
+const (
+	defaultTimezone         = "UTC"
+	scheduleBatchSize       = 16
+	defaultScheduleInterval = time.Second
+	replacedRunStopSignal   = "KILL"
+)
+
+func nextRun(expression string, timezone string, from time.Time) (*time.Time, error) {
+	schedule, err := cron.Parse(expression)
+	if err != nil {
+		return nil, appErrors.ErrWrongCron
+	}
+
+	if timezone == "Local" {
+		return nil, appErrors.ErrWrongTimezone
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, appErrors.ErrWrongTimezone
+	}
+
+	next := schedule.Next(from.In(location))
+	if next.IsZero() {
+		return nil, nil
+	}
+
+	return &next, nil
+}
+
+func (s *bashrunService) CreateSchedule(ctx context.Context, schedule domain.ScheduleFromUser) (int, error) {
+	const logPrefix = "service.CreateSchedule"
+
+	if schedule.Timezone == "" {
+		schedule.Timezone = defaultTimezone
+	}
+
+	if schedule.Overlap == "" {
+		schedule.Overlap = domain.OverlapSkip
+	}
+
+	switch schedule.Overlap {
+	case domain.OverlapSkip, domain.OverlapQueue, domain.OverlapReplace:
+	default:
+		return 0, fmt.Errorf("%s: %w", logPrefix, appErrors.ErrWrongOverlap)
+	}
+
+	if schedule.Command.RunAt != nil || schedule.Command.DelaySeconds != nil {
+		return 0, fmt.Errorf("%s: %w", logPrefix, appErrors.ErrWrongRunAt)
+	}
+
+	next, err := nextRun(schedule.Cron, schedule.Timezone, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	if next == nil {
+		return 0, fmt.Errorf("%s: %w", logPrefix, appErrors.ErrWrongCron)
+	}
+
+	err = s.prepareCommand(&schedule.Command)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	id, err := s.repo.CreateSchedule(ctx, domain.Schedule{
+		Cron:      schedule.Cron,
+		Timezone:  schedule.Timezone,
+		Overlap:   schedule.Overlap,
+		Command:   schedule.Command,
+		NextRunAt: next,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return id, nil
+}
+
+func (s *bashrunService) redactSchedule(schedule *domain.Schedule) {
+	redactEnv(schedule.Command.Env, s.cfg.RedactedEnvKeys)
+	schedule.Command.CallbackSecret = ""
+}
+
+func (s *bashrunService) ListSchedules(ctx context.Context) ([]domain.Schedule, error) {
+	const logPrefix = "service.ListSchedules"
+
+	schedules, err := s.repo.ListSchedules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	for i := range schedules {
+		s.redactSchedule(&schedules[i])
+	}
+
+	return schedules, nil
+}
+
+func (s *bashrunService) ReadSchedule(ctx context.Context, id int) (domain.Schedule, error) {
+	const logPrefix = "service.ReadSchedule"
+
+	schedule, err := s.repo.ReadSchedule(ctx, id)
+	if err != nil {
+		return domain.Schedule{}, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	s.redactSchedule(&schedule)
+
+	return schedule, nil
+}
+
+func (s *bashrunService) PauseSchedule(ctx context.Context, id int) error {
+	const logPrefix = "service.PauseSchedule"
+
+	err := s.repo.PauseSchedule(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return nil
+}
+
+// ResumeSchedule does not catch up on runs missed while the schedule was
+// paused, the next run is calculated from the current time.
+func (s *bashrunService) ResumeSchedule(ctx context.Context, id int) error {
+	const logPrefix = "service.ResumeSchedule"
+
+	schedule, err := s.repo.ReadSchedule(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	next, err := nextRun(schedule.Cron, schedule.Timezone, time.Now())
+	if err != nil {
+		return fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	err = s.repo.ResumeSchedule(ctx, id, next)
+	if err != nil {
+		return fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return nil
+}
+
+func (s *bashrunService) DeleteSchedule(ctx context.Context, id int) error {
+	const logPrefix = "service.DeleteSchedule"
+
+	err := s.repo.DeleteSchedule(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return nil
+}
+
+func (s *bashrunService) RunScheduler(ctx context.Context) {
+	interval := time.Duration(s.cfg.SchedulePollInterval) * time.Millisecond
+	if interval <= 0 {
+		interval = defaultScheduleInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runDueSchedules(ctx)
+		}
+	}
+}
+
+func (s *bashrunService) runDueSchedules(ctx context.Context) {
+	const logPrefix = "service.runDueSchedules"
+
+	now := time.Now()
+
+	schedules, err := s.repo.ListDueSchedules(ctx, now, scheduleBatchSize)
+	if err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+		return
+	}
+
+	for _, schedule := range schedules {
+		err = s.runSchedule(ctx, schedule, now)
+		if err != nil {
+			logger.Logger().Error(logPrefix, ": ", err.Error())
+		}
+	}
+}
+
+// runSchedule creates a single run even if several runs were missed while the
+// service was down, the next run is calculated from now.
+func (s *bashrunService) runSchedule(ctx context.Context, schedule domain.Schedule, now time.Time) error {
+	next, err := nextRun(schedule.Cron, schedule.Timezone, now)
+	if err != nil {
+		return fmt.Errorf("schedule %d: %w", schedule.ID, err)
+	}
+
+	command := schedule.Command
+	command.CreatedAt = now
+
+	result, err := s.repo.RunSchedule(ctx, domain.ScheduleRun{
+		ScheduleID: schedule.ID,
+		Overlap:    schedule.Overlap,
+		Command:    command,
+		RunAt:      *schedule.NextRunAt,
+		NextRunAt:  next,
+	})
+	if errors.Is(err, appErrors.ErrRowsNotAffected) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if result.Skipped {
+		return nil
+	}
+
+	for _, id := range result.Replaced {
+		err = s.StopCommand(ctx, id, replacedRunStopSignal)
+		if err != nil && !errors.Is(err, appErrors.ErrCommandNotRunning) {
+			logger.Logger().Error("service.runSchedule: ", err.Error())
+		}
+	}
+
+	s.commandCreated(result.CommandID, domain.StatusCreated)
+
+	return nil
+}
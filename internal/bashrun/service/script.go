@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const scriptFilePattern = "bashrun-script-*"
+
+func hasShebang(script string) bool {
+	return strings.HasPrefix(script, "#!")
+}
+
+func writeScript(dir string, script string) (string, error) {
+	file, err := os.CreateTemp(dir, scriptFilePattern)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = file.WriteString(script)
+	if err == nil {
+		err = file.Chmod(0700)
+	}
+
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
+func (s *bashrunService) newScriptCmd(ctx context.Context, script string, interpreter string, args []string) (*exec.Cmd, func(), error) {
+	path, err := writeScript(s.cfg.ScriptDir, script)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cleanup := func() {
+		os.Remove(path)
+	}
+
+	if interpreter == "" {
+		return exec.CommandContext(ctx, path, args...), cleanup, nil
+	}
+
+	return exec.CommandContext(ctx, interpreter, append([]string{path}, args...)...), cleanup, nil
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	appErrors "github.com/PoorMercymain/bashrun/errors"
+	"github.com/PoorMercymain/bashrun/internal/bashrun/domain"
+	"github.com/PoorMercymain/bashrun/pkg/logger"
+)
+
+const maxWorkflowNodes = 100
+
+func (s *bashrunService) prepareWorkflow(workflow *domain.WorkflowFromUser) error {
+	if len(workflow.Nodes) == 0 || len(workflow.Nodes) > maxWorkflowNodes {
+		return appErrors.ErrWrongWorkflow
+	}
+
+	names := make(map[string]struct{}, len(workflow.Nodes))
+	for _, node := range workflow.Nodes {
+		if node.Name == "" || strings.TrimSpace(node.Name) != node.Name {
+			return appErrors.ErrWrongWorkflow
+		}
+
+		if _, ok := names[node.Name]; ok {
+			return appErrors.ErrWrongWorkflow
+		}
+
+		names[node.Name] = struct{}{}
+	}
+
+	for i := range workflow.Nodes {
+		node := &workflow.Nodes[i]
+
+		for j, dependency := range node.DependsOn {
+			if _, ok := names[dependency]; !ok || dependency == node.Name || slices.Contains(node.DependsOn[:j], dependency) {
+				return appErrors.ErrWrongWorkflow
+			}
+		}
+
+		if node.Condition == "" {
+			node.Condition = domain.ConditionOnSuccess
+		}
+
+		switch node.Condition {
+		case domain.ConditionOnSuccess, domain.ConditionOnFailure, domain.ConditionAlways:
+		default:
+			return appErrors.ErrWrongWorkflow
+		}
+
+		if node.Command.RunAt != nil || node.Command.DelaySeconds != nil {
+			return appErrors.ErrWrongWorkflow
+		}
+
+		err := s.prepareCommand(&node.Command)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !workflow.Acyclic() {
+		return appErrors.ErrWorkflowCycle
+	}
+
+	return nil
+}
+
+func (s *bashrunService) CreateWorkflow(ctx context.Context, workflow domain.WorkflowFromUser) (int, error) {
+	const logPrefix = "service.CreateWorkflow"
+
+	err := s.prepareWorkflow(&workflow)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	id, advance, err := s.repo.CreateWorkflow(ctx, workflow, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	for _, command := range advance.Started {
+		s.commandCreated(command.ID, command.Status)
+	}
+
+	return id, nil
+}
+
+func (s *bashrunService) ListWorkflows(ctx context.Context) ([]domain.Workflow, error) {
+	const logPrefix = "service.ListWorkflows"
+
+	workflows, err := s.repo.ListWorkflows(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return workflows, nil
+}
+
+func (s *bashrunService) ReadWorkflow(ctx context.Context, id int) (domain.Workflow, error) {
+	const logPrefix = "service.ReadWorkflow"
+
+	workflow, err := s.repo.ReadWorkflow(ctx, id)
+	if err != nil {
+		return domain.Workflow{}, fmt.Errorf("%s: %w", logPrefix, err)
+	}
+
+	return workflow, nil
+}
+
+// advanceWorkflow is called when a command of the workflow reaches a final
+// status and starts the nodes that were waiting for it.
+func (s *bashrunService) advanceWorkflow(id int) {
+	const logPrefix = "service.advanceWorkflow"
+
+	c, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	advance, err := s.repo.AdvanceWorkflow(c, id, time.Now())
+	if err != nil {
+		logger.Logger().Error(logPrefix, ": ", err.Error())
+		return
+	}
+
+	for _, command := range advance.Started {
+		s.commandCreated(command.ID, command.Status)
+	}
+}
@@ -0,0 +1,30 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS cmd_output (
+    command_id INTEGER NOT NULL REFERENCES cmd(command_id) ON DELETE CASCADE,
+    seq BIGINT NOT NULL,
+    stream TEXT NOT NULL,
+    stream_offset BIGINT NOT NULL,
+    data TEXT NOT NULL,
+    PRIMARY KEY (command_id, seq)
+);
+
+CREATE INDEX IF NOT EXISTS idx_cmd_output_stream ON cmd_output(command_id, stream, stream_offset);
+
+-- для уже выполненных команд порядок stdout и stderr относительно друг друга не сохраняется
+INSERT INTO cmd_output(command_id, seq, stream, stream_offset, data)
+SELECT command_id, 1, 'stdout', 0, output_text FROM cmd WHERE output_text <> '';
+
+INSERT INTO cmd_output(command_id, seq, stream, stream_offset, data)
+SELECT command_id, 2, 'stderr', 0, stderr_text FROM cmd WHERE stderr_text <> '';
+
+DROP INDEX IF EXISTS idx_cmd_output_search;
+DROP INDEX IF EXISTS idx_cmd;
+
+ALTER TABLE cmd DROP COLUMN IF EXISTS combined_text;
+ALTER TABLE cmd DROP COLUMN IF EXISTS stderr_text;
+ALTER TABLE cmd DROP COLUMN IF EXISTS output_text;
+
+CREATE INDEX IF NOT EXISTS idx_cmd_output_search ON cmd_output USING GIN (to_tsvector('simple', data)) WHERE stream = 'stdout';
+
+COMMIT;
\ No newline at end of file
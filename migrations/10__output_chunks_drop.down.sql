@@ -0,0 +1,19 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_cmd_output_search;
+
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS output_text TEXT DEFAULT '';
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS stderr_text TEXT DEFAULT '';
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS combined_text TEXT DEFAULT '';
+
+UPDATE cmd SET
+    output_text = COALESCE((SELECT string_agg(data, '' ORDER BY seq) FROM cmd_output o WHERE o.command_id = cmd.command_id AND o.stream = 'stdout'), ''),
+    stderr_text = COALESCE((SELECT string_agg(data, '' ORDER BY seq) FROM cmd_output o WHERE o.command_id = cmd.command_id AND o.stream = 'stderr'), ''),
+    combined_text = COALESCE((SELECT string_agg(data, '' ORDER BY seq) FROM cmd_output o WHERE o.command_id = cmd.command_id), '');
+
+DROP TABLE IF EXISTS cmd_output;
+
+CREATE INDEX IF NOT EXISTS idx_cmd ON cmd(command_id, command, pid, output_text, processing_status, exit_status);
+CREATE INDEX IF NOT EXISTS idx_cmd_output_search ON cmd USING GIN (to_tsvector('simple', output_text));
+
+COMMIT;
\ No newline at end of file
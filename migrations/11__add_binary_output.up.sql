@@ -0,0 +1,19 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_cmd_output_search;
+
+ALTER TABLE cmd_output ALTER COLUMN data TYPE BYTEA USING convert_to(data, 'UTF8');
+
+-- вывод может содержать невалидный UTF-8 и NUL, такие фрагменты индексируются в escape-формате
+CREATE OR REPLACE FUNCTION cmd_output_text(data BYTEA) RETURNS TEXT
+LANGUAGE plpgsql IMMUTABLE STRICT AS $$
+BEGIN
+    RETURN convert_from(data, 'UTF8');
+EXCEPTION WHEN OTHERS THEN
+    RETURN encode(data, 'escape');
+END;
+$$;
+
+CREATE INDEX IF NOT EXISTS idx_cmd_output_search ON cmd_output USING GIN (to_tsvector('simple', cmd_output_text(data))) WHERE stream = 'stdout';
+
+COMMIT;
\ No newline at end of file
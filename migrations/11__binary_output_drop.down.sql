@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_cmd_output_search;
+
+ALTER TABLE cmd_output ALTER COLUMN data TYPE TEXT USING cmd_output_text(data);
+
+DROP FUNCTION IF EXISTS cmd_output_text(BYTEA);
+
+CREATE INDEX IF NOT EXISTS idx_cmd_output_search ON cmd_output USING GIN (to_tsvector('simple', data)) WHERE stream = 'stdout';
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,10 @@
+BEGIN;
+
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS max_output_bytes BIGINT;
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS output_policy TEXT NOT NULL DEFAULT 'head';
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS truncated BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS output_bytes BIGINT NOT NULL DEFAULT 0;
+
+UPDATE cmd SET output_bytes = (SELECT COALESCE(SUM(octet_length(o.data)), 0) FROM cmd_output o WHERE o.command_id = cmd.command_id);
+
+COMMIT;
\ No newline at end of file
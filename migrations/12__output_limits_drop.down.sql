@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE cmd DROP COLUMN IF EXISTS output_bytes;
+ALTER TABLE cmd DROP COLUMN IF EXISTS truncated;
+ALTER TABLE cmd DROP COLUMN IF EXISTS output_policy;
+ALTER TABLE cmd DROP COLUMN IF EXISTS max_output_bytes;
+
+COMMIT;
\ No newline at end of file
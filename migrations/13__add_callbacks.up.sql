@@ -0,0 +1,25 @@
+BEGIN;
+
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS callback_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS callback_secret TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS cmd_delivery (
+    delivery_id BIGSERIAL PRIMARY KEY,
+    command_id INTEGER NOT NULL REFERENCES cmd(command_id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_status INTEGER,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_attempt_at TIMESTAMPTZ,
+    next_attempt_at TIMESTAMPTZ DEFAULT now(),
+    delivered_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_cmd_delivery_command ON cmd_delivery(command_id);
+-- очередь на отправку: только ожидающие доставки, по времени следующей попытки
+CREATE INDEX IF NOT EXISTS idx_cmd_delivery_pending ON cmd_delivery(next_attempt_at) WHERE status = 'pending';
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE IF EXISTS cmd_delivery;
+
+ALTER TABLE cmd DROP COLUMN IF EXISTS callback_secret;
+ALTER TABLE cmd DROP COLUMN IF EXISTS callback_url;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS error TEXT NOT NULL DEFAULT '';
+
+-- раньше причина ошибки записывалась прямо в статус
+UPDATE cmd SET error = processing_status, processing_status = 'failed'
+WHERE processing_status NOT IN ('created', 'started', 'done', 'stopped', 'timed_out', 'output_limit_exceeded');
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,7 @@
+BEGIN;
+
+UPDATE cmd SET processing_status = error WHERE processing_status = 'failed' AND error <> '';
+
+ALTER TABLE cmd DROP COLUMN IF EXISTS error;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS priority INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMPTZ DEFAULT NULL;
+
+-- очередь: еще не взятые воркерами команды в порядке приоритета и создания
+CREATE INDEX IF NOT EXISTS idx_cmd_queue ON cmd (priority DESC, command_id) WHERE processing_status = 'created' AND claimed_at IS NULL;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_cmd_queue;
+
+ALTER TABLE cmd DROP COLUMN IF EXISTS claimed_at;
+ALTER TABLE cmd DROP COLUMN IF EXISTS priority;
+
+COMMIT;
\ No newline at end of file
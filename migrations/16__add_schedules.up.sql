@@ -0,0 +1,25 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS cmd_schedule (
+    schedule_id SERIAL PRIMARY KEY,
+    cron TEXT NOT NULL,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    overlap TEXT NOT NULL DEFAULT 'skip',
+    spec JSONB NOT NULL,
+    stdin BYTEA,
+    paused BOOLEAN NOT NULL DEFAULT false,
+    next_run_at TIMESTAMPTZ,
+    last_run_at TIMESTAMPTZ,
+    last_command_id INTEGER,
+    skipped_runs INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- планировщик: активные расписания по времени следующего запуска
+CREATE INDEX IF NOT EXISTS idx_cmd_schedule_due ON cmd_schedule(next_run_at) WHERE NOT paused;
+
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS schedule_id INTEGER REFERENCES cmd_schedule(schedule_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_cmd_schedule ON cmd(schedule_id, command_id) WHERE schedule_id IS NOT NULL;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE cmd DROP COLUMN IF EXISTS schedule_id;
+
+DROP TABLE IF EXISTS cmd_schedule;
+
+COMMIT;
\ No newline at end of file
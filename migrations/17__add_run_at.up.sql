@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS run_at TIMESTAMPTZ DEFAULT NULL;
+
+-- отложенные команды, ожидающие времени запуска
+CREATE INDEX IF NOT EXISTS idx_cmd_run_at ON cmd (run_at) WHERE processing_status = 'scheduled';
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,9 @@
+BEGIN;
+
+UPDATE cmd SET processing_status = 'created' WHERE processing_status = 'scheduled';
+
+DROP INDEX IF EXISTS idx_cmd_run_at;
+
+ALTER TABLE cmd DROP COLUMN IF EXISTS run_at;
+
+COMMIT;
\ No newline at end of file
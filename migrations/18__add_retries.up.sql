@@ -0,0 +1,10 @@
+BEGIN;
+
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS retry_policy JSONB DEFAULT NULL;
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS parent_id INTEGER REFERENCES cmd(command_id) ON DELETE CASCADE;
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS attempt INTEGER DEFAULT NULL;
+
+-- попытки команды с повторами, номер попытки уникален в пределах родителя
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cmd_attempt ON cmd (parent_id, attempt) WHERE parent_id IS NOT NULL;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,11 @@
+BEGIN;
+
+DELETE FROM cmd WHERE parent_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_cmd_attempt;
+
+ALTER TABLE cmd DROP COLUMN IF EXISTS attempt;
+ALTER TABLE cmd DROP COLUMN IF EXISTS parent_id;
+ALTER TABLE cmd DROP COLUMN IF EXISTS retry_policy;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,31 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS cmd_workflow (
+    workflow_id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'running',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ
+);
+
+-- незавершенные workflow, которые продвигаются при восстановлении
+CREATE INDEX IF NOT EXISTS idx_cmd_workflow_running ON cmd_workflow(workflow_id) WHERE status = 'running';
+
+CREATE TABLE IF NOT EXISTS cmd_workflow_node (
+    workflow_id INTEGER NOT NULL REFERENCES cmd_workflow(workflow_id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    depends_on TEXT[] NOT NULL DEFAULT '{}',
+    condition TEXT NOT NULL DEFAULT 'on_success',
+    spec JSONB NOT NULL,
+    stdin BYTEA,
+    skipped BOOLEAN NOT NULL DEFAULT false,
+    command_id INTEGER REFERENCES cmd(command_id),
+    PRIMARY KEY (workflow_id, name)
+);
+
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS workflow_id INTEGER REFERENCES cmd_workflow(workflow_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_cmd_workflow ON cmd(workflow_id, command_id) WHERE workflow_id IS NOT NULL;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE cmd DROP COLUMN IF EXISTS workflow_id;
+
+DROP TABLE IF EXISTS cmd_workflow_node;
+DROP TABLE IF EXISTS cmd_workflow;
+
+COMMIT;
\ No newline at end of file
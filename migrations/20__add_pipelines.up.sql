@@ -0,0 +1,33 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS cmd_pipeline (
+    pipeline_id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
+    continue_on_error BOOLEAN NOT NULL DEFAULT false,
+    status TEXT NOT NULL DEFAULT 'running',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ
+);
+
+-- незавершенные pipeline, которые продвигаются при восстановлении
+CREATE INDEX IF NOT EXISTS idx_cmd_pipeline_running ON cmd_pipeline(pipeline_id) WHERE status = 'running';
+
+-- input - как шаг получает stdout предыдущего шага: '' - никак, stdin или env (в переменной input_env)
+CREATE TABLE IF NOT EXISTS cmd_pipeline_step (
+    pipeline_id INTEGER NOT NULL REFERENCES cmd_pipeline(pipeline_id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    input TEXT NOT NULL DEFAULT '',
+    input_env TEXT NOT NULL DEFAULT '',
+    spec JSONB NOT NULL,
+    stdin BYTEA,
+    skipped BOOLEAN NOT NULL DEFAULT false,
+    command_id INTEGER REFERENCES cmd(command_id),
+    PRIMARY KEY (pipeline_id, position)
+);
+
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS pipeline_id INTEGER REFERENCES cmd_pipeline(pipeline_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_cmd_pipeline ON cmd(pipeline_id, command_id) WHERE pipeline_id IS NOT NULL;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE cmd DROP COLUMN IF EXISTS pipeline_id;
+
+DROP TABLE IF EXISTS cmd_pipeline_step;
+DROP TABLE IF EXISTS cmd_pipeline;
+
+COMMIT;
\ No newline at end of file
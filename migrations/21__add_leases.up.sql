@@ -0,0 +1,12 @@
+BEGIN;
+
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS owner TEXT DEFAULT NULL;
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMPTZ DEFAULT NULL;
+
+-- продление аренды: взятые экземпляром сервиса и еще не завершенные команды
+CREATE INDEX IF NOT EXISTS idx_cmd_owner ON cmd (owner) WHERE processing_status IN ('created', 'started') AND claimed_at IS NOT NULL;
+
+-- восстановление: взятые команды с истекшей арендой
+CREATE INDEX IF NOT EXISTS idx_cmd_lease ON cmd (lease_expires_at) WHERE processing_status IN ('created', 'started') AND claimed_at IS NOT NULL;
+
+COMMIT;
\ No newline at end of file
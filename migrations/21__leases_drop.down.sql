@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_cmd_lease;
+DROP INDEX IF EXISTS idx_cmd_owner;
+
+ALTER TABLE cmd DROP COLUMN IF EXISTS lease_expires_at;
+ALTER TABLE cmd DROP COLUMN IF EXISTS owner;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS stop_signal INTEGER DEFAULT NULL;
+
+-- запросы на остановку: экземпляр сервиса забирает запросы к своим командам
+CREATE INDEX IF NOT EXISTS idx_cmd_stop_request ON cmd (owner) WHERE stop_signal IS NOT NULL;
+
+COMMIT;
\ No newline at end of file
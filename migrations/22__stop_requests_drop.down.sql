@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_cmd_stop_request;
+
+ALTER TABLE cmd DROP COLUMN IF EXISTS stop_signal;
+
+COMMIT;
\ No newline at end of file
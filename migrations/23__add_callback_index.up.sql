@@ -0,0 +1,6 @@
+BEGIN;
+
+-- поиск завершенных команд с callback_url, для которых не сохранено уведомление
+CREATE INDEX IF NOT EXISTS idx_cmd_callback ON cmd (command_id) WHERE callback_url <> '';
+
+COMMIT;
\ No newline at end of file
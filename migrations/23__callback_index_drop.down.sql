@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_cmd_callback;
+
+COMMIT;
\ No newline at end of file
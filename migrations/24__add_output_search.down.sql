@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS cmd_output_search;
+
+CREATE INDEX IF NOT EXISTS idx_cmd_output_search ON cmd_output USING GIN (to_tsvector('simple', cmd_output_text(data))) WHERE stream = 'stdout';
+
+COMMIT;
\ No newline at end of file
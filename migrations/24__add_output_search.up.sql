@@ -0,0 +1,23 @@
+BEGIN;
+
+-- поисковый документ по всему stdout команды, хвост без завершающего пробела хранится отдельно,
+-- чтобы слово на границе между частями вывода попало в документ целиком
+CREATE TABLE IF NOT EXISTS cmd_output_search (
+    command_id INTEGER PRIMARY KEY REFERENCES cmd(command_id) ON DELETE CASCADE,
+    document TSVECTOR NOT NULL DEFAULT ''::TSVECTOR,
+    tail BYTEA NOT NULL DEFAULT ''::BYTEA
+);
+
+CREATE INDEX IF NOT EXISTS idx_cmd_output_search_document ON cmd_output_search USING GIN ((document || to_tsvector('simple', cmd_output_text(tail))));
+
+INSERT INTO cmd_output_search (command_id, document)
+SELECT command_id, to_tsvector('simple', cmd_output_text(string_agg(data, ''::BYTEA ORDER BY seq)))
+FROM cmd_output
+WHERE stream = 'stdout'
+GROUP BY command_id
+ON CONFLICT (command_id) DO NOTHING;
+
+-- индекс по отдельным частям вывода больше не используется
+DROP INDEX IF EXISTS idx_cmd_output_search;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS stderr_text TEXT DEFAULT '';
+-- общий вывод, куда stdout и stderr дописываются в порядке поступления
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS combined_text TEXT DEFAULT '';
+
+UPDATE cmd SET combined_text = output_text;
+
+COMMIT;
\ No newline at end of file
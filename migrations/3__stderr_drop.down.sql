@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE cmd DROP COLUMN IF EXISTS combined_text;
+ALTER TABLE cmd DROP COLUMN IF EXISTS stderr_text;
+
+COMMIT;
\ No newline at end of file
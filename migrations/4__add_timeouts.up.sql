@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS timeout_seconds INTEGER DEFAULT NULL;
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS kill_grace_seconds INTEGER DEFAULT NULL;
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS duration_ms BIGINT DEFAULT NULL;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE cmd DROP COLUMN IF EXISTS duration_ms;
+ALTER TABLE cmd DROP COLUMN IF EXISTS kill_grace_seconds;
+ALTER TABLE cmd DROP COLUMN IF EXISTS timeout_seconds;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS env JSONB DEFAULT NULL;
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS workdir TEXT DEFAULT '';
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS clear_env BOOLEAN DEFAULT FALSE;
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS stdin BYTEA DEFAULT NULL;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE cmd DROP COLUMN IF EXISTS stdin;
+ALTER TABLE cmd DROP COLUMN IF EXISTS clear_env;
+ALTER TABLE cmd DROP COLUMN IF EXISTS workdir;
+ALTER TABLE cmd DROP COLUMN IF EXISTS env;
+
+COMMIT;
\ No newline at end of file
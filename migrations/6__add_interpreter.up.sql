@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS interpreter TEXT DEFAULT 'sh';
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS argv TEXT[] DEFAULT NULL;
+
+COMMIT;
\ No newline at end of file
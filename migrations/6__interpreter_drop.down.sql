@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE cmd DROP COLUMN IF EXISTS argv;
+ALTER TABLE cmd DROP COLUMN IF EXISTS interpreter;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS script TEXT DEFAULT '';
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS script_args TEXT[] DEFAULT NULL;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE cmd DROP COLUMN IF EXISTS script_args;
+ALTER TABLE cmd DROP COLUMN IF EXISTS script;
+
+COMMIT;
\ No newline at end of file
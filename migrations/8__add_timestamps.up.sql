@@ -0,0 +1,10 @@
+BEGIN;
+
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS started_at TIMESTAMPTZ DEFAULT NULL;
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS finished_at TIMESTAMPTZ DEFAULT NULL;
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS queued_ms BIGINT DEFAULT NULL;
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS user_cpu_ms BIGINT DEFAULT NULL;
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS system_cpu_ms BIGINT DEFAULT NULL;
+
+COMMIT;
\ No newline at end of file
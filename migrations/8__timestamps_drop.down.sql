@@ -0,0 +1,10 @@
+BEGIN;
+
+ALTER TABLE cmd DROP COLUMN IF EXISTS system_cpu_ms;
+ALTER TABLE cmd DROP COLUMN IF EXISTS user_cpu_ms;
+ALTER TABLE cmd DROP COLUMN IF EXISTS queued_ms;
+ALTER TABLE cmd DROP COLUMN IF EXISTS finished_at;
+ALTER TABLE cmd DROP COLUMN IF EXISTS started_at;
+ALTER TABLE cmd DROP COLUMN IF EXISTS created_at;
+
+COMMIT;
\ No newline at end of file
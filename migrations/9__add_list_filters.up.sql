@@ -0,0 +1,10 @@
+BEGIN;
+
+ALTER TABLE cmd ADD COLUMN IF NOT EXISTS labels TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_cmd_labels ON cmd USING GIN (labels);
+CREATE INDEX IF NOT EXISTS idx_cmd_created_at ON cmd (created_at);
+CREATE INDEX IF NOT EXISTS idx_cmd_status ON cmd (processing_status);
+CREATE INDEX IF NOT EXISTS idx_cmd_output_search ON cmd USING GIN (to_tsvector('simple', output_text));
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_cmd_output_search;
+DROP INDEX IF EXISTS idx_cmd_status;
+DROP INDEX IF EXISTS idx_cmd_created_at;
+DROP INDEX IF EXISTS idx_cmd_labels;
+
+ALTER TABLE cmd DROP COLUMN IF EXISTS labels;
+
+COMMIT;
\ No newline at end of file
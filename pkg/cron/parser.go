@@ -0,0 +1,222 @@
+package cron
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrWrongExpression = errors.New("cron expression should consist of 5 fields (minute, hour, day of month, month, day of week) or be one of @yearly, @monthly, @weekly, @daily, @hourly")
+
+const searchYears = 5
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	monthNames = map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}
+	dayNames   = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+)
+
+type field struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{min: 0, max: 59}
+	hourField   = field{min: 0, max: 23}
+	domField    = field{min: 1, max: 31}
+	monthField  = field{min: 1, max: 12, names: monthNames}
+	dowField    = field{min: 0, max: 7, names: dayNames}
+)
+
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+func Parse(expression string) (*Schedule, error) {
+	expression = strings.TrimSpace(expression)
+	if macro, ok := macros[strings.ToLower(expression)]; ok {
+		expression = macro
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, ErrWrongExpression
+	}
+
+	var s Schedule
+	var err error
+
+	if s.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+
+	if s.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+
+	if s.dom, err = domField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+
+	if s.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+
+	if s.dow, err = dowField.parse(fields[4]); err != nil {
+		return nil, err
+	}
+
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+
+	s.domStar = strings.HasPrefix(fields[2], "*") || fields[2] == "?"
+	s.dowStar = strings.HasPrefix(fields[4], "*") || fields[4] == "?"
+
+	return &s, nil
+}
+
+func (f field) parse(expression string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expression, ",") {
+		partBits, err := f.parsePart(part)
+		if err != nil {
+			return 0, err
+		}
+
+		bits |= partBits
+	}
+
+	return bits, nil
+}
+
+func (f field) parsePart(part string) (uint64, error) {
+	rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+	step := 1
+	if hasStep {
+		var err error
+		step, err = strconv.Atoi(stepPart)
+		if err != nil || step < 1 {
+			return 0, ErrWrongExpression
+		}
+	}
+
+	var low, high int
+	switch {
+	case rangePart == "*" || rangePart == "?":
+		low, high = f.min, f.max
+	case strings.Contains(rangePart, "-"):
+		lowPart, highPart, _ := strings.Cut(rangePart, "-")
+
+		var err error
+		if low, err = f.value(lowPart); err != nil {
+			return 0, err
+		}
+
+		if high, err = f.value(highPart); err != nil {
+			return 0, err
+		}
+
+		if low > high {
+			return 0, ErrWrongExpression
+		}
+	default:
+		var err error
+		if low, err = f.value(rangePart); err != nil {
+			return 0, err
+		}
+
+		high = low
+		if hasStep {
+			high = f.max
+		}
+	}
+
+	var bits uint64
+	for value := low; value <= high; value += step {
+		bits |= 1 << value
+	}
+
+	return bits, nil
+}
+
+func (f field) value(s string) (int, error) {
+	if value, ok := f.names[strings.ToLower(s)]; ok {
+		return value, nil
+	}
+
+	value, err := strconv.Atoi(s)
+	if err != nil || value < f.min || value > f.max {
+		return 0, ErrWrongExpression
+	}
+
+	return value, nil
+}
+
+// Next returns the first moment after t matching the schedule in t's location
+// or the zero time if there is no such moment in the next five years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Year() + searchYears
+
+	for t.Year() <= limit {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = advance(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc))
+			continue
+		}
+
+		if !s.dayMatches(t) {
+			t = advance(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+			continue
+		}
+
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = advance(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc))
+			continue
+		}
+
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+// advance guards against local times skipped by a DST transition, which
+// time.Date may resolve to a moment before t.
+func advance(t, next time.Time) time.Time {
+	for !next.After(t) {
+		next = next.Add(time.Hour)
+	}
+
+	return next
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatches := s.dom&(1<<uint(t.Day())) != 0
+	dowMatches := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domStar || s.dowStar {
+		return domMatches && dowMatches
+	}
+
+	return domMatches || dowMatches
+}
@@ -0,0 +1,51 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	for _, expression := range []string{"* * * * *", "*/5 0-6,22 1 jan-MAR mon-fri", "@daily", "@Hourly", "0 0 * * 7", "30 4 1,15 * 5", "5/10 * * * ?"} {
+		_, err := Parse(expression)
+		require.NoError(t, err, expression)
+	}
+
+	for _, expression := range []string{"", "* * * *", "* * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8",
+		"*/0 * * * *", "5-1 * * * *", "a * * * *", "1,,2 * * * *", "@every 5m"} {
+		_, err := Parse(expression)
+		require.ErrorIs(t, err, ErrWrongExpression, expression)
+	}
+}
+
+func TestNext(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	cases := []struct {
+		expression string
+		from       time.Time
+		next       time.Time
+	}{
+		{"* * * * *", time.Date(2024, 5, 1, 10, 0, 30, 0, time.UTC), time.Date(2024, 5, 1, 10, 1, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 5, 1, 10, 15, 0, 0, time.UTC), time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2024, 5, 1, 10, 0, 0, 0, moscow), time.Date(2024, 5, 2, 3, 0, 0, 0, moscow)},
+		{"0 0 1 * *", time.Date(2024, 12, 15, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * mon", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2024, 3, 10, 0, 0, 0, 0, newYork), time.Date(2024, 3, 11, 2, 30, 0, 0, newYork)},
+		{"0 0 30 2 *", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{}},
+	}
+
+	for _, c := range cases {
+		schedule, err := Parse(c.expression)
+		require.NoError(t, err)
+		require.True(t, c.next.Equal(schedule.Next(c.from)), "%s: %v", c.expression, schedule.Next(c.from))
+	}
+}
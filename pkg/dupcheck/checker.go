@@ -58,6 +58,21 @@ func checkArray(d *json.Decoder, path []string) error {
 			return err
 		}
 
+		if delim, ok := t.(json.Delim); ok {
+			if delim == '{' {
+				err = checkObject(d, append(path, fmt.Sprint(index)))
+			} else {
+				err = checkArray(d, append(path, fmt.Sprint(index)))
+			}
+
+			if err != nil {
+				return err
+			}
+
+			index++
+			continue
+		}
+
 		valStr := fmt.Sprintf("%v", t)
 
 		if values[valStr] {
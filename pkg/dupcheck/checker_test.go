@@ -17,6 +17,14 @@ func TestCheckDuplicatesInJSON(t *testing.T) {
 	err = CheckDuplicatesInJSON(d, nil)
 	require.NoError(t, err)
 
+	d = json.NewDecoder(strings.NewReader("[{\"test\":100}, {\"test\":200}]"))
+	err = CheckDuplicatesInJSON(d, nil)
+	require.NoError(t, err)
+
+	d = json.NewDecoder(strings.NewReader("[{\"test\":100, \"t\":[1, 1]}, {\"test\":200}]"))
+	err = CheckDuplicatesInJSON(d, nil)
+	require.Error(t, err)
+
 	d = json.NewDecoder(strings.NewReader("{\"test\":100, \"test\":200}"))
 	err = CheckDuplicatesInJSON(d, nil)
 	require.Error(t, err)
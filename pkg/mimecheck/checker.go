@@ -1,6 +1,9 @@
 package mimecheck
 
-import "net/http"
+import (
+	"mime"
+	"net/http"
+)
 
 func IsJSONContentTypeCorrect(r *http.Request) bool {
 	if len(r.Header.Values("Content-Type")) == 0 {
@@ -19,3 +22,20 @@ func IsJSONContentTypeCorrect(r *http.Request) bool {
 
 	return true
 }
+
+func MatchContentType(r *http.Request, allowedMediaTypes ...string) (string, bool) {
+	for _, contentType := range r.Header.Values("Content-Type") {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			continue
+		}
+
+		for _, allowedMediaType := range allowedMediaTypes {
+			if mediaType == allowedMediaType {
+				return mediaType, true
+			}
+		}
+	}
+
+	return "", false
+}
@@ -23,3 +23,29 @@ func TestIsJSONContentTypeCorrect(t *testing.T) {
 	isCorrect = IsJSONContentTypeCorrect(r)
 	require.False(t, isCorrect)
 }
+
+func TestMatchContentType(t *testing.T) {
+	r, err := http.NewRequest("POST", "", bytes.NewReader([]byte("")))
+	require.NoError(t, err)
+
+	_, ok := MatchContentType(r, "text/x-shellscript", "multipart/form-data")
+	require.False(t, ok)
+
+	r.Header.Set("Content-Type", "multipart/form-data; boundary=abc")
+	mediaType, ok := MatchContentType(r, "text/x-shellscript", "multipart/form-data")
+	require.True(t, ok)
+	require.Equal(t, "multipart/form-data", mediaType)
+
+	r.Header.Set("Content-Type", "text/x-shellscript")
+	mediaType, ok = MatchContentType(r, "text/x-shellscript", "multipart/form-data")
+	require.True(t, ok)
+	require.Equal(t, "text/x-shellscript", mediaType)
+
+	r.Header.Set("Content-Type", "application/json")
+	_, ok = MatchContentType(r, "text/x-shellscript", "multipart/form-data")
+	require.False(t, ok)
+
+	r.Header.Set("Content-Type", ";;;")
+	_, ok = MatchContentType(r, "text/x-shellscript", "multipart/form-data")
+	require.False(t, ok)
+}
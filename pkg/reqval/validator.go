@@ -32,3 +32,12 @@ func ValidateJSONRequest(r *http.Request) error {
 
 	return nil
 }
+
+func ValidateScriptRequest(r *http.Request) (string, error) {
+	mediaType, ok := mimecheck.MatchContentType(r, "text/x-shellscript", "multipart/form-data")
+	if !ok {
+		return "", appErrors.ErrWrongMIME
+	}
+
+	return mediaType, nil
+}
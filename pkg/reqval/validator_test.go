@@ -53,3 +53,22 @@ func TestValidate(t *testing.T) {
 	err = ValidateJSONRequest(r)
 	require.Error(t, err)
 }
+
+func TestValidateScriptRequest(t *testing.T) {
+	r, err := http.NewRequest("POST", "/", strings.NewReader("echo 1"))
+	require.NoError(t, err)
+
+	r.Header.Set("Content-Type", "application/json")
+	_, err = ValidateScriptRequest(r)
+	require.Error(t, err)
+
+	r.Header.Set("Content-Type", "text/x-shellscript")
+	mediaType, err := ValidateScriptRequest(r)
+	require.NoError(t, err)
+	require.Equal(t, "text/x-shellscript", mediaType)
+
+	r.Header.Set("Content-Type", "multipart/form-data; boundary=abc")
+	mediaType, err = ValidateScriptRequest(r)
+	require.NoError(t, err)
+	require.Equal(t, "multipart/form-data", mediaType)
+}